@@ -1,48 +1,352 @@
 package main
 
 import (
-	"log"
-	"net/http"
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
 
+	"pr-review-manager/internal/auth"
+	"pr-review-manager/internal/config"
+	"pr-review-manager/internal/domain"
+	"pr-review-manager/internal/github"
 	"pr-review-manager/internal/handler"
+	"pr-review-manager/internal/jobs"
+	"pr-review-manager/internal/logging"
+	"pr-review-manager/internal/metrics"
 	"pr-review-manager/internal/repository"
 	"pr-review-manager/internal/router"
+	"pr-review-manager/internal/server"
 	"pr-review-manager/internal/service"
+	"pr-review-manager/internal/tracing"
 	"pr-review-manager/pkg/database"
 )
 
 func main() {
-	cfg := database.LoadConfigFromEnv()
+	args := os.Args[1:]
+	if len(args) > 0 && args[0] == "config" {
+		os.Exit(configCommand(args[1:]))
+	}
+	if len(args) > 0 && args[0] == "apikey" {
+		os.Exit(apiKeyCommand(args[1:]))
+	}
+	if len(args) > 0 && args[0] == "org" {
+		os.Exit(orgCommand(args[1:]))
+	}
+
+	cfg, err := loadConfig(args)
+	if err != nil {
+		os.Exit(2)
+	}
+
+	logger, err := logging.New(cfg.Log, os.Stdout)
+	if err != nil {
+		slog.Error("Failed to set up logging", "error", err)
+		os.Exit(1)
+	}
+	slog.SetDefault(logger)
+
+	if err := run(cfg); err != nil {
+		slog.Error("Server stopped with error", "error", err)
+		os.Exit(1)
+	}
+	slog.Info("Server stopped")
+}
+
+// configCommand обрабатывает подкоманду "config print": печатает действующую конфигурацию с замаскированными секретами
+func configCommand(args []string) int {
+	if len(args) == 0 || args[0] != "print" {
+		fmt.Fprintln(os.Stderr, "usage: server config print [flags]")
+		return 2
+	}
+
+	cfg, err := loadConfig(args[1:])
+	if err != nil {
+		return 2
+	}
+	if err := cfg.Print(os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// apiKeyCommand создаёт ключ напрямую в БД: так выпускается первый ключ с team:admin, когда через API это ещё невозможно
+func apiKeyCommand(args []string) int {
+	usage := "usage: server apikey create [-org ORG_ID] NAME SCOPE[,SCOPE...] [flags]"
+	if len(args) == 0 || args[0] != "create" {
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+
+	// Флаги команды идут до позиционных аргументов, флаги конфигурации — после них
+	fs := flag.NewFlagSet("apikey create", flag.ContinueOnError)
+	orgID := fs.String("org", domain.DefaultOrgID, "organization the key belongs to")
+	if err := fs.Parse(args[1:]); err != nil || fs.NArg() < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+	rest := fs.Args()
+
+	db, code := openCommandDB(rest[2:])
+	if db == nil {
+		return code
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if _, err := service.NewOrgService(repository.NewOrgRepository(db)).GetOrg(ctx, *orgID); err != nil {
+		fmt.Fprintf(os.Stderr, "organization %q: %v\n", *orgID, err)
+		return 1
+	}
+
+	apiKeyService := service.NewAPIKeyService(repository.NewAPIKeyRepository(db))
+	key, err := apiKeyService.CreateKey(auth.WithOrg(ctx, *orgID), rest[0], strings.Split(rest[1], ","))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	fmt.Printf("key_id: %s\norg_id: %s\nscopes: %s\nkey: %s\n", key.KeyID, key.OrgID, strings.Join(key.Scopes, ","), key.Key)
+	return 0
+}
+
+// orgCommand управляет организациями; через API это недоступно, так как вызывающий всегда ограничен своей организацией
+func orgCommand(args []string) int {
+	switch {
+	case len(args) >= 2 && args[0] == "create":
+		name := ""
+		flagArgs := args[2:]
+		if len(flagArgs) > 0 && !strings.HasPrefix(flagArgs[0], "-") {
+			name, flagArgs = flagArgs[0], flagArgs[1:]
+		}
+
+		db, code := openCommandDB(flagArgs)
+		if db == nil {
+			return code
+		}
+		defer db.Close()
+
+		org, err := service.NewOrgService(repository.NewOrgRepository(db)).CreateOrg(context.Background(), args[1], name)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Printf("org_id: %s\nname: %s\n", org.OrgID, org.Name)
+		return 0
+
+	case len(args) >= 1 && args[0] == "list":
+		db, code := openCommandDB(args[1:])
+		if db == nil {
+			return code
+		}
+		defer db.Close()
+
+		orgs, err := service.NewOrgService(repository.NewOrgRepository(db)).ListOrgs(context.Background())
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		for _, org := range orgs {
+			fmt.Printf("%s\t%s\n", org.OrgID, org.Name)
+		}
+		return 0
+	}
+
+	fmt.Fprintln(os.Stderr, "usage: server org create ORG_ID [NAME] [flags] | server org list [flags]")
+	return 2
+}
+
+// openCommandDB подключается к БД и применяет миграции для подкоманд CLI; при ошибке возвращает nil и код выхода
+func openCommandDB(args []string) (*sql.DB, int) {
+	cfg, err := loadConfig(args)
+	if err != nil {
+		return nil, 2
+	}
+
+	db, err := database.Connect(cfg.Database)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return nil, 1
+	}
+
+	if err := database.RunMigrations(db, cfg.Database.MigrationsPath); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		db.Close()
+		return nil, 1
+	}
+	return db, 0
+}
 
-	db, err := database.Connect(cfg)
+func loadConfig(args []string) (*config.Config, error) {
+	cfg, err := config.Load(args, os.LookupEnv)
+	if errors.Is(err, flag.ErrHelp) {
+		config.Usage(os.Stderr)
+		return nil, err
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// run возвращает ошибку вместо os.Exit, чтобы отложенные закрытия БД и выгрузка трейсов успели выполниться
+func run(cfg *config.Config) error {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing)
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		return fmt.Errorf("failed to set up tracing: %w", err)
+	}
+	defer shutdownTracing(context.Background())
+
+	db, err := database.Connect(cfg.Database)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
 	}
 	defer db.Close()
 
-	if err := database.RunMigrations(db, "./migrations"); err != nil {
-		log.Fatalf("Failed to run migrations: %v", err)
+	if err := database.RunMigrations(db, cfg.Database.MigrationsPath); err != nil {
+		return fmt.Errorf("failed to run migrations: %w", err)
+	}
+
+	expectedVersion, err := database.LatestMigrationVersion(cfg.Database.MigrationsPath)
+	if err != nil {
+		return fmt.Errorf("failed to read migrations: %w", err)
 	}
 
 	teamRepo := repository.NewTeamRepository(db)
 	userRepo := repository.NewUserRepository(db)
 	prRepo := repository.NewPRRepository(db)
 	statsRepo := repository.NewStatsRepository(db)
+	healthRepo := repository.NewHealthRepository(db)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	orgRepo := repository.NewOrgRepository(db)
+	idempotencyRepo := repository.NewIdempotencyRepository(db)
+	subscriptionRepo := repository.NewSubscriptionRepository(db)
+
+	subscriptionService := service.NewSubscriptionService(subscriptionRepo, service.DeliveryOptions{
+		Timeout:              cfg.Webhooks.Delivery.Timeout,
+		MaxAttempts:          cfg.Webhooks.Delivery.MaxAttempts,
+		RetryBackoff:         cfg.Webhooks.Delivery.RetryBackoff,
+		MaxRetryBackoff:      cfg.Webhooks.Delivery.MaxRetryBackoff,
+		AllowPrivateNetworks: cfg.Webhooks.Delivery.AllowPrivateNetworks,
+	})
+	userService := service.NewUserService(userRepo, prRepo, subscriptionService)
+
+	// Синхронизация ревьюверов с GitHub работает, только если задан хотя бы один токен
+	var reviewerSync service.ReviewerSync
+	var reviewerSyncJob *jobs.ReviewerSyncJob
+	if cfg.GitHub.Enabled() {
+		reviewerSyncJob = jobs.NewReviewerSyncJob(github.NewClient(cfg.GitHub), userService)
+		reviewerSync = reviewerSyncJob
+	}
+
+	teamService := service.NewTeamService(teamRepo, userRepo, prRepo, reviewerSync, subscriptionService)
+	prService := service.NewPRService(prRepo, userRepo, reviewerSync, subscriptionService)
+	statsService := service.NewStatsService(statsRepo, cfg.Stats.Fairness.GiniThreshold)
+	healthService := service.NewHealthService(healthRepo, expectedVersion, cfg.Health.ReadinessTimeout)
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo)
+	orgService := service.NewOrgService(orgRepo)
+	idempotencyService := service.NewIdempotencyService(idempotencyRepo, cfg.Idempotency.TTL)
+	webhookService := service.NewWebhookService(prService, userRepo)
+
+	var verifier *auth.JWTVerifier
+	if cfg.Auth.JWT.Enabled() {
+		verifier, err = auth.NewJWTVerifier(ctx, cfg.Auth.JWT)
+		if err != nil {
+			return fmt.Errorf("failed to set up bearer token verification: %w", err)
+		}
+	}
+	authService := service.NewAuthService(apiKeyService, userRepo, verifier)
+
+	metrics.RegisterDB(db, statsRepo)
+
+	// Фоновые задания останавливаются отдельно от сервера, чтобы дождаться их до закрытия БД
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
 
-	teamService := service.NewTeamService(teamRepo, userRepo, prRepo)
-	userService := service.NewUserService(userRepo, prRepo)
-	prService := service.NewPRService(prRepo, userRepo)
-	statsService := service.NewStatsService(statsRepo)
+	snapshotJob := jobs.NewSnapshotJob(statsService, orgService, cfg.Jobs.SnapshotInterval)
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		snapshotJob.Run(workersCtx)
+	}()
+
+	if reviewerSyncJob != nil {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			reviewerSyncJob.Run(workersCtx)
+		}()
+	}
+
+	cleanupJob := jobs.NewIdempotencyCleanupJob(idempotencyService, cfg.Jobs.IdempotencyCleanupInterval)
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		cleanupJob.Run(workersCtx)
+	}()
+
+	deliveryJob := jobs.NewWebhookDeliveryJob(subscriptionService, cfg.Jobs.WebhookDeliveryInterval, cfg.Webhooks.Delivery.Retention)
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		deliveryJob.Run(workersCtx)
+	}()
 
 	teamHandler := handler.NewTeamHandler(teamService)
 	userHandler := handler.NewUserHandler(userService)
 	prHandler := handler.NewPRHandler(prService)
 	statsHandler := handler.NewStatsHandler(statsService)
+	healthHandler := handler.NewHealthHandler(healthService)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
+	subscriptionHandler := handler.NewSubscriptionHandler(subscriptionService)
+	authenticator := handler.NewAuthenticator(authService, cfg.Auth.Enabled, cfg.Auth.TrustedUserHeader, cfg.Auth.TrustedOrgHeader)
+	if !cfg.Auth.Enabled {
+		slog.Warn("API key authentication is disabled")
+	}
 
-	r := router.NewRouter(teamHandler, userHandler, prHandler, statsHandler)
+	rateLimiter := handler.NewRateLimiter(cfg.RateLimit)
+	idempotency := handler.NewIdempotency(idempotencyService)
+	githubWebhookHandler := handler.NewGitHubWebhookHandler(webhookService, cfg.Webhooks.GitHub.Secret, cfg.Webhooks.GitHub.OrgID)
+	gitlabWebhookHandler := handler.NewGitLabWebhookHandler(webhookService, cfg.Webhooks.GitLab.Secret, cfg.Webhooks.GitLab.OrgID)
 
-	log.Println("Server starting on :8080")
-	if err := http.ListenAndServe(":8080", r); err != nil {
-		log.Fatalf("Server failed to start: %v", err)
+	r := router.NewRouter(teamHandler, userHandler, prHandler, statsHandler, healthHandler, apiKeyHandler, authenticator, rateLimiter, idempotency, githubWebhookHandler, gitlabWebhookHandler, subscriptionHandler)
+
+	// Если один из серверов не поднялся, останавливается и второй
+	serveCtx, stopServing := context.WithCancel(ctx)
+	defer stopServing()
+
+	metricsCfg := cfg.Server
+	metricsCfg.Addr = cfg.Server.MetricsAddr
+	metricsErr := make(chan error, 1)
+	go func() {
+		err := server.Run(serveCtx, server.New(metricsCfg, router.NewInternalRouter()), cfg.Server.ShutdownTimeout)
+		if err != nil {
+			stopServing()
+		}
+		metricsErr <- err
+	}()
+
+	srv := server.New(cfg.Server, r)
+	serveErr := server.Run(serveCtx, srv, cfg.Server.ShutdownTimeout)
+	stopServing()
+	if err := <-metricsErr; err != nil && serveErr == nil {
+		serveErr = fmt.Errorf("metrics server: %w", err)
 	}
+
+	stopWorkers()
+	workers.Wait()
+
+	return serveErr
 }
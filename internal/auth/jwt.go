@@ -0,0 +1,187 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+)
+
+const (
+	clockLeeway = 30 * time.Second
+	// minRefetchInterval ограничивает повторную загрузку JWKS при токенах с неизвестным kid
+	minRefetchInterval = time.Minute
+)
+
+var signatureAlgorithms = []jose.SignatureAlgorithm{
+	jose.RS256, jose.RS384, jose.RS512,
+	jose.PS256, jose.PS384, jose.PS512,
+	jose.ES256, jose.ES384, jose.ES512,
+	jose.EdDSA,
+}
+
+type JWTConfig struct {
+	JWKSFile            string        `yaml:"jwks_file"`
+	JWKSURL             string        `yaml:"jwks_url"`
+	JWKSRefreshInterval time.Duration `yaml:"jwks_refresh_interval"`
+	Issuer              string        `yaml:"issuer"`
+	Audience            string        `yaml:"audience"`
+	UserClaim           string        `yaml:"user_claim"`
+	OrgClaim            string        `yaml:"org_claim"`
+}
+
+// Enabled сообщает, настроен ли источник ключей для проверки bearer-токенов
+func (c JWTConfig) Enabled() bool {
+	return c.JWKSFile != "" || c.JWKSURL != ""
+}
+
+// JWTVerifier проверяет подпись и claims токенов по набору ключей OIDC-провайдера.
+// Ключи из URL перечитываются по истечении JWKSRefreshInterval и при появлении неизвестного kid
+type JWTVerifier struct {
+	cfg    JWTConfig
+	client *http.Client
+
+	mu        sync.RWMutex
+	keys      jose.JSONWebKeySet
+	fetchedAt time.Time
+}
+
+func NewJWTVerifier(ctx context.Context, cfg JWTConfig) (*JWTVerifier, error) {
+	v := &JWTVerifier{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+	if err := v.loadKeys(ctx); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Verify возвращает значения claim'ов UserClaim и OrgClaim из действительного токена.
+// Организация пуста, только если OrgClaim не задан; токен без заданного OrgClaim отклоняется,
+// иначе пользователь другой организации попал бы в организацию по умолчанию
+func (v *JWTVerifier) Verify(ctx context.Context, raw string) (userID, orgID string, err error) {
+	token, err := jwt.ParseSigned(raw, signatureAlgorithms)
+	if err != nil {
+		return "", "", fmt.Errorf("malformed token: %w", err)
+	}
+
+	keys := v.keySet(ctx, token.Headers[0].KeyID)
+
+	var claims jwt.Claims
+	var custom map[string]interface{}
+	if err := token.Claims(&keys, &claims, &custom); err != nil {
+		return "", "", fmt.Errorf("invalid signature: %w", err)
+	}
+
+	if claims.Expiry == nil {
+		return "", "", errors.New("token has no exp claim")
+	}
+	expected := jwt.Expected{Issuer: v.cfg.Issuer, Time: time.Now()}
+	if v.cfg.Audience != "" {
+		expected.AnyAudience = jwt.Audience{v.cfg.Audience}
+	}
+	if err := claims.ValidateWithLeeway(expected, clockLeeway); err != nil {
+		return "", "", err
+	}
+
+	userID, _ = custom[v.cfg.UserClaim].(string)
+	if userID == "" {
+		return "", "", fmt.Errorf("token has no %q claim", v.cfg.UserClaim)
+	}
+	if v.cfg.OrgClaim != "" {
+		orgID, _ = custom[v.cfg.OrgClaim].(string)
+		if orgID == "" {
+			return "", "", fmt.Errorf("token has no %q claim", v.cfg.OrgClaim)
+		}
+	}
+	return userID, orgID, nil
+}
+
+func (v *JWTVerifier) keySet(ctx context.Context, kid string) jose.JSONWebKeySet {
+	v.mu.RLock()
+	keys, fetchedAt := v.keys, v.fetchedAt
+	v.mu.RUnlock()
+
+	if v.cfg.JWKSURL == "" {
+		return keys
+	}
+
+	age := time.Since(fetchedAt)
+	stale := v.cfg.JWKSRefreshInterval > 0 && age > v.cfg.JWKSRefreshInterval
+	rotated := len(keys.Key(kid)) == 0 && age > minRefetchInterval
+	if !stale && !rotated {
+		return keys
+	}
+
+	// Отметка времени до загрузки не даёт параллельным запросам и недоступному провайдеру
+	// вызывать загрузку на каждый токен
+	v.mu.Lock()
+	if !v.fetchedAt.Equal(fetchedAt) {
+		keys = v.keys
+		v.mu.Unlock()
+		return keys
+	}
+	v.fetchedAt = time.Now()
+	v.mu.Unlock()
+
+	// При недоступности провайдера продолжаем работать с ранее загруженными ключами
+	if err := v.loadKeys(ctx); err != nil {
+		return keys
+	}
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.keys
+}
+
+func (v *JWTVerifier) loadKeys(ctx context.Context) error {
+	var data []byte
+	var err error
+	if v.cfg.JWKSFile != "" {
+		data, err = os.ReadFile(v.cfg.JWKSFile)
+	} else {
+		data, err = v.fetch(ctx)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load JWKS: %w", err)
+	}
+
+	var keys jose.JSONWebKeySet
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return fmt.Errorf("failed to parse JWKS: %w", err)
+	}
+	if len(keys.Keys) == 0 {
+		return errors.New("JWKS contains no keys")
+	}
+
+	v.mu.Lock()
+	v.keys = keys
+	v.fetchedAt = time.Now()
+	v.mu.Unlock()
+	return nil
+}
+
+func (v *JWTVerifier) fetch(ctx context.Context) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.cfg.JWKSURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d from %s", resp.StatusCode, v.cfg.JWKSURL)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"context"
+	"errors"
+
+	"pr-review-manager/internal/domain"
+)
+
+// ErrNoOrg — в контексте нет организации: вызов прошёл мимо аутентификации, вебхука или фонового задания,
+// которые её задают. Запрос завершается ошибкой, а не попадает в организацию по умолчанию
+var ErrNoOrg = errors.New("organization is not set in context")
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, principal *domain.Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext возвращает вызывающего либо nil, если аутентификация выключена
+// или вызов пришёл не из HTTP (CLI, фоновые задания)
+func PrincipalFromContext(ctx context.Context) *domain.Principal {
+	principal, _ := ctx.Value(principalKey{}).(*domain.Principal)
+	return principal
+}
+
+type orgKey struct{}
+
+// WithOrg задаёт организацию, в пределах которой выполняются запросы к репозиториям
+func WithOrg(ctx context.Context, orgID string) context.Context {
+	return context.WithValue(ctx, orgKey{}, orgID)
+}
+
+// OrgFromContext возвращает организацию вызывающего; false, если она не задана
+func OrgFromContext(ctx context.Context) (string, bool) {
+	orgID, _ := ctx.Value(orgKey{}).(string)
+	return orgID, orgID != ""
+}
+
+// RequireOrg возвращает организацию вызывающего либо ErrNoOrg
+func RequireOrg(ctx context.Context) (string, error) {
+	orgID, ok := OrgFromContext(ctx)
+	if !ok {
+		return "", ErrNoOrg
+	}
+	return orgID, nil
+}
@@ -0,0 +1,564 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"pr-review-manager/internal/auth"
+	"pr-review-manager/internal/github"
+	"pr-review-manager/internal/logging"
+	"pr-review-manager/internal/ratelimit"
+	"pr-review-manager/internal/server"
+	"pr-review-manager/internal/tracing"
+	"pr-review-manager/pkg/database"
+)
+
+const maskedValue = "******"
+
+type Config struct {
+	Server      server.Config     `yaml:"server"`
+	Database    database.Config   `yaml:"database"`
+	Log         logging.Config    `yaml:"log"`
+	Tracing     tracing.Config    `yaml:"tracing"`
+	Jobs        JobsConfig        `yaml:"jobs"`
+	Stats       StatsConfig       `yaml:"stats"`
+	Health      HealthConfig      `yaml:"health"`
+	Auth        AuthConfig        `yaml:"auth"`
+	RateLimit   ratelimit.Config  `yaml:"rate_limit"`
+	Idempotency IdempotencyConfig `yaml:"idempotency"`
+	Webhooks    WebhooksConfig    `yaml:"webhooks"`
+	GitHub      github.Config     `yaml:"github"`
+}
+
+type JobsConfig struct {
+	SnapshotInterval           time.Duration `yaml:"snapshot_interval"`
+	IdempotencyCleanupInterval time.Duration `yaml:"idempotency_cleanup_interval"`
+	WebhookDeliveryInterval    time.Duration `yaml:"webhook_delivery_interval"`
+}
+
+// StatsConfig: Fairness.GiniThreshold — порог коэффициента Джини, выше которого /stats/fairness
+// помечает команду перекошенной, если в запросе не передан threshold
+type StatsConfig struct {
+	Fairness FairnessConfig `yaml:"fairness"`
+}
+
+type FairnessConfig struct {
+	GiniThreshold float64 `yaml:"gini_threshold"`
+}
+
+type HealthConfig struct {
+	ReadinessTimeout time.Duration `yaml:"readiness_timeout"`
+}
+
+// IdempotencyConfig: TTL — сколько хранится ответ на запрос с Idempotency-Key
+type IdempotencyConfig struct {
+	TTL time.Duration `yaml:"ttl"`
+}
+
+type WebhooksConfig struct {
+	GitHub   WebhookConfig         `yaml:"github"`
+	GitLab   WebhookConfig         `yaml:"gitlab"`
+	Delivery WebhookDeliveryConfig `yaml:"delivery"`
+}
+
+// WebhookConfig: Secret — секрет подписи GitHub или токен GitLab, пустой выключает вебхук;
+// OrgID — организация, в которую попадают его события, обязательна для включённого вебхука
+type WebhookConfig struct {
+	Secret string `yaml:"secret"`
+	OrgID  string `yaml:"org_id"`
+}
+
+// WebhookDeliveryConfig — отправка исходящих вебхуков подписчикам. Retention — сколько
+// завершённые доставки хранятся в журнале, AllowPrivateNetworks разрешает подписчиков во внутренней сети
+type WebhookDeliveryConfig struct {
+	Timeout              time.Duration `yaml:"timeout"`
+	MaxAttempts          int           `yaml:"max_attempts"`
+	RetryBackoff         time.Duration `yaml:"retry_backoff"`
+	MaxRetryBackoff      time.Duration `yaml:"max_retry_backoff"`
+	Retention            time.Duration `yaml:"retention"`
+	AllowPrivateNetworks bool          `yaml:"allow_private_networks"`
+}
+
+type AuthConfig struct {
+	Enabled           bool           `yaml:"enabled"`
+	TrustedUserHeader string         `yaml:"trusted_user_header"`
+	TrustedOrgHeader  string         `yaml:"trusted_org_header"`
+	JWT               auth.JWTConfig `yaml:"jwt"`
+}
+
+func Default() *Config {
+	return &Config{
+		Server: server.Config{
+			Addr:              ":8080",
+			MetricsAddr:       ":9090",
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       15 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       120 * time.Second,
+			ShutdownTimeout:   30 * time.Second,
+		},
+		Database: database.Config{
+			Host:                 "localhost",
+			Port:                 "5432",
+			User:                 "pruser",
+			Password:             "prpass",
+			DBName:               "pr_review_db",
+			SSLMode:              "disable",
+			MaxOpenConns:         25,
+			MaxIdleConns:         5,
+			ConnMaxLifetime:      5 * time.Minute,
+			ConnectRetries:       10,
+			ConnectRetryInterval: 2 * time.Second,
+			MigrationsPath:       "./migrations",
+		},
+		Log: logging.Config{
+			Level:  "info",
+			Format: logging.FormatJSON,
+		},
+		Tracing: tracing.Config{
+			Exporter:    tracing.ExporterNone,
+			ServiceName: "pr-review-manager",
+		},
+		Jobs: JobsConfig{
+			SnapshotInterval:           time.Hour,
+			IdempotencyCleanupInterval: 10 * time.Minute,
+			WebhookDeliveryInterval:    5 * time.Second,
+		},
+		Stats: StatsConfig{
+			Fairness: FairnessConfig{GiniThreshold: 0.3},
+		},
+		Health: HealthConfig{
+			ReadinessTimeout: 2 * time.Second,
+		},
+		RateLimit: ratelimit.Config{
+			Enabled: true,
+			Read:    ratelimit.Limit{RPS: 20, Burst: 40},
+			Write:   ratelimit.Limit{RPS: 5, Burst: 10},
+		},
+		Idempotency: IdempotencyConfig{
+			TTL: 24 * time.Hour,
+		},
+		Webhooks: WebhooksConfig{
+			Delivery: WebhookDeliveryConfig{
+				Timeout:         10 * time.Second,
+				MaxAttempts:     10,
+				RetryBackoff:    30 * time.Second,
+				MaxRetryBackoff: time.Hour,
+				Retention:       30 * 24 * time.Hour,
+			},
+		},
+		GitHub: github.Config{
+			BaseURL:      github.DefaultBaseURL,
+			Timeout:      10 * time.Second,
+			Retries:      3,
+			RetryBackoff: 500 * time.Millisecond,
+		},
+		Auth: AuthConfig{
+			Enabled: true,
+			JWT: auth.JWTConfig{
+				JWKSRefreshInterval: time.Hour,
+				UserClaim:           "sub",
+				OrgClaim:            "org",
+			},
+		},
+	}
+}
+
+// setting связывает поле конфигурации с переменной окружения и флагом командной строки
+type setting struct {
+	flag  string
+	env   string
+	usage string
+	set   func(string) error
+}
+
+func settings(cfg *Config) []setting {
+	return []setting{
+		{"http-addr", "HTTP_ADDR", "address to listen on", stringVar(&cfg.Server.Addr)},
+		{"metrics-addr", "METRICS_ADDR", "internal address for /metrics", stringVar(&cfg.Server.MetricsAddr)},
+		{"http-read-header-timeout", "HTTP_READ_HEADER_TIMEOUT", "time to read request headers", durationVar(&cfg.Server.ReadHeaderTimeout)},
+		{"http-read-timeout", "HTTP_READ_TIMEOUT", "time to read the whole request", durationVar(&cfg.Server.ReadTimeout)},
+		{"http-write-timeout", "HTTP_WRITE_TIMEOUT", "time to write the response", durationVar(&cfg.Server.WriteTimeout)},
+		{"http-idle-timeout", "HTTP_IDLE_TIMEOUT", "keep-alive idle timeout", durationVar(&cfg.Server.IdleTimeout)},
+		{"http-shutdown-timeout", "HTTP_SHUTDOWN_TIMEOUT", "time to drain requests on shutdown", durationVar(&cfg.Server.ShutdownTimeout)},
+
+		{"db-host", "DB_HOST", "database host", stringVar(&cfg.Database.Host)},
+		{"db-port", "DB_PORT", "database port", stringVar(&cfg.Database.Port)},
+		{"db-user", "DB_USER", "database user", stringVar(&cfg.Database.User)},
+		{"db-password", "DB_PASSWORD", "database password", stringVar(&cfg.Database.Password)},
+		{"db-name", "DB_NAME", "database name", stringVar(&cfg.Database.DBName)},
+		{"db-sslmode", "DB_SSLMODE", "database sslmode", stringVar(&cfg.Database.SSLMode)},
+		{"db-max-open-conns", "DB_MAX_OPEN_CONNS", "maximum open connections in the pool", intVar(&cfg.Database.MaxOpenConns)},
+		{"db-max-idle-conns", "DB_MAX_IDLE_CONNS", "maximum idle connections in the pool", intVar(&cfg.Database.MaxIdleConns)},
+		{"db-conn-max-lifetime", "DB_CONN_MAX_LIFETIME", "maximum connection lifetime", durationVar(&cfg.Database.ConnMaxLifetime)},
+		{"db-connect-retries", "DB_CONNECT_RETRIES", "connection attempts on startup", intVar(&cfg.Database.ConnectRetries)},
+		{"db-connect-retry-interval", "DB_CONNECT_RETRY_INTERVAL", "delay between connection attempts", durationVar(&cfg.Database.ConnectRetryInterval)},
+		{"migrations-path", "MIGRATIONS_PATH", "directory with SQL migrations", stringVar(&cfg.Database.MigrationsPath)},
+
+		{"log-level", "LOG_LEVEL", "debug, info, warn or error", stringVar(&cfg.Log.Level)},
+		{"log-format", "LOG_FORMAT", "json or text", stringVar(&cfg.Log.Format)},
+
+		{"otel-traces-exporter", "OTEL_TRACES_EXPORTER", "none, stdout or otlp", stringVar(&cfg.Tracing.Exporter)},
+		{"otel-exporter-otlp-endpoint", "OTEL_EXPORTER_OTLP_ENDPOINT", "OTLP/HTTP collector URL", stringVar(&cfg.Tracing.OTLPEndpoint)},
+		{"otel-service-name", "OTEL_SERVICE_NAME", "service name in traces", stringVar(&cfg.Tracing.ServiceName)},
+
+		{"snapshot-interval", "STATS_SNAPSHOT_INTERVAL", "interval between stats snapshots", durationVar(&cfg.Jobs.SnapshotInterval)},
+		{"stats-fairness-gini-threshold", "STATS_FAIRNESS_GINI_THRESHOLD", "default Gini coefficient above which a team is imbalanced", floatVar(&cfg.Stats.Fairness.GiniThreshold)},
+		{"readiness-timeout", "READINESS_TIMEOUT", "timeout of readiness checks", durationVar(&cfg.Health.ReadinessTimeout)},
+
+		{"auth-enabled", "AUTH_ENABLED", "require API keys or bearer tokens on API routes", boolVar(&cfg.Auth.Enabled)},
+		{"auth-trusted-user-header", "AUTH_TRUSTED_USER_HEADER", "header with user_id set by an authenticating proxy", stringVar(&cfg.Auth.TrustedUserHeader)},
+		{"auth-trusted-org-header", "AUTH_TRUSTED_ORG_HEADER", "header with org_id set by an authenticating proxy", stringVar(&cfg.Auth.TrustedOrgHeader)},
+		{"auth-jwt-jwks-file", "AUTH_JWT_JWKS_FILE", "JWKS file to verify bearer tokens", stringVar(&cfg.Auth.JWT.JWKSFile)},
+		{"auth-jwt-jwks-url", "AUTH_JWT_JWKS_URL", "JWKS URL of the OIDC provider", stringVar(&cfg.Auth.JWT.JWKSURL)},
+		{"auth-jwt-jwks-refresh-interval", "AUTH_JWT_JWKS_REFRESH_INTERVAL", "how often to reload JWKS from the URL", durationVar(&cfg.Auth.JWT.JWKSRefreshInterval)},
+		{"auth-jwt-issuer", "AUTH_JWT_ISSUER", "expected iss claim", stringVar(&cfg.Auth.JWT.Issuer)},
+		{"auth-jwt-audience", "AUTH_JWT_AUDIENCE", "expected aud claim", stringVar(&cfg.Auth.JWT.Audience)},
+		{"auth-jwt-user-claim", "AUTH_JWT_USER_CLAIM", "claim holding users.user_id", stringVar(&cfg.Auth.JWT.UserClaim)},
+		{"auth-jwt-org-claim", "AUTH_JWT_ORG_CLAIM", "claim holding organizations.org_id", stringVar(&cfg.Auth.JWT.OrgClaim)},
+
+		{"rate-limit-enabled", "RATE_LIMIT_ENABLED", "limit request rate per API key or client IP", boolVar(&cfg.RateLimit.Enabled)},
+		{"rate-limit-read-rps", "RATE_LIMIT_READ_RPS", "sustained GET requests per second", floatVar(&cfg.RateLimit.Read.RPS)},
+		{"rate-limit-read-burst", "RATE_LIMIT_READ_BURST", "GET requests allowed in a burst", intVar(&cfg.RateLimit.Read.Burst)},
+		{"rate-limit-write-rps", "RATE_LIMIT_WRITE_RPS", "sustained mutating requests per second", floatVar(&cfg.RateLimit.Write.RPS)},
+		{"rate-limit-write-burst", "RATE_LIMIT_WRITE_BURST", "mutating requests allowed in a burst", intVar(&cfg.RateLimit.Write.Burst)},
+		{"rate-limit-routes", "RATE_LIMIT_ROUTES", "per-route limits, e.g. /pullRequest/create=1:5", routesVar(&cfg.RateLimit.Routes)},
+		{"rate-limit-trusted-proxies", "RATE_LIMIT_TRUSTED_PROXIES", "comma-separated proxy CIDRs whose X-Forwarded-For and X-Real-IP are trusted", listVar(&cfg.RateLimit.TrustedProxies)},
+
+		{"idempotency-ttl", "IDEMPOTENCY_TTL", "how long responses to Idempotency-Key requests are kept", durationVar(&cfg.Idempotency.TTL)},
+		{"idempotency-cleanup-interval", "IDEMPOTENCY_CLEANUP_INTERVAL", "interval between deletions of expired idempotency keys", durationVar(&cfg.Jobs.IdempotencyCleanupInterval)},
+
+		{"github-webhook-secret", "GITHUB_WEBHOOK_SECRET", "secret of the GitHub webhook; empty disables /webhooks/github", stringVar(&cfg.Webhooks.GitHub.Secret)},
+		{"github-webhook-org-id", "GITHUB_WEBHOOK_ORG_ID", "organization that receives GitHub webhook events", stringVar(&cfg.Webhooks.GitHub.OrgID)},
+		{"gitlab-webhook-secret", "GITLAB_WEBHOOK_SECRET", "secret token of the GitLab webhook; empty disables /webhooks/gitlab", stringVar(&cfg.Webhooks.GitLab.Secret)},
+		{"gitlab-webhook-org-id", "GITLAB_WEBHOOK_ORG_ID", "organization that receives GitLab webhook events", stringVar(&cfg.Webhooks.GitLab.OrgID)},
+		{"webhook-delivery-interval", "WEBHOOK_DELIVERY_INTERVAL", "interval between checks of the outgoing webhook queue", durationVar(&cfg.Jobs.WebhookDeliveryInterval)},
+		{"webhook-delivery-timeout", "WEBHOOK_DELIVERY_TIMEOUT", "timeout of an outgoing webhook request", durationVar(&cfg.Webhooks.Delivery.Timeout)},
+		{"webhook-delivery-max-attempts", "WEBHOOK_DELIVERY_MAX_ATTEMPTS", "attempts before an outgoing webhook delivery is marked failed", intVar(&cfg.Webhooks.Delivery.MaxAttempts)},
+		{"webhook-delivery-retry-backoff", "WEBHOOK_DELIVERY_RETRY_BACKOFF", "delay before the first retry of an outgoing webhook, doubled each time", durationVar(&cfg.Webhooks.Delivery.RetryBackoff)},
+		{"webhook-delivery-max-retry-backoff", "WEBHOOK_DELIVERY_MAX_RETRY_BACKOFF", "longest delay between outgoing webhook retries", durationVar(&cfg.Webhooks.Delivery.MaxRetryBackoff)},
+		{"webhook-delivery-retention", "WEBHOOK_DELIVERY_RETENTION", "how long finished outgoing webhook deliveries are kept in the log", durationVar(&cfg.Webhooks.Delivery.Retention)},
+		{"webhook-delivery-allow-private-networks", "WEBHOOK_DELIVERY_ALLOW_PRIVATE_NETWORKS", "allow outgoing webhooks to loopback, link-local and private addresses", boolVar(&cfg.Webhooks.Delivery.AllowPrivateNetworks)},
+
+		{"github-api-url", "GITHUB_API_URL", "GitHub REST API base URL", stringVar(&cfg.GitHub.BaseURL)},
+		{"github-token", "GITHUB_TOKEN", "token to request reviewers on GitHub; empty disables the sync", stringVar(&cfg.GitHub.Token)},
+		{"github-repo-tokens", "GITHUB_REPO_TOKENS", "per-repository tokens, e.g. octo-org/api=ghp_xxx,octo-org=ghp_yyy", tokensVar(&cfg.GitHub.Tokens)},
+		{"github-api-timeout", "GITHUB_API_TIMEOUT", "timeout of a GitHub API request", durationVar(&cfg.GitHub.Timeout)},
+		{"github-api-retries", "GITHUB_API_RETRIES", "retries of a failed GitHub API request", intVar(&cfg.GitHub.Retries)},
+		{"github-api-retry-backoff", "GITHUB_API_RETRY_BACKOFF", "initial delay between GitHub API retries, doubled each time", durationVar(&cfg.GitHub.RetryBackoff)},
+	}
+}
+
+func stringVar(p *string) func(string) error {
+	return func(value string) error {
+		*p = value
+		return nil
+	}
+}
+
+func intVar(p *int) func(string) error {
+	return func(value string) error {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		*p = parsed
+		return nil
+	}
+}
+
+func floatVar(p *float64) func(string) error {
+	return func(value string) error {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		*p = parsed
+		return nil
+	}
+}
+
+func routesVar(p *map[string]ratelimit.Limit) func(string) error {
+	return func(value string) error {
+		parsed, err := ratelimit.ParseRoutes(value)
+		if err != nil {
+			return err
+		}
+		*p = parsed
+		return nil
+	}
+}
+
+func listVar(p *[]string) func(string) error {
+	return func(value string) error {
+		*p = nil
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				*p = append(*p, item)
+			}
+		}
+		return nil
+	}
+}
+
+func tokensVar(p *map[string]string) func(string) error {
+	return func(value string) error {
+		parsed, err := github.ParseTokens(value)
+		if err != nil {
+			return err
+		}
+		*p = parsed
+		return nil
+	}
+}
+
+func boolVar(p *bool) func(string) error {
+	return func(value string) error {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		*p = parsed
+		return nil
+	}
+}
+
+func durationVar(p *time.Duration) func(string) error {
+	return func(value string) error {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		*p = parsed
+		return nil
+	}
+}
+
+// flagValue запоминает значение флага, чтобы применить его после файла и окружения
+type flagValue struct {
+	value string
+	set   bool
+}
+
+func (v *flagValue) String() string { return v.value }
+
+func (v *flagValue) Set(value string) error {
+	v.value = value
+	v.set = true
+	return nil
+}
+
+func newFlagSet(all []setting) (*flag.FlagSet, *string, []*flagValue) {
+	fs := flag.NewFlagSet("pr-review-manager", flag.ContinueOnError)
+	configFile := fs.String("config", "", "path to YAML config file (env CONFIG_FILE)")
+	flags := make([]*flagValue, len(all))
+	for i, s := range all {
+		flags[i] = &flagValue{}
+		fs.Var(flags[i], s.flag, s.usage+" (env "+s.env+")")
+	}
+	return fs, configFile, flags
+}
+
+// Load собирает конфигурацию с приоритетом: флаги > переменные окружения > YAML-файл > значения по умолчанию.
+// Путь к файлу задаётся флагом -config или переменной CONFIG_FILE; lookupEnv обычно os.LookupEnv
+func Load(args []string, lookupEnv func(string) (string, bool)) (*Config, error) {
+	cfg := Default()
+	all := settings(cfg)
+
+	fs, configFile, flags := newFlagSet(all)
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+
+	path := *configFile
+	if path == "" {
+		path, _ = lookupEnv("CONFIG_FILE")
+	}
+	if path != "" {
+		if err := loadFile(cfg, path); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, s := range all {
+		value, ok := lookupEnv(s.env)
+		if !ok || value == "" {
+			continue
+		}
+		if err := s.set(value); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", s.env, err)
+		}
+	}
+
+	for i, s := range all {
+		if !flags[i].set {
+			continue
+		}
+		if err := s.set(flags[i].value); err != nil {
+			return nil, fmt.Errorf("invalid -%s: %w", s.flag, err)
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func loadFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// Validate проверяет все поля сразу и возвращает все найденные ошибки
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Server.Addr != "", "server.addr must not be empty")
+	check(c.Server.MetricsAddr != "", "server.metrics_addr must not be empty")
+	check(c.Server.MetricsAddr != c.Server.Addr, "server.metrics_addr must differ from server.addr")
+	check(c.Server.ReadHeaderTimeout > 0, "server.read_header_timeout must be positive")
+	check(c.Server.ReadTimeout > 0, "server.read_timeout must be positive")
+	check(c.Server.WriteTimeout > 0, "server.write_timeout must be positive")
+	check(c.Server.IdleTimeout > 0, "server.idle_timeout must be positive")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout must be positive")
+
+	check(c.Database.Host != "", "database.host must not be empty")
+	port, err := strconv.Atoi(c.Database.Port)
+	check(err == nil && port > 0 && port <= 65535, "database.port must be a number between 1 and 65535, got %q", c.Database.Port)
+	check(c.Database.User != "", "database.user must not be empty")
+	check(c.Database.DBName != "", "database.dbname must not be empty")
+	switch c.Database.SSLMode {
+	case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		check(false, "database.sslmode %q is not supported", c.Database.SSLMode)
+	}
+	check(c.Database.MaxOpenConns > 0, "database.max_open_conns must be positive")
+	check(c.Database.MaxIdleConns >= 0 && c.Database.MaxIdleConns <= c.Database.MaxOpenConns,
+		"database.max_idle_conns must be between 0 and max_open_conns")
+	check(c.Database.ConnMaxLifetime >= 0, "database.conn_max_lifetime must not be negative")
+	check(c.Database.ConnectRetries > 0, "database.connect_retries must be positive")
+	check(c.Database.ConnectRetryInterval >= 0, "database.connect_retry_interval must not be negative")
+	check(c.Database.MigrationsPath != "", "database.migrations_path must not be empty")
+
+	var level slog.Level
+	check(level.UnmarshalText([]byte(c.Log.Level)) == nil, "log.level %q is not supported", c.Log.Level)
+	check(c.Log.Format == logging.FormatJSON || c.Log.Format == logging.FormatText, "log.format %q is not supported", c.Log.Format)
+
+	switch c.Tracing.Exporter {
+	case tracing.ExporterNone, tracing.ExporterStdout, tracing.ExporterOTLP:
+	default:
+		check(false, "tracing.exporter %q is not supported", c.Tracing.Exporter)
+	}
+	check(c.Tracing.ServiceName != "", "tracing.service_name must not be empty")
+
+	check(c.Jobs.SnapshotInterval > 0, "jobs.snapshot_interval must be positive")
+	check(c.Stats.Fairness.GiniThreshold >= 0 && c.Stats.Fairness.GiniThreshold <= 1, "stats.fairness.gini_threshold must be between 0 and 1")
+	check(c.Jobs.IdempotencyCleanupInterval > 0, "jobs.idempotency_cleanup_interval must be positive")
+	check(c.Idempotency.TTL > 0, "idempotency.ttl must be positive")
+	check(c.Health.ReadinessTimeout > 0, "health.readiness_timeout must be positive")
+
+	check(c.Auth.JWT.JWKSFile == "" || c.Auth.JWT.JWKSURL == "", "auth.jwt.jwks_file and auth.jwt.jwks_url are mutually exclusive")
+	check(c.Auth.JWT.UserClaim != "", "auth.jwt.user_claim must not be empty")
+	// Без iss подошёл бы токен любого провайдера, чьи ключи оказались в JWKS
+	check(!c.Auth.JWT.Enabled() || c.Auth.JWT.Issuer != "", "auth.jwt.issuer is required when jwks_file or jwks_url is set")
+
+	// События вебхука не должны молча попадать в организацию по умолчанию
+	check(c.Webhooks.GitHub.Secret == "" || c.Webhooks.GitHub.OrgID != "", "webhooks.github.org_id is required when webhooks.github.secret is set")
+	check(c.Webhooks.GitLab.Secret == "" || c.Webhooks.GitLab.OrgID != "", "webhooks.gitlab.org_id is required when webhooks.gitlab.secret is set")
+	check(c.Jobs.WebhookDeliveryInterval > 0, "jobs.webhook_delivery_interval must be positive")
+	check(c.Webhooks.Delivery.Timeout > 0, "webhooks.delivery.timeout must be positive")
+	check(c.Webhooks.Delivery.MaxAttempts > 0, "webhooks.delivery.max_attempts must be positive")
+	check(c.Webhooks.Delivery.RetryBackoff > 0, "webhooks.delivery.retry_backoff must be positive")
+	check(c.Webhooks.Delivery.MaxRetryBackoff >= c.Webhooks.Delivery.RetryBackoff, "webhooks.delivery.max_retry_backoff must not be less than retry_backoff")
+	check(c.Webhooks.Delivery.Retention > 0, "webhooks.delivery.retention must be positive")
+
+	check(strings.HasPrefix(c.GitHub.BaseURL, "http://") || strings.HasPrefix(c.GitHub.BaseURL, "https://"), "github.base_url must be an http(s) URL, got %q", c.GitHub.BaseURL)
+	check(c.GitHub.Timeout > 0, "github.timeout must be positive")
+	check(c.GitHub.Retries >= 0, "github.retries must not be negative")
+	check(c.GitHub.RetryBackoff >= 0, "github.retry_backoff must not be negative")
+
+	if c.RateLimit.Enabled {
+		check(c.RateLimit.Read.Valid(), "rate_limit.read must have positive rps and burst")
+		check(c.RateLimit.Write.Valid(), "rate_limit.write must have positive rps and burst")
+		for path, limit := range c.RateLimit.Routes {
+			check(strings.HasPrefix(path, "/"), "rate_limit.routes key %q must be a path starting with /", path)
+			check(limit.Valid(), "rate_limit.routes[%s] must have positive rps and burst", path)
+		}
+		_, err := ratelimit.ParseProxies(c.RateLimit.TrustedProxies)
+		check(err == nil, "rate_limit.trusted_proxies: %v", err)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// Masked возвращает копию конфигурации, в которой секреты заменены на звёздочки
+func (c *Config) Masked() *Config {
+	masked := *c
+	masked.Database.Password = mask(c.Database.Password)
+	masked.Webhooks.GitHub.Secret = mask(c.Webhooks.GitHub.Secret)
+	masked.Webhooks.GitLab.Secret = mask(c.Webhooks.GitLab.Secret)
+	masked.GitHub.Token = mask(c.GitHub.Token)
+	if c.GitHub.Tokens != nil {
+		masked.GitHub.Tokens = make(map[string]string, len(c.GitHub.Tokens))
+		for repo, token := range c.GitHub.Tokens {
+			masked.GitHub.Tokens[repo] = mask(token)
+		}
+	}
+	return &masked
+}
+
+func mask(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return maskedValue
+}
+
+// Print выводит действующую конфигурацию в YAML с замаскированными секретами
+func (c *Config) Print(w io.Writer) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(c.Masked()); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// Usage печатает список флагов с соответствующими переменными окружения
+func Usage(w io.Writer) {
+	fs, _, _ := newFlagSet(settings(Default()))
+	fs.SetOutput(w)
+	fs.PrintDefaults()
+}
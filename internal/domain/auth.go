@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"slices"
+	"time"
+)
+
+const (
+	ScopeRead      = "read"
+	ScopePRWrite   = "pr:write"
+	ScopeTeamAdmin = "team:admin"
+)
+
+var Scopes = []string{ScopeRead, ScopePRWrite, ScopeTeamAdmin}
+
+const (
+	RoleAdmin    = "admin"
+	RoleTeamLead = "team_lead"
+	RoleMember   = "member"
+)
+
+var Roles = []string{RoleAdmin, RoleTeamLead, RoleMember}
+
+// RoleScopes — scope пользователя по его роли. team:admin у лида ограничен его командой на уровне политик
+func RoleScopes(role string) []string {
+	switch role {
+	case RoleAdmin, RoleTeamLead:
+		return []string{ScopeRead, ScopePRWrite, ScopeTeamAdmin}
+	default:
+		return []string{ScopeRead, ScopePRWrite}
+	}
+}
+
+type APIKey struct {
+	KeyID      string     `json:"key_id"`
+	OrgID      string     `json:"org_id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Principal — аутентифицированный вызывающий: API-ключ сервиса либо пользователь.
+// OrgID заполнен всегда, Role и TeamName — только для пользователя
+type Principal struct {
+	APIKeyID string
+	UserID   string
+	OrgID    string
+	Role     string
+	TeamName string
+	Scopes   []string
+}
+
+func (p *Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+// CreatedAPIKey содержит открытое значение ключа; оно возвращается только при создании
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
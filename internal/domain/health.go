@@ -0,0 +1,18 @@
+package domain
+
+const (
+	HealthStatusOK          = "ok"
+	HealthStatusUnavailable = "unavailable"
+)
+
+type HealthReport struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentHealth `json:"components,omitempty"`
+}
+
+type ComponentHealth struct {
+	Status    string                 `json:"status"`
+	Error     string                 `json:"error,omitempty"`
+	LatencyMs int64                  `json:"latency_ms"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+}
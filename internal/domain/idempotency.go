@@ -0,0 +1,16 @@
+package domain
+
+import "time"
+
+// IdempotencyRecord — сохранённый результат запроса с заголовком Idempotency-Key.
+// Ключ уникален в пределах организации и вызывающего; StatusCode 0 — запрос ещё выполняется
+type IdempotencyRecord struct {
+	OrgID        string
+	Caller       string
+	Key          string
+	RequestHash  string
+	StatusCode   int
+	ResponseBody []byte
+	CreatedAt    time.Time
+	ExpiresAt    time.Time
+}
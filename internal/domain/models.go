@@ -7,6 +7,32 @@ type User struct {
 	Username string `json:"username"`
 	TeamName string `json:"team_name"`
 	IsActive bool   `json:"is_active"`
+	Role     string `json:"role,omitempty"`
+}
+
+type UserWithLoad struct {
+	UserID       string `json:"user_id"`
+	Username     string `json:"username"`
+	TeamName     string `json:"team_name"`
+	IsActive     bool   `json:"is_active"`
+	OpenReviews  int    `json:"open_reviews"`
+	TotalReviews int    `json:"total_reviews"`
+}
+
+type UserListFilter struct {
+	TeamName       string
+	IsActive       *bool
+	MinOpenReviews *int
+	MaxOpenReviews *int
+	Limit          int
+	Offset         int
+}
+
+type UserPage struct {
+	Users  []UserWithLoad `json:"users"`
+	Total  int            `json:"total"`
+	Limit  int            `json:"limit"`
+	Offset int            `json:"offset"`
 }
 
 type Team struct {
@@ -28,16 +54,59 @@ type PullRequest struct {
 	AssignedReviewers []string   `json:"assigned_reviewers"`
 	CreatedAt         *time.Time `json:"createdAt,omitempty"`
 	MergedAt          *time.Time `json:"mergedAt,omitempty"`
+	MergedBy          string     `json:"mergedBy,omitempty"`
+}
+
+type PullRequestDetails struct {
+	PullRequestID      string          `json:"pull_request_id"`
+	PullRequestName    string          `json:"pull_request_name"`
+	Status             string          `json:"status"`
+	Author             PRParticipant   `json:"author"`
+	AssignedReviewers  []PRParticipant `json:"assigned_reviewers"`
+	CreatedAt          *time.Time      `json:"createdAt,omitempty"`
+	MergedAt           *time.Time      `json:"mergedAt,omitempty"`
+	MergedBy           string          `json:"mergedBy,omitempty"`
+	AgeSeconds         int64           `json:"age_seconds"`
+	TimeToMergeSeconds *int64          `json:"time_to_merge_seconds,omitempty"`
+}
+
+type PRParticipant struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+	TeamName string `json:"team_name"`
+	IsActive bool   `json:"is_active"`
 }
 
 type PullRequestShort struct {
-	PullRequestID   string `json:"pull_request_id"`
-	PullRequestName string `json:"pull_request_name"`
-	AuthorID        string `json:"author_id"`
-	Status          string `json:"status"`
+	PullRequestID   string     `json:"pull_request_id"`
+	PullRequestName string     `json:"pull_request_name"`
+	AuthorID        string     `json:"author_id"`
+	AuthorUsername  string     `json:"author_username"`
+	Status          string     `json:"status"`
+	CreatedAt       *time.Time `json:"createdAt,omitempty"`
+}
+
+type ReviewFilter struct {
+	UserID string
+	Status string
+	After  *ReviewCursor
+	Limit  int
+}
+
+// ReviewCursor указывает на последний PR предыдущей страницы (сортировка по created_at DESC, pull_request_id DESC)
+type ReviewCursor struct {
+	CreatedAt     time.Time
+	PullRequestID string
+}
+
+type ReviewPage struct {
+	UserID       string             `json:"user_id"`
+	PullRequests []PullRequestShort `json:"pull_requests"`
+	NextCursor   string             `json:"next_cursor,omitempty"`
 }
 
 const (
 	StatusOpen   = "OPEN"
 	StatusMerged = "MERGED"
+	StatusAll    = "ALL"
 )
@@ -0,0 +1,19 @@
+package domain
+
+import "time"
+
+// DefaultOrgID — организация, в которую попадают данные, созданные до разделения по организациям,
+// и запросы без аутентифицированного вызывающего
+const DefaultOrgID = "default"
+
+type Organization struct {
+	OrgID     string    `json:"org_id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// OrgKey — значение, уникальное только в пределах организации (команда, пользователь)
+type OrgKey struct {
+	OrgID string
+	ID    string
+}
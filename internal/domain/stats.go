@@ -1,5 +1,7 @@
 package domain
 
+import "time"
+
 type Stats struct {
 	TotalPRs      int            `json:"total_prs"`
 	OpenPRs       int            `json:"open_prs"`
@@ -22,3 +24,135 @@ type PRStat struct {
 	Status          string `json:"status"`
 	ReviewersCount  int    `json:"reviewers_count"`
 }
+
+// StatsFilter ограничивает статистику временным окном [From, To) и командой автора PR.
+// PR попадает в окно, если он был создан или смержен внутри него
+type StatsFilter struct {
+	From     *time.Time
+	To       *time.Time
+	TeamName string
+}
+
+type CycleTimeStats struct {
+	Overall   CycleTimeMetrics    `json:"overall"`
+	Teams     []TeamCycleTime     `json:"teams"`
+	Reviewers []ReviewerCycleTime `json:"reviewers"`
+}
+
+type CycleTimeMetrics struct {
+	TimeToMerge       DurationSummary `json:"time_to_merge"`
+	TimeToFirstReview DurationSummary `json:"time_to_first_review"`
+}
+
+// DurationSummary описывает распределение длительностей в секундах; перцентили пустые, если нет данных
+type DurationSummary struct {
+	Count         int      `json:"count"`
+	MedianSeconds *float64 `json:"median_seconds"`
+	P90Seconds    *float64 `json:"p90_seconds"`
+}
+
+type TeamCycleTime struct {
+	TeamName string `json:"team_name"`
+	CycleTimeMetrics
+}
+
+type ReviewerCycleTime struct {
+	UserID       string          `json:"user_id"`
+	Username     string          `json:"username"`
+	TeamName     string          `json:"team_name"`
+	TimeToReview DurationSummary `json:"time_to_review"`
+}
+
+type FairnessReport struct {
+	GiniThreshold float64        `json:"gini_threshold"`
+	Teams         []TeamFairness `json:"teams"`
+}
+
+type TeamFairness struct {
+	TeamName         string           `json:"team_name"`
+	ActiveMembers    int              `json:"active_members"`
+	OpenAssignments  LoadDistribution `json:"open_assignments"`
+	TotalAssignments LoadDistribution `json:"total_assignments"`
+	Imbalanced       bool             `json:"imbalanced"`
+	Members          []ReviewerStat   `json:"members"`
+}
+
+// LoadDistribution описывает распределение назначений среди активных участников команды.
+// MaxMinRatio пустой, если у кого-то ноль назначений, а у кого-то нет
+type LoadDistribution struct {
+	Min         int      `json:"min"`
+	Max         int      `json:"max"`
+	Mean        float64  `json:"mean"`
+	StdDev      float64  `json:"std_dev"`
+	Gini        float64  `json:"gini"`
+	MaxMinRatio *float64 `json:"max_min_ratio"`
+}
+
+type AgingReport struct {
+	Buckets   AgeBuckets      `json:"buckets"`
+	Teams     []TeamAging     `json:"teams"`
+	Reviewers []ReviewerAging `json:"reviewers"`
+	Oldest    []AgingPR       `json:"oldest"`
+}
+
+// AgeBuckets — количество открытых PR по возрасту: <1д, 1–3д, 3–7д, >7д
+type AgeBuckets struct {
+	LessThan1Day  int `json:"lt_1d"`
+	From1To3Days  int `json:"1d_3d"`
+	From3To7Days  int `json:"3d_7d"`
+	MoreThan7Days int `json:"gt_7d"`
+}
+
+type TeamAging struct {
+	TeamName string     `json:"team_name"`
+	Buckets  AgeBuckets `json:"buckets"`
+}
+
+type ReviewerAging struct {
+	UserID   string     `json:"user_id"`
+	Username string     `json:"username"`
+	TeamName string     `json:"team_name"`
+	Buckets  AgeBuckets `json:"buckets"`
+}
+
+type AgingPR struct {
+	PullRequestID   string          `json:"pull_request_id"`
+	PullRequestName string          `json:"pull_request_name"`
+	Author          PRParticipant   `json:"author"`
+	Reviewers       []PRParticipant `json:"reviewers"`
+	CreatedAt       time.Time       `json:"createdAt"`
+	AgeSeconds      int64           `json:"age_seconds"`
+}
+
+const (
+	SnapshotScopeOverall  = "overall"
+	SnapshotScopeTeam     = "team"
+	SnapshotScopeReviewer = "reviewer"
+)
+
+// StatsSnapshot — агрегаты на конец дня Date. Для ревьюверов счётчики означают назначения
+type StatsSnapshot struct {
+	Date      time.Time
+	Scope     string
+	ScopeID   string
+	TotalPRs  int
+	OpenPRs   int
+	MergedPRs int
+}
+
+type StatsHistory struct {
+	Scope  string        `json:"scope"`
+	Series []StatsSeries `json:"series"`
+}
+
+type StatsSeries struct {
+	ScopeID string       `json:"scope_id"`
+	Points  []StatsPoint `json:"points"`
+}
+
+type StatsPoint struct {
+	Date      string `json:"date"`
+	TotalPRs  int    `json:"total_prs"`
+	OpenPRs   int    `json:"open_prs"`
+	MergedPRs int    `json:"merged_prs"`
+}
@@ -0,0 +1,89 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Типы событий, на которые можно подписаться исходящим вебхуком
+const (
+	EventPRCreated           = "pr.created"
+	EventPRMerged            = "pr.merged"
+	EventReviewerAssigned    = "reviewer.assigned"
+	EventReviewerReassigned  = "reviewer.reassigned"
+	EventTeamDeactivated     = "team.deactivated"
+	EventUserActivityChanged = "user.activity_changed"
+)
+
+var EventTypes = []string{
+	EventPRCreated,
+	EventPRMerged,
+	EventReviewerAssigned,
+	EventReviewerReassigned,
+	EventTeamDeactivated,
+	EventUserActivityChanged,
+}
+
+// Subscription — исходящий вебхук организации. Secret не отдаётся в API после создания
+type Subscription struct {
+	SubscriptionID string    `json:"subscription_id"`
+	OrgID          string    `json:"org_id"`
+	URL            string    `json:"url"`
+	Secret         string    `json:"-"`
+	EventTypes     []string  `json:"event_types"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// Event — тело исходящего вебхука
+type Event struct {
+	EventID    string    `json:"event_id"`
+	Type       string    `json:"type"`
+	OrgID      string    `json:"org_id"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Data       any       `json:"data"`
+}
+
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// Delivery — отправка события одной подписке. Payload и URL/Secret подписки нужны только при отправке
+type Delivery struct {
+	DeliveryID     int64           `json:"delivery_id"`
+	SubscriptionID string          `json:"subscription_id"`
+	EventID        string          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at,omitempty"`
+	LastStatusCode *int            `json:"last_status_code,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+	OrgID          string          `json:"-"`
+	Payload        json.RawMessage `json:"-"`
+	URL            string          `json:"-"`
+	Secret         string          `json:"-"`
+}
+
+// ReviewerAssignment — данные reviewer.assigned; Source — create_pr или team_deactivation
+type ReviewerAssignment struct {
+	PullRequestID string   `json:"pull_request_id"`
+	ReviewerIDs   []string `json:"reviewer_ids"`
+	Source        string   `json:"source"`
+}
+
+type ReviewerReassignment struct {
+	PullRequestID string `json:"pull_request_id"`
+	OldReviewerID string `json:"old_reviewer_id"`
+	NewReviewerID string `json:"new_reviewer_id"`
+}
+
+type TeamDeactivation struct {
+	TeamName            string   `json:"team_name"`
+	DeactivatedUserIDs  []string `json:"deactivated_user_ids"`
+	AffectedPRs         int      `json:"affected_prs"`
+	ReassignedReviewers int      `json:"reassigned_reviewers"`
+}
@@ -0,0 +1,46 @@
+package domain
+
+const (
+	ProviderGitHub = "github"
+	ProviderGitLab = "gitlab"
+)
+
+var Providers = []string{ProviderGitHub, ProviderGitLab}
+
+// UserAccount связывает логин во внешней системе (GitHub login, GitLab username) с пользователем сервиса
+type UserAccount struct {
+	Provider string `json:"provider"`
+	Login    string `json:"login"`
+	UserID   string `json:"user_id"`
+}
+
+// Действия над PR, к которым вебхуки приводят события внешних систем
+const (
+	PREventOpened   = "opened"
+	PREventMerged   = "merged"
+	PREventReviewed = "reviewed"
+	PREventClosed   = "closed"
+	PREventDraft    = "draft"
+)
+
+// PREvent — событие PR из вебхука, не зависящее от провайдера.
+// ActorLogin — кто выполнил действие: смержил PR или оставил ревью
+type PREvent struct {
+	Provider      string
+	Action        string
+	PullRequestID string
+	Title         string
+	AuthorLogin   string
+	ActorLogin    string
+}
+
+const (
+	WebhookApplied = "applied"
+	WebhookIgnored = "ignored"
+)
+
+type WebhookResult struct {
+	Outcome       string `json:"outcome"`
+	PullRequestID string `json:"pull_request_id,omitempty"`
+	Reason        string `json:"reason,omitempty"`
+}
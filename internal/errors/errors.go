@@ -21,10 +21,37 @@ func NewAppError(code, message string, status int) *AppError {
 }
 
 var (
-	ErrTeamExists  = NewAppError("TEAM_EXISTS", "team_name already exists", 400)
-	ErrPRExists    = NewAppError("PR_EXISTS", "PR id already exists", 409)
-	ErrPRMerged    = NewAppError("PR_MERGED", "cannot reassign on merged PR", 409)
-	ErrNotAssigned = NewAppError("NOT_ASSIGNED", "reviewer is not assigned to this PR", 409)
-	ErrNoCandidate = NewAppError("NO_CANDIDATE", "no active replacement candidate in team", 409)
-	ErrNotFound    = NewAppError("NOT_FOUND", "resource not found", 404)
+	ErrTeamExists   = NewAppError("TEAM_EXISTS", "team_name already exists", 400)
+	ErrPRExists     = NewAppError("PR_EXISTS", "PR id already exists", 409)
+	ErrPRMerged     = NewAppError("PR_MERGED", "cannot reassign on merged PR", 409)
+	ErrReviewMerged = NewAppError("REVIEW_ON_MERGED", "cannot review merged PR", 409)
+	ErrNotAssigned  = NewAppError("NOT_ASSIGNED", "reviewer is not assigned to this PR", 409)
+	ErrNoCandidate  = NewAppError("NO_CANDIDATE", "no active replacement candidate in team", 409)
+	ErrNotFound     = NewAppError("NOT_FOUND", "resource not found", 404)
+	ErrBadCursor    = NewAppError("INVALID_CURSOR", "pagination cursor is malformed", 400)
+	ErrRangeTooWide = NewAppError("RANGE_TOO_WIDE", "requested date range is too wide", 400)
+	ErrUnauthorized = NewAppError("UNAUTHORIZED", "missing or invalid API key", 401)
+	ErrInvalidToken = NewAppError("UNAUTHORIZED", "invalid bearer token or unknown user", 401)
+	ErrForbidden    = NewAppError("FORBIDDEN", "caller does not have the required scope", 403)
+	ErrNotCaller    = NewAppError("FORBIDDEN", "cannot act on behalf of another user", 403)
+	ErrInvalidScope = NewAppError("INVALID_SCOPE", "unknown API key scope", 400)
+	ErrInvalidRole  = NewAppError("INVALID_ROLE", "unknown role", 400)
+	ErrAdminOnly    = NewAppError("FORBIDDEN", "only org admins can perform this action", 403)
+	ErrNotTeamLead  = NewAppError("FORBIDDEN", "only org admins and the lead of this team can perform this action", 403)
+	ErrNotPRMember  = NewAppError("FORBIDDEN", "only the PR author, its reviewers and their team lead can act on this PR", 403)
+	ErrOrgExists    = NewAppError("ORG_EXISTS", "org_id already exists", 409)
+	ErrInvalidOrgID = NewAppError("INVALID_ORG_ID", "org_id must be 1-64 lowercase letters, digits or dashes", 400)
+	ErrRateLimited  = NewAppError("RATE_LIMITED", "too many requests, retry later", 429)
+
+	ErrInvalidProvider     = NewAppError("INVALID_PROVIDER", "unknown account provider", 400)
+	ErrInvalidSignature    = NewAppError("INVALID_SIGNATURE", "webhook signature does not match", 401)
+	ErrInvalidWebhookToken = NewAppError("INVALID_SIGNATURE", "webhook token does not match", 401)
+	ErrInvalidWebhookURL   = NewAppError("INVALID_URL", "webhook url must be an absolute http or https URL of a public host", 400)
+	ErrInvalidEventType    = NewAppError("INVALID_EVENT_TYPE", "unknown webhook event type", 400)
+
+	ErrInvalidIdempotencyKey = NewAppError("INVALID_IDEMPOTENCY_KEY", "Idempotency-Key must be at most 255 characters", 400)
+	ErrIdempotencyKeyReused  = NewAppError("IDEMPOTENCY_KEY_REUSED", "Idempotency-Key was already used with a different request", 422)
+	ErrIdempotencyInProgress = NewAppError("IDEMPOTENCY_IN_PROGRESS", "a request with this Idempotency-Key is still in progress", 409)
+
+	ErrBodyTooLarge = NewAppError("BODY_TOO_LARGE", "request body exceeds 5 MiB", 413)
 )
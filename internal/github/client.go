@@ -0,0 +1,233 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"pr-review-manager/internal/logging"
+	"pr-review-manager/internal/tracing"
+)
+
+const (
+	DefaultBaseURL = "https://api.github.com"
+	apiVersion     = "2022-11-28"
+	// maxRetryAfter ограничивает ожидание по Retry-After, чтобы один запрос не занимал очередь надолго
+	maxRetryAfter = time.Minute
+)
+
+// Config: Tokens — токены по репозиторию ("owner/repo") или владельцу ("owner"), Token — для остальных.
+// BaseURL меняется для GitHub Enterprise ("https://github.example.com/api/v3") и тестов
+type Config struct {
+	BaseURL      string            `yaml:"base_url"`
+	Token        string            `yaml:"token"`
+	Tokens       map[string]string `yaml:"tokens"`
+	Timeout      time.Duration     `yaml:"timeout"`
+	Retries      int               `yaml:"retries"`
+	RetryBackoff time.Duration     `yaml:"retry_backoff"`
+}
+
+// Enabled сообщает, задан ли хотя бы один токен
+func (c Config) Enabled() bool {
+	return c.Token != "" || len(c.Tokens) > 0
+}
+
+// TokenFor выбирает токен репозитория, затем его владельца, затем общий
+func (c Config) TokenFor(repo string) string {
+	if token, ok := c.Tokens[repo]; ok {
+		return token
+	}
+	owner, _, _ := strings.Cut(repo, "/")
+	if token, ok := c.Tokens[owner]; ok {
+		return token
+	}
+	return c.Token
+}
+
+// ParseTokens разбирает токены из строки вида "octo-org/api=ghp_xxx,octo-org=ghp_yyy"
+func ParseTokens(value string) (map[string]string, error) {
+	tokens := make(map[string]string)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		repo, token, ok := strings.Cut(item, "=")
+		if !ok || repo == "" || token == "" {
+			return nil, fmt.Errorf("repo token must look like owner/repo=token or owner=token")
+		}
+		tokens[strings.TrimSpace(repo)] = strings.TrimSpace(token)
+	}
+	return tokens, nil
+}
+
+// APIError — ответ GitHub с кодом ошибки
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("github api: %d %s", e.StatusCode, e.Message)
+}
+
+// Client вызывает REST API GitHub для назначения ревьюверов PR.
+// Сетевые ошибки, 5xx и исчерпанный лимит запросов повторяются с экспоненциальной задержкой
+type Client struct {
+	cfg    Config
+	client *http.Client
+}
+
+func NewClient(cfg Config) *Client {
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = DefaultBaseURL
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+	return &Client{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+// RequestReviewers запрашивает ревью у пользователей GitHub; repo — "owner/repo"
+func (c *Client) RequestReviewers(ctx context.Context, repo string, number int, logins []string) error {
+	ctx, span := tracing.Start(ctx, "GitHubClient.RequestReviewers")
+	defer span.End()
+
+	return c.do(ctx, http.MethodPost, repo, reviewersPath(repo, number), map[string][]string{"reviewers": logins})
+}
+
+// RemoveReviewers отзывает запрос ревью; для уже оставивших ревью GitHub ничего не меняет
+func (c *Client) RemoveReviewers(ctx context.Context, repo string, number int, logins []string) error {
+	ctx, span := tracing.Start(ctx, "GitHubClient.RemoveReviewers")
+	defer span.End()
+
+	return c.do(ctx, http.MethodDelete, repo, reviewersPath(repo, number), map[string][]string{"reviewers": logins})
+}
+
+func reviewersPath(repo string, number int) string {
+	return "/repos/" + repo + "/pulls/" + strconv.Itoa(number) + "/requested_reviewers"
+}
+
+func (c *Client) do(ctx context.Context, method, repo, path string, body interface{}) error {
+	token := c.cfg.TokenFor(repo)
+	if token == "" {
+		return fmt.Errorf("github api: no token configured for %s", repo)
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; ; attempt++ {
+		retryAfter, err := c.attempt(ctx, method, path, token, payload)
+		if err == nil {
+			return nil
+		}
+		if retryAfter < 0 || attempt >= c.cfg.Retries {
+			return err
+		}
+
+		delay := max(retryAfter, c.backoff(attempt))
+		logging.FromContext(ctx).Warn("github api request failed, retrying",
+			"method", method, "path", path, "attempt", attempt+1, "delay", delay, "error", err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// attempt выполняет один запрос. retryAfter < 0 означает, что повтор бесполезен;
+// иначе это минимальная задержка, запрошенная GitHub
+func (c *Client) attempt(ctx context.Context, method, path, token string, payload []byte) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.cfg.BaseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return -1, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "pr-review-manager")
+	req.Header.Set("X-GitHub-Api-Version", apiVersion)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return -1, err
+		}
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 300 {
+		io.Copy(io.Discard, resp.Body)
+		return 0, nil
+	}
+
+	var apiErr struct {
+		Message string `json:"message"`
+	}
+	json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&apiErr)
+	err = &APIError{StatusCode: resp.StatusCode, Message: apiErr.Message}
+
+	rateLimited := resp.StatusCode == http.StatusTooManyRequests ||
+		resp.StatusCode == http.StatusForbidden && (resp.Header.Get("Retry-After") != "" || resp.Header.Get("X-RateLimit-Remaining") == "0")
+	switch {
+	case rateLimited:
+		return retryDelay(resp.Header), err
+	case resp.StatusCode >= 500:
+		return 0, err
+	default:
+		return -1, err
+	}
+}
+
+// retryDelay читает Retry-After или время сброса лимита X-RateLimit-Reset
+func retryDelay(header http.Header) time.Duration {
+	if seconds, err := strconv.Atoi(header.Get("Retry-After")); err == nil {
+		return min(time.Duration(seconds)*time.Second, maxRetryAfter)
+	}
+	if reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		return min(max(time.Until(time.Unix(reset, 0)), 0), maxRetryAfter)
+	}
+	return 0
+}
+
+// backoff удваивает задержку с каждой попыткой и добавляет до 50% случайного разброса
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := c.cfg.RetryBackoff << attempt
+	if delay <= 0 {
+		return 0
+	}
+	return delay + time.Duration(rand.Int63n(int64(delay)/2+1))
+}
+
+// PullRequestID — идентификатор PR из GitHub в сервисе: "owner/repo#number"
+func PullRequestID(repo string, number int) string {
+	return repo + "#" + strconv.Itoa(number)
+}
+
+// ParsePullRequestID разбирает PullRequestID; ok = false для PR, созданных не из GitHub
+func ParsePullRequestID(id string) (repo string, number int, ok bool) {
+	repo, num, found := strings.Cut(id, "#")
+	if !found || strings.Count(repo, "/") != 1 || strings.HasPrefix(repo, "/") || strings.HasSuffix(repo, "/") {
+		return "", 0, false
+	}
+	number, err := strconv.Atoi(num)
+	if err != nil || number <= 0 {
+		return "", 0, false
+	}
+	return repo, number, true
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"pr-review-manager/internal/service"
+)
+
+type APIKeyHandler struct {
+	apiKeyService *service.APIKeyService
+}
+
+func NewAPIKeyHandler(apiKeyService *service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{apiKeyService: apiKeyService}
+}
+
+func (h *APIKeyHandler) CreateKey(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name   string   `json:"name"`
+		Scopes []string `json:"scopes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		return
+	}
+
+	if req.Name == "" {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "name is required")
+		return
+	}
+
+	key, err := h.apiKeyService.CreateKey(r.Context(), req.Name, req.Scopes)
+	if err != nil {
+		handleServiceError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, map[string]interface{}{
+		"api_key": key,
+	})
+}
+
+// RedactAPIKeySecret убирает открытое значение ключа из ответа на создание перед сохранением для
+// Idempotency-Key: повтор вернёт key_id созданного ключа, но не сам ключ
+func RedactAPIKeySecret(body []byte) []byte {
+	var resp struct {
+		APIKey map[string]json.RawMessage `json:"api_key"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil || resp.APIKey == nil {
+		return body
+	}
+	delete(resp.APIKey, "key")
+	redacted, err := json.Marshal(resp)
+	if err != nil {
+		return body
+	}
+	return redacted
+}
+
+func (h *APIKeyHandler) ListKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.apiKeyService.ListKeys(r.Context())
+	if err != nil {
+		handleServiceError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"api_keys": keys,
+	})
+}
+
+func (h *APIKeyHandler) RevokeKey(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		KeyID string `json:"key_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		return
+	}
+
+	if req.KeyID == "" {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "key_id is required")
+		return
+	}
+
+	key, err := h.apiKeyService.RevokeKey(r.Context(), req.KeyID)
+	if err != nil {
+		handleServiceError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"api_key": key,
+	})
+}
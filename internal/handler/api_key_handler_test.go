@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRedactAPIKeySecret(t *testing.T) {
+	body := []byte(`{"api_key":{"key_id":"k1","name":"ci","scopes":["read"],"key":"prm_secret"}}`)
+
+	var resp struct {
+		APIKey map[string]any `json:"api_key"`
+	}
+	if err := json.Unmarshal(RedactAPIKeySecret(body), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := resp.APIKey["key"]; ok {
+		t.Errorf("Expected key to be removed, got %v", resp.APIKey)
+	}
+	if resp.APIKey["key_id"] != "k1" || resp.APIKey["name"] != "ci" {
+		t.Errorf("Expected other fields to be kept, got %v", resp.APIKey)
+	}
+
+	errorBody := []byte(`{"error":{"code":"INVALID_SCOPE","message":"unknown API key scope"}}`)
+	if got := RedactAPIKeySecret(errorBody); string(got) != string(errorBody) {
+		t.Errorf("Expected error responses to be stored as is, got %s", got)
+	}
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"pr-review-manager/internal/auth"
+	"pr-review-manager/internal/domain"
+	"pr-review-manager/internal/errors"
+	"pr-review-manager/internal/logging"
+	"pr-review-manager/internal/service"
+)
+
+const apiKeyHeader = "X-API-Key"
+
+// Authenticator принимает API-ключ из X-API-Key, JWT из Authorization: Bearer либо
+// user_id и org_id из заголовков доверенного прокси, проверяет наличие нужного scope
+// и ограничивает запрос организацией вызывающего.
+// При выключенной аутентификации пропускает все запросы в организацию по умолчанию
+type Authenticator struct {
+	authService       *service.AuthService
+	enabled           bool
+	trustedUserHeader string
+	trustedOrgHeader  string
+}
+
+// NewAuthenticator: пустой trustedUserHeader отключает доверие к заголовку прокси,
+// без trustedOrgHeader пользователи прокси относятся к организации по умолчанию
+func NewAuthenticator(authService *service.AuthService, enabled bool, trustedUserHeader, trustedOrgHeader string) *Authenticator {
+	return &Authenticator{
+		authService:       authService,
+		enabled:           enabled,
+		trustedUserHeader: trustedUserHeader,
+		trustedOrgHeader:  trustedOrgHeader,
+	}
+}
+
+func (a *Authenticator) Require(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !a.enabled {
+				next.ServeHTTP(w, r.WithContext(auth.WithOrg(r.Context(), domain.DefaultOrgID)))
+				return
+			}
+
+			principal, err := a.authenticate(r)
+			if err != nil {
+				handleServiceError(w, r, err)
+				return
+			}
+
+			ctx := logging.With(auth.WithOrg(r.Context(), principal.OrgID), "org_id", principal.OrgID)
+			if principal.APIKeyID != "" {
+				ctx = logging.With(ctx, "api_key_id", principal.APIKeyID)
+			} else {
+				ctx = logging.With(ctx, "caller_user_id", principal.UserID)
+			}
+			if !principal.HasScope(scope) {
+				handleServiceError(w, r.WithContext(ctx), errors.ErrForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(ctx, principal)))
+		})
+	}
+}
+
+func (a *Authenticator) authenticate(r *http.Request) (*domain.Principal, error) {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return a.authService.AuthenticateBearer(r.Context(), strings.TrimSpace(token))
+	}
+	if a.trustedUserHeader != "" && r.Header.Get(apiKeyHeader) == "" {
+		if userID := r.Header.Get(a.trustedUserHeader); userID != "" {
+			// Если прокси передаёт организацию, запрос без неё отклоняется, а не попадает в организацию по умолчанию
+			var orgID string
+			if a.trustedOrgHeader != "" {
+				if orgID = r.Header.Get(a.trustedOrgHeader); orgID == "" {
+					return nil, errors.ErrInvalidToken
+				}
+			}
+			return a.authService.AuthenticateTrustedUser(r.Context(), orgID, userID)
+		}
+	}
+	return a.authService.AuthenticateAPIKey(r.Context(), r.Header.Get(apiKeyHeader))
+}
@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"pr-review-manager/internal/auth"
+	"pr-review-manager/internal/domain"
+	"pr-review-manager/internal/errors"
+	"pr-review-manager/internal/github"
+	"pr-review-manager/internal/logging"
+	"pr-review-manager/internal/service"
+)
+
+// GitHubWebhookHandler принимает события pull_request и pull_request_review.
+// Вызывающий аутентифицируется подписью X-Hub-Signature-256, а события относятся к одной организации
+type GitHubWebhookHandler struct {
+	webhookService *service.WebhookService
+	secret         string
+	orgID          string
+}
+
+// NewGitHubWebhookHandler: пустой secret выключает вебхук
+func NewGitHubWebhookHandler(webhookService *service.WebhookService, secret, orgID string) *GitHubWebhookHandler {
+	return &GitHubWebhookHandler{
+		webhookService: webhookService,
+		secret:         secret,
+		orgID:          orgID,
+	}
+}
+
+type githubUser struct {
+	Login string `json:"login"`
+}
+
+type githubPayload struct {
+	Action      string `json:"action"`
+	PullRequest struct {
+		Number   int         `json:"number"`
+		Title    string      `json:"title"`
+		Draft    bool        `json:"draft"`
+		Merged   bool        `json:"merged"`
+		User     githubUser  `json:"user"`
+		MergedBy *githubUser `json:"merged_by"`
+	} `json:"pull_request"`
+	Review struct {
+		State string     `json:"state"`
+		User  githubUser `json:"user"`
+	} `json:"review"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+	Sender githubUser `json:"sender"`
+}
+
+func (h *GitHubWebhookHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	if h.secret == "" {
+		handleServiceError(w, r, errors.ErrNotFound)
+		return
+	}
+
+	body, ok := readBody(w, r)
+	if !ok {
+		return
+	}
+	if !validGitHubSignature(h.secret, body, r.Header.Get("X-Hub-Signature-256")) {
+		handleServiceError(w, r, errors.ErrInvalidSignature)
+		return
+	}
+
+	eventType := r.Header.Get("X-GitHub-Event")
+	ctx := logging.With(auth.WithOrg(r.Context(), h.orgID), "org_id", h.orgID, "delivery_id", r.Header.Get("X-GitHub-Delivery"), "event", eventType)
+	r = r.WithContext(ctx)
+
+	if eventType != "pull_request" && eventType != "pull_request_review" {
+		respondJSON(w, http.StatusOK, &domain.WebhookResult{
+			Outcome: domain.WebhookIgnored,
+			Reason:  "event " + eventType + " is not tracked",
+		})
+		return
+	}
+
+	var payload githubPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid webhook payload")
+		return
+	}
+
+	result, err := h.webhookService.HandlePREvent(ctx, githubPREvent(eventType, &payload))
+	if err != nil {
+		handleServiceError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, result)
+}
+
+// githubPREvent приводит событие GitHub к действию над PR. Черновики не отслеживаются,
+// пока не станут готовы к ревью, а закрытие без merge не меняет PR
+func githubPREvent(eventType string, payload *githubPayload) domain.PREvent {
+	pr := payload.PullRequest
+	event := domain.PREvent{
+		Provider:      domain.ProviderGitHub,
+		Action:        payload.Action,
+		PullRequestID: github.PullRequestID(payload.Repository.FullName, pr.Number),
+		Title:         pr.Title,
+		AuthorLogin:   pr.User.Login,
+		ActorLogin:    payload.Sender.Login,
+	}
+
+	if eventType == "pull_request_review" {
+		if payload.Action == "submitted" {
+			event.Action = domain.PREventReviewed
+			event.ActorLogin = payload.Review.User.Login
+		}
+		return event
+	}
+
+	switch payload.Action {
+	case "opened", "reopened", "ready_for_review":
+		event.Action = domain.PREventOpened
+		if pr.Draft {
+			event.Action = domain.PREventDraft
+		}
+	case "converted_to_draft":
+		event.Action = domain.PREventDraft
+	case "closed":
+		event.Action = domain.PREventClosed
+		if pr.Merged {
+			event.Action = domain.PREventMerged
+			if pr.MergedBy != nil {
+				event.ActorLogin = pr.MergedBy.Login
+			}
+		}
+	}
+	return event
+}
+
+func validGitHubSignature(secret string, body []byte, header string) bool {
+	signature, ok := strings.CutPrefix(header, "sha256=")
+	if !ok {
+		return false
+	}
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
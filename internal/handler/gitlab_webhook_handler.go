@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"pr-review-manager/internal/auth"
+	"pr-review-manager/internal/domain"
+	"pr-review-manager/internal/errors"
+	"pr-review-manager/internal/logging"
+	"pr-review-manager/internal/service"
+)
+
+// GitLabWebhookHandler принимает Merge Request Hook. Вызывающий аутентифицируется
+// секретным токеном X-Gitlab-Token, а события относятся к одной организации
+type GitLabWebhookHandler struct {
+	webhookService *service.WebhookService
+	secret         string
+	orgID          string
+}
+
+// NewGitLabWebhookHandler: пустой secret выключает вебхук
+func NewGitLabWebhookHandler(webhookService *service.WebhookService, secret, orgID string) *GitLabWebhookHandler {
+	return &GitLabWebhookHandler{
+		webhookService: webhookService,
+		secret:         secret,
+		orgID:          orgID,
+	}
+}
+
+type gitlabDraftChange struct {
+	Previous bool `json:"previous"`
+	Current  bool `json:"current"`
+}
+
+// В Merge Request Hook нет имени автора MR, только его числовой id в author_id, а user — это
+// тот, кто выполнил действие. Имя автора известно, только когда действие выполнил он сам
+type gitlabPayload struct {
+	ObjectKind string `json:"object_kind"`
+	User       struct {
+		ID       int64  `json:"id"`
+		Username string `json:"username"`
+	} `json:"user"`
+	Project struct {
+		PathWithNamespace string `json:"path_with_namespace"`
+	} `json:"project"`
+	ObjectAttributes struct {
+		IID      int    `json:"iid"`
+		AuthorID int64  `json:"author_id"`
+		Title    string `json:"title"`
+		Action   string `json:"action"`
+		Draft    bool   `json:"draft"`
+	} `json:"object_attributes"`
+	Changes struct {
+		Draft *gitlabDraftChange `json:"draft"`
+	} `json:"changes"`
+}
+
+func (h *GitLabWebhookHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	if h.secret == "" {
+		handleServiceError(w, r, errors.ErrNotFound)
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get("X-Gitlab-Token")), []byte(h.secret)) != 1 {
+		handleServiceError(w, r, errors.ErrInvalidWebhookToken)
+		return
+	}
+
+	eventType := r.Header.Get("X-Gitlab-Event")
+	ctx := logging.With(auth.WithOrg(r.Context(), h.orgID), "org_id", h.orgID, "delivery_id", r.Header.Get("X-Gitlab-Event-UUID"), "event", eventType)
+	r = r.WithContext(ctx)
+
+	if eventType != "Merge Request Hook" {
+		respondJSON(w, http.StatusOK, &domain.WebhookResult{
+			Outcome: domain.WebhookIgnored,
+			Reason:  "event " + eventType + " is not tracked",
+		})
+		return
+	}
+
+	body, ok := readBody(w, r)
+	if !ok {
+		return
+	}
+	var payload gitlabPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid webhook payload")
+		return
+	}
+
+	result, err := h.webhookService.HandlePREvent(ctx, gitlabPREvent(&payload))
+	if err != nil {
+		handleServiceError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, result)
+}
+
+// gitlabPREvent приводит событие MR к действию над PR. Черновик отслеживается после снятия
+// отметки draft, а закрытие без merge и отзыв одобрения не меняют PR. Если MR переоткрыл или
+// вывел из черновика не автор, автор неизвестен и PR не создаётся
+func gitlabPREvent(payload *gitlabPayload) domain.PREvent {
+	mr := payload.ObjectAttributes
+	event := domain.PREvent{
+		Provider:      domain.ProviderGitLab,
+		Action:        mr.Action,
+		PullRequestID: payload.Project.PathWithNamespace + "!" + strconv.Itoa(mr.IID),
+		Title:         mr.Title,
+		ActorLogin:    payload.User.Username,
+	}
+
+	author := ""
+	if payload.User.ID != 0 && payload.User.ID == mr.AuthorID {
+		author = payload.User.Username
+	}
+
+	switch mr.Action {
+	case "open", "reopen":
+		event.Action = domain.PREventOpened
+		event.AuthorLogin = author
+		if mr.Draft {
+			event.Action = domain.PREventDraft
+		}
+	case "update":
+		if draft := payload.Changes.Draft; draft != nil && draft.Previous && !draft.Current {
+			event.Action = domain.PREventOpened
+			event.AuthorLogin = author
+		}
+	case "merge":
+		event.Action = domain.PREventMerged
+	case "close":
+		event.Action = domain.PREventClosed
+	case "approved", "approval":
+		event.Action = domain.PREventReviewed
+	}
+	return event
+}
@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"net/http"
+
+	"pr-review-manager/internal/domain"
+	"pr-review-manager/internal/service"
+)
+
+type HealthHandler struct {
+	healthService *service.HealthService
+}
+
+func NewHealthHandler(healthService *service.HealthService) *HealthHandler {
+	return &HealthHandler{healthService: healthService}
+}
+
+// Liveness не трогает зависимости: процесс жив, пока способен отвечать на запросы
+func (h *HealthHandler) Liveness(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, domain.HealthReport{Status: domain.HealthStatusOK})
+}
+
+func (h *HealthHandler) Readiness(w http.ResponseWriter, r *http.Request) {
+	report := h.healthService.Readiness(r.Context())
+
+	status := http.StatusOK
+	if report.Status != domain.HealthStatusOK {
+		status = http.StatusServiceUnavailable
+	}
+	respondJSON(w, status, report)
+}
@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+
+	"pr-review-manager/internal/auth"
+	"pr-review-manager/internal/logging"
+	"pr-review-manager/internal/metrics"
+	"pr-review-manager/internal/service"
+)
+
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// Idempotency повторяет сохранённый ответ на POST с уже встречавшимся Idempotency-Key.
+// Стоит после аутентификации: ключи разделены по организации и вызывающему
+type Idempotency struct {
+	idempotencyService *service.IdempotencyService
+}
+
+func NewIdempotency(idempotencyService *service.IdempotencyService) *Idempotency {
+	return &Idempotency{idempotencyService: idempotencyService}
+}
+
+func (i *Idempotency) Handle(next http.Handler) http.Handler {
+	return i.handle(next, nil)
+}
+
+// Redacting сохраняет ответ, пропущенный через redact: секреты из него не попадают в БД,
+// а повтор возвращает ответ без них
+func (i *Idempotency) Redacting(redact func(body []byte) []byte) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return i.handle(next, redact)
+	}
+}
+
+func (i *Idempotency) handle(next http.Handler, redact func(body []byte) []byte) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" || r.Method != http.MethodPost {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, ok := readBody(w, r)
+		if !ok {
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := logging.With(r.Context(), "idempotency_key", key)
+		caller := idempotencyCaller(ctx)
+		stored, err := i.idempotencyService.Begin(ctx, caller, key, r.URL.Path, body)
+		if err != nil {
+			handleServiceError(w, r.WithContext(ctx), err)
+			return
+		}
+		if stored != nil {
+			metrics.IdempotentReplays.Inc()
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(stored.StatusCode)
+			w.Write(stored.ResponseBody)
+			return
+		}
+
+		// Результат сохраняется и после отключения клиента: ради этого он и повторит запрос
+		saveCtx := context.WithoutCancel(ctx)
+		completed := false
+		defer func() {
+			if completed {
+				return
+			}
+			if err := i.idempotencyService.Release(saveCtx, caller, key); err != nil {
+				logging.FromContext(ctx).Error("failed to release idempotency key", "error", err)
+			}
+		}()
+
+		rec := &recordingWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		if rec.status >= http.StatusInternalServerError {
+			return
+		}
+		response := rec.body.Bytes()
+		if redact != nil {
+			response = redact(response)
+		}
+		if err := i.idempotencyService.Complete(saveCtx, caller, key, rec.status, response); err != nil {
+			logging.FromContext(ctx).Error("failed to store idempotent response", "error", err)
+			return
+		}
+		completed = true
+	})
+}
+
+// idempotencyCaller — владелец ключа: два клиента не должны получить ответы друг друга
+func idempotencyCaller(ctx context.Context) string {
+	principal := auth.PrincipalFromContext(ctx)
+	switch {
+	case principal == nil:
+		return "anonymous"
+	case principal.APIKeyID != "":
+		return "key:" + principal.APIKeyID
+	default:
+		return "user:" + principal.UserID
+	}
+}
+
+// recordingWriter пропускает ответ клиенту и копирует его для сохранения
+type recordingWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (w *recordingWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recordingWriter) Write(p []byte) (int, error) {
+	w.wroteHeader = true
+	w.body.Write(p)
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *recordingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
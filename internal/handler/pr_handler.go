@@ -28,7 +28,7 @@ func (h *PRHandler) CreatePR(w http.ResponseWriter, r *http.Request) {
 
 	pr, err := h.prService.CreatePR(r.Context(), req.PullRequestID, req.PullRequestName, req.AuthorID)
 	if err != nil {
-		handleServiceError(w, err)
+		handleServiceError(w, r, err)
 		return
 	}
 
@@ -37,6 +37,24 @@ func (h *PRHandler) CreatePR(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (h *PRHandler) GetPR(w http.ResponseWriter, r *http.Request) {
+	prID := r.URL.Query().Get("pull_request_id")
+	if prID == "" {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "pull_request_id is required")
+		return
+	}
+
+	pr, err := h.prService.GetPR(r.Context(), prID)
+	if err != nil {
+		handleServiceError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"pr": pr,
+	})
+}
+
 func (h *PRHandler) MergePR(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		PullRequestID string `json:"pull_request_id"`
@@ -48,7 +66,7 @@ func (h *PRHandler) MergePR(w http.ResponseWriter, r *http.Request) {
 
 	pr, err := h.prService.MergePR(r.Context(), req.PullRequestID)
 	if err != nil {
-		handleServiceError(w, err)
+		handleServiceError(w, r, err)
 		return
 	}
 
@@ -69,7 +87,7 @@ func (h *PRHandler) ReassignReviewer(w http.ResponseWriter, r *http.Request) {
 
 	pr, replacedBy, err := h.prService.ReassignReviewer(r.Context(), req.PullRequestID, req.OldUserID)
 	if err != nil {
-		handleServiceError(w, err)
+		handleServiceError(w, r, err)
 		return
 	}
 
@@ -78,3 +96,24 @@ func (h *PRHandler) ReassignReviewer(w http.ResponseWriter, r *http.Request) {
 		"replaced_by": replacedBy,
 	})
 }
+
+func (h *PRHandler) SubmitReview(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		PullRequestID string `json:"pull_request_id"`
+		UserID        string `json:"user_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		return
+	}
+
+	pr, err := h.prService.SubmitReview(r.Context(), req.PullRequestID, req.UserID)
+	if err != nil {
+		handleServiceError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"pr": pr,
+	})
+}
@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"pr-review-manager/internal/errors"
+	"pr-review-manager/internal/metrics"
+	"pr-review-manager/internal/ratelimit"
+)
+
+// RateLimiter ограничивает частоту запросов клиента: по API-ключу, а без него — по IP.
+// Стоит перед аутентификацией, чтобы поток запросов не доходил до БД даже с неверным ключом.
+// Поэтому своя корзина есть только у ключа, который уже прошёл проверку; запросы с
+// непроверенными ключами делят корзину IP, и перебор случайных ключей её не обходит.
+// IP клиента берётся из X-Forwarded-For и X-Real-IP, только если запрос пришёл от доверенного прокси
+type RateLimiter struct {
+	limiter *ratelimit.Limiter
+	cfg     ratelimit.Config
+	proxies []netip.Prefix
+
+	mu           sync.Mutex
+	verifiedKeys map[string]bool
+}
+
+// NewRateLimiter ожидает конфигурацию, уже проверенную config.Validate; неверные прокси отбрасываются
+func NewRateLimiter(cfg ratelimit.Config) *RateLimiter {
+	proxies, _ := ratelimit.ParseProxies(cfg.TrustedProxies)
+	return &RateLimiter{
+		limiter:      ratelimit.NewLimiter(),
+		cfg:          cfg,
+		proxies:      proxies,
+		verifiedKeys: make(map[string]bool),
+	}
+}
+
+func (l *RateLimiter) Limit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !l.cfg.Enabled {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		name, limit := l.bucket(r)
+		keyID := apiKeyID(r)
+		client := "ip:" + l.clientIP(r)
+		if keyID != "" && l.isVerified(keyID) {
+			client = keyID
+		}
+		result := l.limiter.Allow(client+"|"+name, limit)
+
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		w.Header().Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+
+		if !result.Allowed {
+			metrics.RateLimited.WithLabelValues(name).Inc()
+			w.Header().Set("Retry-After", strconv.Itoa(max(ceilSeconds(result.RetryAfter), 1)))
+			handleServiceError(w, r, errors.ErrRateLimited)
+			return
+		}
+
+		if keyID == "" || client == keyID {
+			next.ServeHTTP(w, r)
+			return
+		}
+		// Ответ без 401 значит, что аутентификация ключ приняла. 5xx не учитываем: при сбое БД ключ не проверен
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+		if status := ww.Status(); status != 0 && status != http.StatusUnauthorized && status < 500 {
+			l.markVerified(keyID)
+		}
+	})
+}
+
+func (l *RateLimiter) isVerified(keyID string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.verifiedKeys[keyID]
+}
+
+// markVerified запоминает только ключи, принятые аутентификацией, поэтому набор ограничен числом выпущенных ключей
+func (l *RateLimiter) markVerified(keyID string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.verifiedKeys[keyID] = true
+}
+
+// bucket выбирает лимит: отдельный для пути из Routes, иначе общий для чтения или записи
+func (l *RateLimiter) bucket(r *http.Request) (string, ratelimit.Limit) {
+	if limit, ok := l.cfg.Routes[r.URL.Path]; ok {
+		return r.URL.Path, limit
+	}
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return "read", l.cfg.Read
+	default:
+		return "write", l.cfg.Write
+	}
+}
+
+// apiKeyID не хранит ключ в открытом виде: в памяти остаётся только префикс его хеша
+func apiKeyID(r *http.Request) string {
+	key := r.Header.Get(apiKeyHeader)
+	if key == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(key))
+	return "key:" + hex.EncodeToString(sum[:8])
+}
+
+// clientIP доверяет заголовкам прокси, только если соединение пришло от доверенного прокси.
+// X-Forwarded-For читается справа налево: первый адрес вне доверенных сетей и есть клиент,
+// всё левее него мог подставить сам клиент
+func (l *RateLimiter) clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if !l.isTrustedProxy(host) {
+		return host
+	}
+
+	if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		hops := strings.Split(strings.Join(forwarded, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if _, err := netip.ParseAddr(hop); err != nil {
+				break
+			}
+			host = hop
+			if !l.isTrustedProxy(hop) {
+				break
+			}
+		}
+		return host
+	}
+	if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); realIP != "" {
+		if _, err := netip.ParseAddr(realIP); err == nil {
+			return realIP
+		}
+	}
+	return host
+}
+
+func (l *RateLimiter) isTrustedProxy(host string) bool {
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range l.proxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,343 @@
+package handler
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"pr-review-manager/internal/domain"
+	"pr-review-manager/internal/logging"
+)
+
+const (
+	formatJSON     = "json"
+	formatCSV      = "csv"
+	formatMarkdown = "markdown"
+)
+
+// reportTable — плоское представление раздела отчёта для CSV и Markdown.
+// Порядок колонок фиксирован, чтобы выгрузки можно было вставлять в одни и те же таблицы
+type reportTable struct {
+	Name    string
+	Columns []string
+	Rows    [][]string
+}
+
+// negotiateFormat выбирает формат по ?format=, затем по Accept с учётом q-значений. По умолчанию — JSON
+func negotiateFormat(w http.ResponseWriter, r *http.Request) (string, bool) {
+	switch strings.ToLower(r.URL.Query().Get("format")) {
+	case "":
+	case "json":
+		return formatJSON, true
+	case "csv":
+		return formatCSV, true
+	case "md", "markdown":
+		return formatMarkdown, true
+	default:
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "format must be one of json, csv, markdown")
+		return "", false
+	}
+
+	return acceptedFormat(r.Header.Get("Accept")), true
+}
+
+// acceptedFormat возвращает поддерживаемый формат с наибольшим q; при равных q побеждает указанный раньше.
+// Диапазоны с q=0 исключаются, */* и application/* означают JSON
+func acceptedFormat(accept string) string {
+	best, bestQ := formatJSON, 0.0
+	for _, mediaRange := range strings.Split(accept, ",") {
+		params := strings.Split(mediaRange, ";")
+		var format string
+		switch strings.ToLower(strings.TrimSpace(params[0])) {
+		case "application/json", "application/*", "*/*":
+			format = formatJSON
+		case "text/csv":
+			format = formatCSV
+		case "text/markdown":
+			format = formatMarkdown
+		default:
+			continue
+		}
+
+		q := 1.0
+		for _, param := range params[1:] {
+			name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(name, "q") {
+				parsed, err := strconv.ParseFloat(value, 64)
+				if err != nil || parsed < 0 || parsed > 1 {
+					parsed = 0
+				}
+				q = parsed
+			}
+		}
+		if q > bestQ {
+			best, bestQ = format, q
+		}
+	}
+	return best
+}
+
+// respondReport отдаёт data как JSON либо таблицы tables() как CSV/Markdown.
+// Параметр ?section= оставляет в выгрузке только один раздел
+func respondReport(w http.ResponseWriter, r *http.Request, format string, data interface{}, tables func() []reportTable) {
+	if format == formatJSON {
+		respondJSON(w, http.StatusOK, data)
+		return
+	}
+
+	selected := tables()
+	if section := r.URL.Query().Get("section"); section != "" {
+		filtered := []reportTable{}
+		for _, table := range selected {
+			if table.Name == section {
+				filtered = append(filtered, table)
+			}
+		}
+		if len(filtered) == 0 {
+			respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "unknown section "+section)
+			return
+		}
+		selected = filtered
+	}
+
+	switch format {
+	case formatCSV:
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		// Статус уже отправлен, поэтому ошибку записи остаётся только залогировать
+		if err := writeCSV(w, selected); err != nil {
+			logging.FromContext(r.Context()).Error("Failed to write CSV report", "error", err)
+		}
+	case formatMarkdown:
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		writeMarkdown(w, selected)
+	}
+}
+
+// writeCSV экранирует ячейки, которые табличный редактор принял бы за формулу
+func writeCSV(w io.Writer, tables []reportTable) error {
+	writer := csv.NewWriter(w)
+	for i, table := range tables {
+		if len(tables) > 1 {
+			if i > 0 {
+				writer.Write(nil)
+			}
+			writer.Write([]string{"# " + table.Name})
+		}
+		writer.Write(escapeCSVRow(table.Columns))
+		for _, row := range table.Rows {
+			writer.Write(escapeCSVRow(row))
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// escapeCSVRow добавляет ' перед ячейками, начинающимися с =, +, -, @, табуляции или перевода строки.
+// Числа не меняются: отрицательное число формулой не является
+func escapeCSVRow(cells []string) []string {
+	escaped := make([]string, len(cells))
+	for i, cell := range cells {
+		escaped[i] = cell
+		if cell == "" || !strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+			continue
+		}
+		if _, err := strconv.ParseFloat(cell, 64); err != nil {
+			escaped[i] = "'" + cell
+		}
+	}
+	return escaped
+}
+
+func writeMarkdown(w io.Writer, tables []reportTable) {
+	var b strings.Builder
+	for i, table := range tables {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "### %s\n\n", table.Name)
+		writeMarkdownRow(&b, table.Columns)
+		separators := make([]string, len(table.Columns))
+		for j := range separators {
+			separators[j] = "---"
+		}
+		writeMarkdownRow(&b, separators)
+		for _, row := range table.Rows {
+			writeMarkdownRow(&b, row)
+		}
+	}
+	w.Write([]byte(b.String()))
+}
+
+func writeMarkdownRow(b *strings.Builder, cells []string) {
+	escaped := make([]string, len(cells))
+	for i, cell := range cells {
+		escaped[i] = strings.ReplaceAll(strings.ReplaceAll(cell, "|", `\|`), "\n", " ")
+	}
+	b.WriteString("| " + strings.Join(escaped, " | ") + " |\n")
+}
+
+func statsTables(stats *domain.Stats) []reportTable {
+	reviewers := reportTable{
+		Name:    "reviewer_stats",
+		Columns: []string{"user_id", "username", "total_assigned", "open_assigned", "merged_assigned"},
+	}
+	for _, stat := range stats.ReviewerStats {
+		reviewers.Rows = append(reviewers.Rows, []string{
+			stat.UserID, stat.Username, itoa(stat.TotalAssigned), itoa(stat.OpenAssigned), itoa(stat.MergedAssigned),
+		})
+	}
+
+	prs := reportTable{
+		Name:    "pr_stats",
+		Columns: []string{"pull_request_id", "pull_request_name", "status", "reviewers_count"},
+	}
+	for _, stat := range stats.PRStats {
+		prs.Rows = append(prs.Rows, []string{
+			stat.PullRequestID, stat.PullRequestName, stat.Status, itoa(stat.ReviewersCount),
+		})
+	}
+
+	return []reportTable{
+		{
+			Name:    "summary",
+			Columns: []string{"total_prs", "open_prs", "merged_prs"},
+			Rows:    [][]string{{itoa(stats.TotalPRs), itoa(stats.OpenPRs), itoa(stats.MergedPRs)}},
+		},
+		reviewers,
+		prs,
+	}
+}
+
+func cycleTimeTables(stats *domain.CycleTimeStats) []reportTable {
+	teams := reportTable{
+		Name: "teams",
+		Columns: []string{"team_name",
+			"merged_count", "time_to_merge_median_seconds", "time_to_merge_p90_seconds",
+			"reviewed_count", "time_to_first_review_median_seconds", "time_to_first_review_p90_seconds"},
+	}
+	appendMetrics := func(teamName string, metrics domain.CycleTimeMetrics) {
+		teams.Rows = append(teams.Rows, []string{teamName,
+			itoa(metrics.TimeToMerge.Count), ftoa(metrics.TimeToMerge.MedianSeconds), ftoa(metrics.TimeToMerge.P90Seconds),
+			itoa(metrics.TimeToFirstReview.Count), ftoa(metrics.TimeToFirstReview.MedianSeconds), ftoa(metrics.TimeToFirstReview.P90Seconds),
+		})
+	}
+	appendMetrics("*", stats.Overall)
+	for _, team := range stats.Teams {
+		appendMetrics(team.TeamName, team.CycleTimeMetrics)
+	}
+
+	reviewers := reportTable{
+		Name:    "reviewers",
+		Columns: []string{"user_id", "username", "team_name", "reviewed_count", "time_to_review_median_seconds", "time_to_review_p90_seconds"},
+	}
+	for _, reviewer := range stats.Reviewers {
+		reviewers.Rows = append(reviewers.Rows, []string{
+			reviewer.UserID, reviewer.Username, reviewer.TeamName,
+			itoa(reviewer.TimeToReview.Count), ftoa(reviewer.TimeToReview.MedianSeconds), ftoa(reviewer.TimeToReview.P90Seconds),
+		})
+	}
+
+	return []reportTable{teams, reviewers}
+}
+
+func fairnessTables(report *domain.FairnessReport) []reportTable {
+	teams := reportTable{
+		Name: "teams",
+		Columns: []string{"team_name", "active_members",
+			"open_min", "open_max", "open_mean", "open_std_dev", "open_gini", "open_max_min_ratio",
+			"total_min", "total_max", "total_mean", "total_std_dev", "total_gini", "total_max_min_ratio",
+			"imbalanced"},
+	}
+	members := reportTable{
+		Name:    "members",
+		Columns: []string{"team_name", "user_id", "username", "total_assigned", "open_assigned", "merged_assigned"},
+	}
+	for _, team := range report.Teams {
+		row := []string{team.TeamName, itoa(team.ActiveMembers)}
+		for _, dist := range []domain.LoadDistribution{team.OpenAssignments, team.TotalAssignments} {
+			row = append(row, itoa(dist.Min), itoa(dist.Max), ftoa(&dist.Mean), ftoa(&dist.StdDev), ftoa(&dist.Gini), ftoa(dist.MaxMinRatio))
+		}
+		teams.Rows = append(teams.Rows, append(row, strconv.FormatBool(team.Imbalanced)))
+
+		for _, member := range team.Members {
+			members.Rows = append(members.Rows, []string{
+				team.TeamName, member.UserID, member.Username,
+				itoa(member.TotalAssigned), itoa(member.OpenAssigned), itoa(member.MergedAssigned),
+			})
+		}
+	}
+	return []reportTable{teams, members}
+}
+
+func agingTables(report *domain.AgingReport) []reportTable {
+	bucketColumns := []string{"lt_1d", "1d_3d", "3d_7d", "gt_7d"}
+	bucketCells := func(b domain.AgeBuckets) []string {
+		return []string{itoa(b.LessThan1Day), itoa(b.From1To3Days), itoa(b.From3To7Days), itoa(b.MoreThan7Days)}
+	}
+
+	teams := reportTable{
+		Name:    "teams",
+		Columns: append([]string{"team_name"}, bucketColumns...),
+		Rows:    [][]string{append([]string{"*"}, bucketCells(report.Buckets)...)},
+	}
+	for _, team := range report.Teams {
+		teams.Rows = append(teams.Rows, append([]string{team.TeamName}, bucketCells(team.Buckets)...))
+	}
+
+	reviewers := reportTable{
+		Name:    "reviewers",
+		Columns: append([]string{"user_id", "username", "team_name"}, bucketColumns...),
+	}
+	for _, reviewer := range report.Reviewers {
+		reviewers.Rows = append(reviewers.Rows, append([]string{reviewer.UserID, reviewer.Username, reviewer.TeamName}, bucketCells(reviewer.Buckets)...))
+	}
+
+	oldest := reportTable{
+		Name:    "oldest",
+		Columns: []string{"pull_request_id", "pull_request_name", "author_id", "author_team", "created_at", "age_seconds", "reviewers"},
+	}
+	for _, pr := range report.Oldest {
+		reviewerIDs := make([]string, len(pr.Reviewers))
+		for i, reviewer := range pr.Reviewers {
+			reviewerIDs[i] = reviewer.UserID
+		}
+		oldest.Rows = append(oldest.Rows, []string{
+			pr.PullRequestID, pr.PullRequestName, pr.Author.UserID, pr.Author.TeamName,
+			pr.CreatedAt.Format(time.RFC3339), strconv.FormatInt(pr.AgeSeconds, 10), strings.Join(reviewerIDs, ";"),
+		})
+	}
+
+	return []reportTable{teams, reviewers, oldest}
+}
+
+func historyTables(history *domain.StatsHistory) []reportTable {
+	points := reportTable{
+		Name:    "history",
+		Columns: []string{"scope", "scope_id", "date", "total_prs", "open_prs", "merged_prs"},
+	}
+	for _, series := range history.Series {
+		for _, point := range series.Points {
+			points.Rows = append(points.Rows, []string{
+				history.Scope, series.ScopeID, point.Date, itoa(point.TotalPRs), itoa(point.OpenPRs), itoa(point.MergedPRs),
+			})
+		}
+	}
+	return []reportTable{points}
+}
+
+func itoa(n int) string {
+	return strconv.Itoa(n)
+}
+
+func ftoa(value *float64) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*value, 'f', 2, 64)
+}
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAcceptedFormat(t *testing.T) {
+	cases := []struct {
+		accept string
+		want   string
+	}{
+		{"", formatJSON},
+		{"text/csv", formatCSV},
+		{"text/markdown; charset=utf-8", formatMarkdown},
+		{"text/csv;q=0.5, application/json", formatJSON},
+		{"application/json;q=0.2, text/csv;q=0.8", formatCSV},
+		{"text/csv;q=0, text/markdown;q=0.1", formatMarkdown},
+		{"text/csv;q=0", formatJSON},
+		{"text/html, */*;q=0.1, text/markdown;q=0.3", formatMarkdown},
+		{"text/markdown, text/csv", formatMarkdown},
+		{"TEXT/CSV; Q=0.9, application/json;q=0.5", formatCSV},
+		{"text/csv;q=oops, application/json;q=0.1", formatJSON},
+		{"image/png", formatJSON},
+	}
+	for _, c := range cases {
+		if got := acceptedFormat(c.accept); got != c.want {
+			t.Errorf("acceptedFormat(%q) = %s, want %s", c.accept, got, c.want)
+		}
+	}
+}
+
+func TestNegotiateFormatQueryOverridesAccept(t *testing.T) {
+	r := httptest.NewRequest("GET", "/stats?format=md", nil)
+	r.Header.Set("Accept", "text/csv")
+	if format, ok := negotiateFormat(httptest.NewRecorder(), r); !ok || format != formatMarkdown {
+		t.Errorf("Expected ?format= to win over Accept, got %s", format)
+	}
+
+	w := httptest.NewRecorder()
+	if _, ok := negotiateFormat(w, httptest.NewRequest("GET", "/stats?format=xml", nil)); ok || w.Code != 400 {
+		t.Errorf("Expected unknown format to be rejected, got %d", w.Code)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	tables := []reportTable{
+		{Name: "summary", Columns: []string{"a", "b"}, Rows: [][]string{{"1", "-2.50"}}},
+		{Name: "prs", Columns: []string{"pull_request_id", "pull_request_name"}, Rows: [][]string{
+			{"pr-1", `=HYPERLINK("http://evil","x")`},
+			{"+1", "@SUM(A1)"},
+			{"-cmd", "plain, with comma"},
+			{"\tTAB", ""},
+		}},
+	}
+
+	var b bytes.Buffer
+	if err := writeCSV(&b, tables); err != nil {
+		t.Fatal(err)
+	}
+	want := "# summary\n" +
+		"a,b\n" +
+		"1,-2.50\n" +
+		"\n" +
+		"# prs\n" +
+		"pull_request_id,pull_request_name\n" +
+		"pr-1,\"'=HYPERLINK(\"\"http://evil\"\",\"\"x\"\")\"\n" +
+		"+1,'@SUM(A1)\n" +
+		"'-cmd,\"plain, with comma\"\n" +
+		"'\tTAB,\n"
+	if b.String() != want {
+		t.Errorf("Unexpected CSV:\n%s\nwant:\n%s", b.String(), want)
+	}
+
+	// Один раздел выгружается без заголовка-комментария
+	b.Reset()
+	if err := writeCSV(&b, tables[:1]); err != nil || b.String() != "a,b\n1,-2.50\n" {
+		t.Errorf("Unexpected single-table CSV %q: %v", b.String(), err)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("connection reset")
+}
+
+func TestWriteCSVReportsWriteError(t *testing.T) {
+	if err := writeCSV(failingWriter{}, []reportTable{{Name: "t", Columns: []string{"a"}}}); err == nil {
+		t.Error("Expected write error to be returned")
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	var b bytes.Buffer
+	writeMarkdown(&b, []reportTable{
+		{Name: "summary", Columns: []string{"a", "b"}, Rows: [][]string{{"1", "2"}}},
+		{Name: "prs", Columns: []string{"name"}, Rows: [][]string{{"a|b\nc"}}},
+	})
+	want := "### summary\n\n" +
+		"| a | b |\n" +
+		"| --- | --- |\n" +
+		"| 1 | 2 |\n" +
+		"\n" +
+		"### prs\n\n" +
+		"| name |\n" +
+		"| --- |\n" +
+		"| a\\|b c |\n"
+	if b.String() != want {
+		t.Errorf("Unexpected Markdown:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
@@ -1,8 +1,12 @@
 package handler
 
 import (
+	"encoding/json"
 	"net/http"
+	"strconv"
+	"time"
 
+	"pr-review-manager/internal/domain"
 	"pr-review-manager/internal/service"
 )
 
@@ -15,11 +19,197 @@ func NewStatsHandler(statsService *service.StatsService) *StatsHandler {
 }
 
 func (h *StatsHandler) GetStats(w http.ResponseWriter, r *http.Request) {
-	stats, err := h.statsService.GetStats(r.Context())
+	format, ok := negotiateFormat(w, r)
+	if !ok {
+		return
+	}
+
+	filter, ok := parseStatsFilter(w, r)
+	if !ok {
+		return
+	}
+
+	stats, err := h.statsService.GetStats(r.Context(), filter)
+	if err != nil {
+		handleServiceError(w, r, err)
+		return
+	}
+
+	respondReport(w, r, format, stats, func() []reportTable { return statsTables(stats) })
+}
+
+func (h *StatsHandler) GetCycleTime(w http.ResponseWriter, r *http.Request) {
+	format, ok := negotiateFormat(w, r)
+	if !ok {
+		return
+	}
+
+	filter, ok := parseStatsFilter(w, r)
+	if !ok {
+		return
+	}
+
+	stats, err := h.statsService.GetCycleTime(r.Context(), filter)
+	if err != nil {
+		handleServiceError(w, r, err)
+		return
+	}
+
+	respondReport(w, r, format, stats, func() []reportTable { return cycleTimeTables(stats) })
+}
+
+func (h *StatsHandler) GetFairness(w http.ResponseWriter, r *http.Request) {
+	format, ok := negotiateFormat(w, r)
+	if !ok {
+		return
+	}
+
+	var threshold *float64
+	if value := r.URL.Query().Get("threshold"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed < 0 || parsed > 1 {
+			respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "threshold must be a number between 0 and 1")
+			return
+		}
+		threshold = &parsed
+	}
+
+	report, err := h.statsService.GetFairness(r.Context(), r.URL.Query().Get("team_name"), threshold)
 	if err != nil {
-		handleServiceError(w, err)
+		handleServiceError(w, r, err)
 		return
 	}
 
-	respondJSON(w, http.StatusOK, stats)
+	respondReport(w, r, format, report, func() []reportTable { return fairnessTables(report) })
+}
+
+func (h *StatsHandler) GetAging(w http.ResponseWriter, r *http.Request) {
+	format, ok := negotiateFormat(w, r)
+	if !ok {
+		return
+	}
+
+	limit, err := parseInt(r.URL.Query().Get("limit"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "limit must be a non-negative integer")
+		return
+	}
+
+	report, err := h.statsService.GetAging(r.Context(), r.URL.Query().Get("team_name"), limit)
+	if err != nil {
+		handleServiceError(w, r, err)
+		return
+	}
+
+	respondReport(w, r, format, report, func() []reportTable { return agingTables(report) })
+}
+
+func (h *StatsHandler) GetHistory(w http.ResponseWriter, r *http.Request) {
+	format, ok := negotiateFormat(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	scope := query.Get("scope")
+	switch scope {
+	case "":
+		scope = domain.SnapshotScopeOverall
+	case domain.SnapshotScopeOverall, domain.SnapshotScopeTeam, domain.SnapshotScopeReviewer:
+	default:
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "scope must be one of overall, team, reviewer")
+		return
+	}
+
+	from, err := parseOptionalTime(query.Get("from"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "from must be RFC3339 or YYYY-MM-DD")
+		return
+	}
+	to, err := parseOptionalTime(query.Get("to"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "to must be RFC3339 or YYYY-MM-DD")
+		return
+	}
+
+	history, err := h.statsService.GetHistory(r.Context(), scope, query.Get("scope_id"), from, to)
+	if err != nil {
+		handleServiceError(w, r, err)
+		return
+	}
+
+	respondReport(w, r, format, history, func() []reportTable { return historyTables(history) })
+}
+
+func (h *StatsHandler) BackfillHistory(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		From string `json:"from"`
+		To   string `json:"to"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+			return
+		}
+	}
+
+	from, err := parseOptionalTime(req.From)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "from must be RFC3339 or YYYY-MM-DD")
+		return
+	}
+	to, err := parseOptionalTime(req.To)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "to must be RFC3339 or YYYY-MM-DD")
+		return
+	}
+
+	days, err := h.statsService.BackfillSnapshots(r.Context(), from, to)
+	if err != nil {
+		handleServiceError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"days": days,
+	})
+}
+
+// parseStatsFilter разбирает from/to (RFC3339 или YYYY-MM-DD) и team_name.
+// При ошибке сам отвечает 400 и возвращает ok=false
+func parseStatsFilter(w http.ResponseWriter, r *http.Request) (domain.StatsFilter, bool) {
+	query := r.URL.Query()
+	filter := domain.StatsFilter{
+		TeamName: query.Get("team_name"),
+	}
+
+	var err error
+	if filter.From, err = parseOptionalTime(query.Get("from")); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "from must be RFC3339 or YYYY-MM-DD")
+		return filter, false
+	}
+	if filter.To, err = parseOptionalTime(query.Get("to")); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "to must be RFC3339 or YYYY-MM-DD")
+		return filter, false
+	}
+
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "from must be before to")
+		return filter, false
+	}
+
+	return filter, true
+}
+
+func parseOptionalTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		if t, err = time.Parse(time.DateOnly, value); err != nil {
+			return nil, err
+		}
+	}
+	return &t, nil
 }
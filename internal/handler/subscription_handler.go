@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+
+	"pr-review-manager/internal/domain"
+	"pr-review-manager/internal/service"
+)
+
+type SubscriptionHandler struct {
+	subscriptionService *service.SubscriptionService
+}
+
+func NewSubscriptionHandler(subscriptionService *service.SubscriptionService) *SubscriptionHandler {
+	return &SubscriptionHandler{subscriptionService: subscriptionService}
+}
+
+func (h *SubscriptionHandler) CreateSubscription(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		URL        string   `json:"url"`
+		Secret     string   `json:"secret"`
+		EventTypes []string `json:"event_types"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		return
+	}
+
+	if req.URL == "" || req.Secret == "" {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "url and secret are required")
+		return
+	}
+
+	sub, err := h.subscriptionService.CreateSubscription(r.Context(), req.URL, req.Secret, req.EventTypes)
+	if err != nil {
+		handleServiceError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, map[string]interface{}{
+		"subscription": sub,
+	})
+}
+
+func (h *SubscriptionHandler) ListSubscriptions(w http.ResponseWriter, r *http.Request) {
+	subs, err := h.subscriptionService.ListSubscriptions(r.Context())
+	if err != nil {
+		handleServiceError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"subscriptions": subs,
+	})
+}
+
+func (h *SubscriptionHandler) DeleteSubscription(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		SubscriptionID string `json:"subscription_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		return
+	}
+
+	if req.SubscriptionID == "" {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "subscription_id is required")
+		return
+	}
+
+	sub, err := h.subscriptionService.DeleteSubscription(r.Context(), req.SubscriptionID)
+	if err != nil {
+		handleServiceError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"subscription": sub,
+	})
+}
+
+func (h *SubscriptionHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	status := query.Get("status")
+	if status != "" && !slices.Contains([]string{domain.DeliveryPending, domain.DeliveryDelivered, domain.DeliveryFailed}, status) {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "status must be pending, delivered or failed")
+		return
+	}
+	limit, err := parseInt(query.Get("limit"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "limit must be a non-negative integer")
+		return
+	}
+
+	deliveries, err := h.subscriptionService.ListDeliveries(r.Context(), query.Get("subscription_id"), status, limit)
+	if err != nil {
+		handleServiceError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"deliveries": deliveries,
+	})
+}
+
+func (h *SubscriptionHandler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		DeliveryID int64 `json:"delivery_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		return
+	}
+
+	if req.DeliveryID <= 0 {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "delivery_id is required")
+		return
+	}
+
+	delivery, err := h.subscriptionService.Redeliver(r.Context(), req.DeliveryID)
+	if err != nil {
+		handleServiceError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusAccepted, map[string]interface{}{
+		"delivery": delivery,
+	})
+}
@@ -2,10 +2,12 @@ package handler
 
 import (
 	"encoding/json"
+	"io"
 	"net/http"
 
 	"pr-review-manager/internal/domain"
 	"pr-review-manager/internal/errors"
+	"pr-review-manager/internal/logging"
 	"pr-review-manager/internal/service"
 )
 
@@ -26,7 +28,7 @@ func (h *TeamHandler) AddTeam(w http.ResponseWriter, r *http.Request) {
 
 	result, err := h.teamService.AddTeam(r.Context(), &team)
 	if err != nil {
-		handleServiceError(w, err)
+		handleServiceError(w, r, err)
 		return
 	}
 
@@ -44,7 +46,7 @@ func (h *TeamHandler) GetTeam(w http.ResponseWriter, r *http.Request) {
 
 	team, err := h.teamService.GetTeam(r.Context(), teamName)
 	if err != nil {
-		handleServiceError(w, err)
+		handleServiceError(w, r, err)
 		return
 	}
 
@@ -67,7 +69,7 @@ func (h *TeamHandler) DeactivateTeam(w http.ResponseWriter, r *http.Request) {
 
 	deactivatedCount, affectedPRs, err := h.teamService.DeactivateTeam(r.Context(), req.TeamName)
 	if err != nil {
-		handleServiceError(w, err)
+		handleServiceError(w, r, err)
 		return
 	}
 
@@ -94,10 +96,29 @@ func respondError(w http.ResponseWriter, status int, code, message string) {
 	})
 }
 
-func handleServiceError(w http.ResponseWriter, err error) {
+// maxBodyBytes ограничивает тела, которые читаются в память целиком: вебхуки и запросы с Idempotency-Key
+const maxBodyBytes = 5 << 20
+
+// readBody читает тело не больше maxBodyBytes. При ошибке сам отвечает 413 или 400 и возвращает ok=false
+func readBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	if err != nil {
+		if _, tooLarge := err.(*http.MaxBytesError); tooLarge {
+			handleServiceError(w, r, errors.ErrBodyTooLarge)
+		} else {
+			respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		}
+		return nil, false
+	}
+	return body, true
+}
+
+func handleServiceError(w http.ResponseWriter, r *http.Request, err error) {
 	if appErr, ok := err.(*errors.AppError); ok {
+		logging.FromContext(r.Context()).Info("request rejected", "code", appErr.Code)
 		respondError(w, appErr.HTTPStatus, appErr.Code, appErr.Message)
 	} else {
+		logging.FromContext(r.Context()).Error("request failed", "error", err)
 		respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
 	}
 }
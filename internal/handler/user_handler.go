@@ -3,7 +3,9 @@ package handler
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
 
+	"pr-review-manager/internal/domain"
 	"pr-review-manager/internal/service"
 )
 
@@ -27,7 +29,7 @@ func (h *UserHandler) SetIsActive(w http.ResponseWriter, r *http.Request) {
 
 	user, err := h.userService.SetIsActive(r.Context(), req.UserID, req.IsActive)
 	if err != nil {
-		handleServiceError(w, err)
+		handleServiceError(w, r, err)
 		return
 	}
 
@@ -36,6 +38,75 @@ func (h *UserHandler) SetIsActive(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (h *UserHandler) SetRole(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		UserID string `json:"user_id"`
+		Role   string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		return
+	}
+
+	user, err := h.userService.SetRole(r.Context(), req.UserID, req.Role)
+	if err != nil {
+		handleServiceError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"user": user,
+	})
+}
+
+func (h *UserHandler) LinkAccount(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		UserID   string `json:"user_id"`
+		Provider string `json:"provider"`
+		Login    string `json:"login"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		return
+	}
+
+	if req.UserID == "" || req.Login == "" {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "user_id and login are required")
+		return
+	}
+
+	account, err := h.userService.LinkAccount(r.Context(), req.UserID, req.Provider, req.Login)
+	if err != nil {
+		handleServiceError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"account": account,
+	})
+}
+
+func (h *UserHandler) UnlinkAccount(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Provider string `json:"provider"`
+		Login    string `json:"login"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		return
+	}
+
+	account, err := h.userService.UnlinkAccount(r.Context(), req.Provider, req.Login)
+	if err != nil {
+		handleServiceError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"account": account,
+	})
+}
+
 func (h *UserHandler) GetReview(w http.ResponseWriter, r *http.Request) {
 	userID := r.URL.Query().Get("user_id")
 	if userID == "" {
@@ -43,14 +114,94 @@ func (h *UserHandler) GetReview(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	prs, err := h.userService.GetReview(r.Context(), userID)
+	query := r.URL.Query()
+	status := query.Get("status")
+	switch status {
+	case "", domain.StatusOpen, domain.StatusMerged, domain.StatusAll:
+	default:
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "status must be one of OPEN, MERGED, ALL")
+		return
+	}
+
+	limit, err := parseInt(query.Get("limit"))
 	if err != nil {
-		handleServiceError(w, err)
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "limit must be a non-negative integer")
 		return
 	}
 
-	respondJSON(w, http.StatusOK, map[string]interface{}{
-		"user_id":       userID,
-		"pull_requests": prs,
-	})
+	page, err := h.userService.GetReview(r.Context(), userID, status, query.Get("cursor"), limit)
+	if err != nil {
+		handleServiceError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, page)
+}
+
+func (h *UserHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := domain.UserListFilter{
+		TeamName: query.Get("team_name"),
+	}
+
+	if value := query.Get("is_active"); value != "" {
+		isActive, err := strconv.ParseBool(value)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "is_active must be a boolean")
+			return
+		}
+		filter.IsActive = &isActive
+	}
+
+	var err error
+	if filter.MinOpenReviews, err = parseOptionalInt(query.Get("min_open_reviews")); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "min_open_reviews must be a non-negative integer")
+		return
+	}
+	if filter.MaxOpenReviews, err = parseOptionalInt(query.Get("max_open_reviews")); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "max_open_reviews must be a non-negative integer")
+		return
+	}
+	if filter.MinOpenReviews != nil && filter.MaxOpenReviews != nil && *filter.MinOpenReviews > *filter.MaxOpenReviews {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "min_open_reviews must not exceed max_open_reviews")
+		return
+	}
+	if filter.Limit, err = parseInt(query.Get("limit")); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "limit must be a non-negative integer")
+		return
+	}
+	if filter.Offset, err = parseInt(query.Get("offset")); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "offset must be a non-negative integer")
+		return
+	}
+
+	page, err := h.userService.ListUsers(r.Context(), filter)
+	if err != nil {
+		handleServiceError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, page)
+}
+
+func parseInt(value string) (int, error) {
+	n, err := parseOptionalInt(value)
+	if err != nil || n == nil {
+		return 0, err
+	}
+	return *n, nil
+}
+
+func parseOptionalInt(value string) (*int, error) {
+	if value == "" {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 {
+		return nil, strconv.ErrRange
+	}
+	return &n, nil
 }
@@ -0,0 +1,51 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"pr-review-manager/internal/logging"
+	"pr-review-manager/internal/service"
+)
+
+// IdempotencyCleanupJob удаляет сохранённые ответы с истёкшим сроком хранения
+type IdempotencyCleanupJob struct {
+	idempotencyService *service.IdempotencyService
+	interval           time.Duration
+}
+
+func NewIdempotencyCleanupJob(idempotencyService *service.IdempotencyService, interval time.Duration) *IdempotencyCleanupJob {
+	return &IdempotencyCleanupJob{
+		idempotencyService: idempotencyService,
+		interval:           interval,
+	}
+}
+
+func (j *IdempotencyCleanupJob) Run(ctx context.Context) {
+	ctx = logging.With(ctx, "job", "idempotency_cleanup")
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			j.cleanup(ctx)
+		}
+	}
+}
+
+func (j *IdempotencyCleanupJob) cleanup(ctx context.Context) {
+	deleted, err := j.idempotencyService.PurgeExpired(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			logging.FromContext(ctx).Error("Failed to delete expired idempotency keys", "error", err)
+		}
+		return
+	}
+	if deleted > 0 {
+		logging.FromContext(ctx).Info("Deleted expired idempotency keys", "count", deleted)
+	}
+}
@@ -0,0 +1,113 @@
+package jobs
+
+import (
+	"context"
+
+	"pr-review-manager/internal/auth"
+	"pr-review-manager/internal/domain"
+	"pr-review-manager/internal/github"
+	"pr-review-manager/internal/logging"
+	"pr-review-manager/internal/metrics"
+	"pr-review-manager/internal/service"
+)
+
+// reviewerSyncQueueSize — сколько изменений может ждать отправки; при переполнении новые отбрасываются
+const reviewerSyncQueueSize = 1000
+
+type reviewerChange struct {
+	orgID   string
+	prID    string
+	added   []string
+	removed []string
+}
+
+// ReviewerSyncJob отправляет назначения ревьюверов в GitHub в фоне, чтобы повторы запросов
+// к API не задерживали ответ. Синхронизируются только PR вида "owner/repo#number"
+// и пользователи с привязанным логином GitHub
+type ReviewerSyncJob struct {
+	client      *github.Client
+	userService *service.UserService
+	queue       chan reviewerChange
+}
+
+func NewReviewerSyncJob(client *github.Client, userService *service.UserService) *ReviewerSyncJob {
+	return &ReviewerSyncJob{
+		client:      client,
+		userService: userService,
+		queue:       make(chan reviewerChange, reviewerSyncQueueSize),
+	}
+}
+
+func (j *ReviewerSyncJob) ReviewersChanged(ctx context.Context, prID string, added, removed []string) {
+	if _, _, ok := github.ParsePullRequestID(prID); !ok {
+		return
+	}
+	orgID, ok := auth.OrgFromContext(ctx)
+	if !ok {
+		logging.FromContext(ctx).Error("GitHub reviewer sync skipped", "error", auth.ErrNoOrg)
+		return
+	}
+
+	select {
+	case j.queue <- reviewerChange{orgID: orgID, prID: prID, added: added, removed: removed}:
+	default:
+		metrics.ReviewerSync.WithLabelValues("dropped").Inc()
+		logging.FromContext(ctx).Warn("GitHub reviewer sync queue is full, change dropped")
+	}
+}
+
+// Run обрабатывает очередь до остановки; изменения, не отправленные к этому моменту, теряются
+func (j *ReviewerSyncJob) Run(ctx context.Context) {
+	ctx = logging.With(ctx, "job", "github_reviewer_sync")
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case change := <-j.queue:
+			outcome := j.sync(ctx, change)
+			metrics.ReviewerSync.WithLabelValues(outcome).Inc()
+		}
+	}
+}
+
+func (j *ReviewerSyncJob) sync(ctx context.Context, change reviewerChange) string {
+	ctx = logging.With(auth.WithOrg(ctx, change.orgID), "org_id", change.orgID, "pr_id", change.prID)
+	repo, number, _ := github.ParsePullRequestID(change.prID)
+
+	logins, err := j.userService.AccountLogins(ctx, domain.ProviderGitHub, append(append([]string{}, change.added...), change.removed...))
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to resolve GitHub logins", "error", err)
+		return "failed"
+	}
+	added := loginsOf(change.added, logins)
+	removed := loginsOf(change.removed, logins)
+	if len(added) == 0 && len(removed) == 0 {
+		return "skipped"
+	}
+
+	// Сначала запрашиваем новых ревьюверов, чтобы при ошибке PR не остался без них
+	if len(added) > 0 {
+		if err := j.client.RequestReviewers(ctx, repo, number, added); err != nil {
+			logging.FromContext(ctx).Error("Failed to request GitHub reviewers", "logins", added, "error", err)
+			return "failed"
+		}
+	}
+	if len(removed) > 0 {
+		if err := j.client.RemoveReviewers(ctx, repo, number, removed); err != nil {
+			logging.FromContext(ctx).Error("Failed to remove GitHub reviewers", "logins", removed, "error", err)
+			return "failed"
+		}
+	}
+	logging.FromContext(ctx).Info("GitHub reviewers synced", "requested", added, "removed", removed)
+	return "synced"
+}
+
+func loginsOf(userIDs []string, logins map[string]string) []string {
+	result := []string{}
+	for _, userID := range userIDs {
+		if login, ok := logins[userID]; ok {
+			result = append(result, login)
+		}
+	}
+	return result
+}
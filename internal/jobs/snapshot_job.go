@@ -0,0 +1,73 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"pr-review-manager/internal/auth"
+	"pr-review-manager/internal/logging"
+	"pr-review-manager/internal/service"
+)
+
+// SnapshotJob периодически сохраняет дневные снимки статистики каждой организации.
+// При первом запуске восстанавливает историю по уже существующим PR
+type SnapshotJob struct {
+	statsService *service.StatsService
+	orgService   *service.OrgService
+	interval     time.Duration
+}
+
+func NewSnapshotJob(statsService *service.StatsService, orgService *service.OrgService, interval time.Duration) *SnapshotJob {
+	return &SnapshotJob{
+		statsService: statsService,
+		orgService:   orgService,
+		interval:     interval,
+	}
+}
+
+func (j *SnapshotJob) Run(ctx context.Context) {
+	ctx = logging.With(ctx, "job", "stats_snapshot")
+	j.forEachOrg(ctx, func(ctx context.Context) {
+		if err := j.statsService.EnsureHistory(ctx); err != nil {
+			logging.FromContext(ctx).Error("Failed to backfill stats history", "error", err)
+		}
+	})
+	j.snapshot(ctx)
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			j.snapshot(ctx)
+		}
+	}
+}
+
+func (j *SnapshotJob) snapshot(ctx context.Context) {
+	j.forEachOrg(ctx, func(ctx context.Context) {
+		if err := j.statsService.SnapshotDaily(ctx, time.Now().UTC()); err != nil && ctx.Err() == nil {
+			logging.FromContext(ctx).Error("Failed to save stats snapshot", "error", err)
+		}
+	})
+}
+
+// forEachOrg выполняет fn в контексте каждой организации; ошибка одной не мешает остальным
+func (j *SnapshotJob) forEachOrg(ctx context.Context, fn func(ctx context.Context)) {
+	orgs, err := j.orgService.ListOrgs(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			logging.FromContext(ctx).Error("Failed to list organizations", "error", err)
+		}
+		return
+	}
+	for _, org := range orgs {
+		if ctx.Err() != nil {
+			return
+		}
+		fn(logging.With(auth.WithOrg(ctx, org.OrgID), "org_id", org.OrgID))
+	}
+}
@@ -0,0 +1,77 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"pr-review-manager/internal/logging"
+	"pr-review-manager/internal/service"
+)
+
+// deliveryLogPurgeInterval — как часто из журнала удаляются старые доставки
+const deliveryLogPurgeInterval = time.Hour
+
+// WebhookDeliveryJob отправляет исходящие вебхуки из очереди в БД и повторяет неудачные
+// по расписанию. Несколько экземпляров сервиса могут работать одновременно
+type WebhookDeliveryJob struct {
+	subscriptionService *service.SubscriptionService
+	interval            time.Duration
+	retention           time.Duration
+}
+
+func NewWebhookDeliveryJob(subscriptionService *service.SubscriptionService, interval, retention time.Duration) *WebhookDeliveryJob {
+	return &WebhookDeliveryJob{
+		subscriptionService: subscriptionService,
+		interval:            interval,
+		retention:           retention,
+	}
+}
+
+func (j *WebhookDeliveryJob) Run(ctx context.Context) {
+	ctx = logging.With(ctx, "job", "webhook_delivery")
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+	purgeTicker := time.NewTicker(deliveryLogPurgeInterval)
+	defer purgeTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			j.deliver(ctx)
+		case <-purgeTicker.C:
+			j.purge(ctx)
+		}
+	}
+}
+
+// deliver отправляет пачки, пока очередь не опустеет, чтобы всплеск событий не растягивался на много интервалов
+func (j *WebhookDeliveryJob) deliver(ctx context.Context) {
+	for ctx.Err() == nil {
+		sent, err := j.subscriptionService.DeliverDue(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				logging.FromContext(ctx).Error("Failed to deliver webhooks", "error", err)
+			}
+			return
+		}
+		if sent == 0 {
+			return
+		}
+	}
+}
+
+func (j *WebhookDeliveryJob) purge(ctx context.Context) {
+	deleted, err := j.subscriptionService.PurgeDeliveries(ctx, j.retention)
+	if err != nil {
+		if ctx.Err() == nil {
+			logging.FromContext(ctx).Error("Failed to delete old webhook deliveries", "error", err)
+		}
+		return
+	}
+	if deleted > 0 {
+		logging.FromContext(ctx).Info("Deleted old webhook deliveries", "count", deleted)
+	}
+}
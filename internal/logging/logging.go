@@ -0,0 +1,122 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+type Config struct {
+	Level  string `yaml:"level"`
+	Format string `yaml:"format"`
+}
+
+func New(cfg Config, w io.Writer) (*slog.Logger, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q: %w", cfg.Level, err)
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	switch strings.ToLower(cfg.Format) {
+	case FormatJSON:
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	case FormatText:
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("invalid log format %q", cfg.Format)
+	}
+}
+
+type loggerKey struct{}
+
+// FromContext возвращает логгер запроса со всеми накопленными атрибутами либо slog.Default()
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// With добавляет атрибуты (user_id, pr_id, ...) ко всем последующим записям в рамках ctx
+func With(ctx context.Context, args ...any) context.Context {
+	return WithLogger(ctx, FromContext(ctx).With(args...))
+}
+
+// routeHandler дописывает шаблон маршрута в момент записи: когда логгер запроса создаётся,
+// chi ещё не выбрал маршрут, а slog вычисляет атрибуты из With сразу
+type routeHandler struct {
+	slog.Handler
+	rctx *chi.Context
+}
+
+func (h *routeHandler) Handle(ctx context.Context, record slog.Record) error {
+	if h.rctx != nil {
+		record.AddAttrs(slog.String("route", h.rctx.RoutePattern()))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *routeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &routeHandler{Handler: h.Handler.WithAttrs(attrs), rctx: h.rctx}
+}
+
+func (h *routeHandler) WithGroup(name string) slog.Handler {
+	return &routeHandler{Handler: h.Handler.WithGroup(name), rctx: h.rctx}
+}
+
+// Middleware создаёт логгер запроса с request_id, маршрутом и trace_id и пишет итоговую запись о запросе.
+// Должен стоять после middleware.RequestID и tracing.Middleware
+func Middleware(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			requestLogger := slog.New(&routeHandler{
+				Handler: logger.Handler(),
+				rctx:    chi.RouteContext(r.Context()),
+			}).With(
+				slog.String("request_id", middleware.GetReqID(r.Context())),
+				slog.String("method", r.Method),
+			)
+			if spanContext := trace.SpanContextFromContext(r.Context()); spanContext.IsValid() {
+				requestLogger = requestLogger.With(slog.String("trace_id", spanContext.TraceID().String()))
+			}
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r.WithContext(WithLogger(r.Context(), requestLogger)))
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			level := slog.LevelInfo
+			if status >= http.StatusInternalServerError {
+				level = slog.LevelError
+			}
+			requestLogger.Log(r.Context(), level, "request completed",
+				slog.String("path", r.URL.Path),
+				slog.Int("status", status),
+				slog.Int("bytes", ww.BytesWritten()),
+				slog.Duration("duration", time.Since(start)),
+				slog.String("remote_addr", r.RemoteAddr),
+			)
+		})
+	}
+}
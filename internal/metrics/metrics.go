@@ -0,0 +1,184 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"pr-review-manager/internal/domain"
+)
+
+const namespace = "pr_review"
+
+// Registry — собственный реестр сервиса, чтобы метрики можно было проверять в тестах без глобального состояния prometheus
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route pattern and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method and route pattern.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	Reassignments = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reviewer_reassignments_total",
+		Help:      "Reviewer reassignments by source (manual or team_deactivation).",
+	}, []string{"source"})
+
+	NoCandidateErrors = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "no_candidate_errors_total",
+		Help:      "Reassignments rejected because no active replacement was available.",
+	})
+
+	RateLimited = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limited_requests_total",
+		Help:      "Requests rejected with 429 by rate limit bucket (read, write or route path).",
+	}, []string{"bucket"})
+
+	IdempotentReplays = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "idempotent_replays_total",
+		Help:      "Responses replayed from storage for a repeated Idempotency-Key.",
+	})
+
+	WebhookEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_events_total",
+		Help:      "Incoming webhook events by provider, action and outcome (applied or ignored).",
+	}, []string{"provider", "action", "outcome"})
+
+	ReviewerSync = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "github_reviewer_sync_total",
+		Help:      "Reviewer changes pushed to GitHub by outcome (synced, skipped, failed or dropped).",
+	}, []string{"outcome"})
+
+	WebhookDeliveries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "outgoing_webhook_deliveries_total",
+		Help:      "Outgoing webhook delivery attempts by outcome (delivered, retry or failed).",
+	}, []string{"outcome"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpDuration,
+		Reassignments,
+		NoCandidateErrors,
+		RateLimited,
+		IdempotentReplays,
+		WebhookEvents,
+		ReviewerSync,
+		WebhookDeliveries,
+	)
+}
+
+// RegisterDB добавляет статистику пула соединений и доменные gauge'и, которые считаются при каждом scrape
+func RegisterDB(db *sql.DB, source DomainSource) {
+	Registry.MustRegister(
+		collectors.NewDBStatsCollector(db, "postgres"),
+		newDomainCollector(source),
+	)
+}
+
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// Middleware считает запросы по шаблону маршрута chi, а не по сырому пути, чтобы не раздувать кардинальность
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r)
+
+		route := "unmatched"
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		httpRequests.WithLabelValues(r.Method, route, strconv.Itoa(status)).Inc()
+		httpDuration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+	})
+}
+
+// DomainSource отдаёт текущие значения доменных gauge'ей
+type DomainSource interface {
+	GetOpenPRsByTeam(ctx context.Context) (map[domain.OrgKey]int, error)
+	GetOpenReviewsByUser(ctx context.Context) (map[domain.OrgKey]int, error)
+}
+
+type domainCollector struct {
+	source      DomainSource
+	openPRs     *prometheus.Desc
+	openReviews *prometheus.Desc
+}
+
+func newDomainCollector(source DomainSource) *domainCollector {
+	return &domainCollector{
+		source: source,
+		openPRs: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "open_prs"),
+			"Open pull requests by author team.",
+			[]string{"org", "team"}, nil,
+		),
+		openReviews: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "open_reviews"),
+			"Open review assignments by user.",
+			[]string{"org", "user_id"}, nil,
+		),
+	}
+}
+
+func (c *domainCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.openPRs
+	ch <- c.openReviews
+}
+
+func (c *domainCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	openPRs, err := c.source.GetOpenPRsByTeam(ctx)
+	if err != nil {
+		slog.Error("Failed to collect open PRs metric", "error", err)
+	}
+	for team, count := range openPRs {
+		ch <- prometheus.MustNewConstMetric(c.openPRs, prometheus.GaugeValue, float64(count), team.OrgID, team.ID)
+	}
+
+	openReviews, err := c.source.GetOpenReviewsByUser(ctx)
+	if err != nil {
+		slog.Error("Failed to collect open reviews metric", "error", err)
+	}
+	for user, count := range openReviews {
+		ch <- prometheus.MustNewConstMetric(c.openReviews, prometheus.GaugeValue, float64(count), user.OrgID, user.ID)
+	}
+}
@@ -0,0 +1,73 @@
+package policy
+
+import (
+	"context"
+	"slices"
+
+	"pr-review-manager/internal/auth"
+	"pr-review-manager/internal/domain"
+	"pr-review-manager/internal/errors"
+)
+
+// Роли проверяются только для пользователей. Запросы по API-ключу ограничены его scope,
+// а вызовы без вызывающего (AUTH_ENABLED=false, CLI, фоновые задания) доверенные
+
+// userCaller возвращает вызывающего-пользователя, если к нему применяются роли
+func userCaller(ctx context.Context) (*domain.Principal, bool) {
+	principal := auth.PrincipalFromContext(ctx)
+	if principal == nil || principal.UserID == "" || principal.Role == domain.RoleAdmin {
+		return nil, false
+	}
+	return principal, true
+}
+
+func RequireAdmin(ctx context.Context) error {
+	if _, restricted := userCaller(ctx); restricted {
+		return errors.ErrAdminOnly
+	}
+	return nil
+}
+
+// CanManageTeam — деактивация команды и управление её участниками: админ или лид этой команды
+func CanManageTeam(ctx context.Context, teamName string) error {
+	caller, restricted := userCaller(ctx)
+	if !restricted || isLeadOf(caller, teamName) {
+		return nil
+	}
+	return errors.ErrNotTeamLead
+}
+
+// CanCreatePR — участник создаёт PR только от своего имени, лид — за любого из своей команды
+func CanCreatePR(ctx context.Context, author *domain.User) error {
+	caller, restricted := userCaller(ctx)
+	if !restricted || caller.UserID == author.UserID || isLeadOf(caller, author.TeamName) {
+		return nil
+	}
+	return errors.ErrNotPRMember
+}
+
+// CanActOnPR — merge и прочие действия над PR: автор, назначенный ревьювер или лид команды автора
+func CanActOnPR(ctx context.Context, pr *domain.PullRequest, authorTeam string) error {
+	caller, restricted := userCaller(ctx)
+	if !restricted || isParticipant(caller, pr) || isLeadOf(caller, authorTeam) {
+		return nil
+	}
+	return errors.ErrNotPRMember
+}
+
+// CanReassign — лид переназначает ревьюверов своей команды, участник — только в своих PR
+func CanReassign(ctx context.Context, pr *domain.PullRequest, oldReviewer *domain.User) error {
+	caller, restricted := userCaller(ctx)
+	if !restricted || isParticipant(caller, pr) || isLeadOf(caller, oldReviewer.TeamName) {
+		return nil
+	}
+	return errors.ErrNotPRMember
+}
+
+func isLeadOf(caller *domain.Principal, teamName string) bool {
+	return caller.Role == domain.RoleTeamLead && caller.TeamName == teamName
+}
+
+func isParticipant(caller *domain.Principal, pr *domain.PullRequest) bool {
+	return pr.AuthorID == caller.UserID || slices.Contains(pr.AssignedReviewers, caller.UserID)
+}
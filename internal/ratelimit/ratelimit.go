@@ -0,0 +1,153 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sweepInterval — как часто удалять корзины, которые успели наполниться и ничем не отличаются от новых
+const sweepInterval = time.Minute
+
+type Limit struct {
+	RPS   float64 `yaml:"rps"`
+	Burst int     `yaml:"burst"`
+}
+
+func (l Limit) Valid() bool {
+	return l.RPS > 0 && l.Burst >= 1
+}
+
+// Config: Read применяется к GET-запросам, Write — к остальным, Routes переопределяет лимит для конкретного пути.
+// TrustedProxies — адреса или CIDR прокси, от которых принимаются X-Forwarded-For и X-Real-IP
+type Config struct {
+	Enabled        bool             `yaml:"enabled"`
+	Read           Limit            `yaml:"read"`
+	Write          Limit            `yaml:"write"`
+	Routes         map[string]Limit `yaml:"routes"`
+	TrustedProxies []string         `yaml:"trusted_proxies"`
+}
+
+// ParseProxies разбирает доверенные прокси: CIDR вида "10.0.0.0/8" или отдельные адреса
+func ParseProxies(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if !strings.Contains(value, "/") {
+			addr, err := netip.ParseAddr(value)
+			if err != nil {
+				return nil, fmt.Errorf("trusted proxy %q: %w", value, err)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return nil, fmt.Errorf("trusted proxy %q: %w", value, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// ParseRoutes разбирает лимиты маршрутов из строки вида "/pullRequest/create=1:5,/team/deactivate=0.2:2"
+func ParseRoutes(value string) (map[string]Limit, error) {
+	routes := make(map[string]Limit)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		path, spec, ok := strings.Cut(item, "=")
+		rps, burst, ok2 := strings.Cut(spec, ":")
+		if !ok || !ok2 {
+			return nil, fmt.Errorf("route limit %q must look like /path=rps:burst", item)
+		}
+		var limit Limit
+		var err error
+		if limit.RPS, err = strconv.ParseFloat(rps, 64); err != nil {
+			return nil, fmt.Errorf("route limit %q: %w", item, err)
+		}
+		if limit.Burst, err = strconv.Atoi(burst); err != nil {
+			return nil, fmt.Errorf("route limit %q: %w", item, err)
+		}
+		routes[strings.TrimSpace(path)] = limit
+	}
+	return routes, nil
+}
+
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// RetryAfter — через сколько появится следующий токен, Reset — через сколько корзина наполнится полностью
+	RetryAfter time.Duration
+	Reset      time.Duration
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	limit   Limit
+}
+
+// Limiter хранит корзины токенов в памяти процесса: у каждой реплики свои лимиты
+type Limiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func NewLimiter() *Limiter {
+	return &Limiter{buckets: make(map[string]*bucket), lastSweep: time.Now()}
+}
+
+// Allow списывает токен из корзины key; корзина создаётся полной
+func (l *Limiter) Allow(key string, limit Limit) Result {
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) > sweepInterval {
+		l.sweep(now)
+	}
+
+	b, ok := l.buckets[key]
+	if !ok || b.limit != limit {
+		b = &bucket{tokens: float64(limit.Burst), updated: now, limit: limit}
+		l.buckets[key] = b
+	}
+
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+now.Sub(b.updated).Seconds()*limit.RPS)
+	b.updated = now
+
+	result := Result{Limit: limit.Burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - b.tokens) / limit.RPS)
+	}
+	result.Remaining = int(b.tokens)
+	result.Reset = seconds((float64(limit.Burst) - b.tokens) / limit.RPS)
+	return result
+}
+
+func (l *Limiter) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		refill := seconds((float64(b.limit.Burst) - b.tokens) / b.limit.RPS)
+		if now.Sub(b.updated) > refill {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
+
+func seconds(value float64) time.Duration {
+	return time.Duration(value * float64(time.Second))
+}
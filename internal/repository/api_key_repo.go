@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+
+	"pr-review-manager/internal/auth"
+	"pr-review-manager/internal/domain"
+	"pr-review-manager/internal/tracing"
+)
+
+type APIKeyRepository struct {
+	db *sql.DB
+}
+
+func NewAPIKeyRepository(db *sql.DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+const apiKeyColumns = "key_id, org_id, name, scopes, created_at, last_used_at, revoked_at"
+
+func scanAPIKey(row interface{ Scan(...any) error }) (*domain.APIKey, error) {
+	var key domain.APIKey
+	var lastUsedAt, revokedAt sql.NullTime
+	if err := row.Scan(&key.KeyID, &key.OrgID, &key.Name, pq.Array(&key.Scopes), &key.CreatedAt, &lastUsedAt, &revokedAt); err != nil {
+		return nil, err
+	}
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+	return &key, nil
+}
+
+func (r *APIKeyRepository) CreateKey(ctx context.Context, key *domain.APIKey, keyHash string) error {
+	ctx, span := tracing.StartQuery(ctx, "APIKeyRepository.CreateKey")
+	defer span.End()
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO api_keys (key_id, org_id, name, key_hash, scopes, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, key.KeyID, key.OrgID, key.Name, keyHash, pq.Array(key.Scopes), key.CreatedAt)
+	return err
+}
+
+// GetKeyByHash возвращает только действующий (не отозванный) ключ. Поиск идёт по всем организациям:
+// организация вызывающего определяется как раз по ключу
+func (r *APIKeyRepository) GetKeyByHash(ctx context.Context, keyHash string) (*domain.APIKey, error) {
+	ctx, span := tracing.StartQuery(ctx, "APIKeyRepository.GetKeyByHash")
+	defer span.End()
+
+	key, err := scanAPIKey(r.db.QueryRowContext(ctx, `
+		SELECT `+apiKeyColumns+`
+		FROM api_keys
+		WHERE key_hash = $1 AND revoked_at IS NULL
+	`, keyHash))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return key, err
+}
+
+func (r *APIKeyRepository) ListKeys(ctx context.Context) ([]domain.APIKey, error) {
+	ctx, span := tracing.StartQuery(ctx, "APIKeyRepository.ListKeys")
+	defer span.End()
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+apiKeyColumns+`
+		FROM api_keys
+		WHERE org_id = $1
+		ORDER BY created_at, key_id
+	`, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []domain.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+	return keys, rows.Err()
+}
+
+// RevokeKey идемпотентна: повторный отзыв сохраняет исходное время
+func (r *APIKeyRepository) RevokeKey(ctx context.Context, keyID string, revokedAt time.Time) (*domain.APIKey, error) {
+	ctx, span := tracing.StartQuery(ctx, "APIKeyRepository.RevokeKey")
+	defer span.End()
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := scanAPIKey(r.db.QueryRowContext(ctx, `
+		UPDATE api_keys
+		SET revoked_at = COALESCE(revoked_at, $3)
+		WHERE org_id = $1 AND key_id = $2
+		RETURNING `+apiKeyColumns, orgID, keyID, revokedAt))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return key, err
+}
+
+// TouchLastUsed обновляет last_used_at не чаще раза в минуту, чтобы не писать в БД на каждый запрос
+func (r *APIKeyRepository) TouchLastUsed(ctx context.Context, keyID string, usedAt time.Time) error {
+	ctx, span := tracing.StartQuery(ctx, "APIKeyRepository.TouchLastUsed")
+	defer span.End()
+
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE api_keys
+		SET last_used_at = $2
+		WHERE key_id = $1 AND (last_used_at IS NULL OR last_used_at < $2::timestamp - INTERVAL '1 minute')
+	`, keyID, usedAt)
+	return err
+}
@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+type HealthRepository struct {
+	db *sql.DB
+}
+
+func NewHealthRepository(db *sql.DB) *HealthRepository {
+	return &HealthRepository{db: db}
+}
+
+func (r *HealthRepository) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
+
+// MigrationVersion читает состояние из таблицы schema_migrations, которую ведёт golang-migrate
+func (r *HealthRepository) MigrationVersion(ctx context.Context) (uint, bool, error) {
+	var version uint
+	var dirty bool
+	err := r.db.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	return version, dirty, err
+}
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"pr-review-manager/internal/auth"
+	"pr-review-manager/internal/domain"
+	"pr-review-manager/internal/tracing"
+)
+
+type IdempotencyRepository struct {
+	db *sql.DB
+}
+
+func NewIdempotencyRepository(db *sql.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+// Reserve занимает ключ за новым запросом. Истёкшая запись перезаписывается;
+// false означает, что ключ уже занят действующей записью
+func (r *IdempotencyRepository) Reserve(ctx context.Context, record *domain.IdempotencyRecord) (bool, error) {
+	ctx, span := tracing.StartQuery(ctx, "IdempotencyRepository.Reserve")
+	defer span.End()
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	var key string
+	err = r.db.QueryRowContext(ctx, `
+		INSERT INTO idempotency_keys (org_id, caller, idempotency_key, request_hash, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (org_id, caller, idempotency_key) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash,
+		    status_code = NULL,
+		    response_body = NULL,
+		    created_at = EXCLUDED.created_at,
+		    expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= EXCLUDED.created_at
+		RETURNING idempotency_key
+	`, orgID, record.Caller, record.Key, record.RequestHash, record.CreatedAt, record.ExpiresAt).Scan(&key)
+
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *IdempotencyRepository) GetRecord(ctx context.Context, caller, key string) (*domain.IdempotencyRecord, error) {
+	ctx, span := tracing.StartQuery(ctx, "IdempotencyRepository.GetRecord")
+	defer span.End()
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var record domain.IdempotencyRecord
+	var statusCode sql.NullInt64
+	err = r.db.QueryRowContext(ctx, `
+		SELECT org_id, caller, idempotency_key, request_hash, status_code, response_body, created_at, expires_at
+		FROM idempotency_keys
+		WHERE org_id = $1 AND caller = $2 AND idempotency_key = $3
+	`, orgID, caller, key).Scan(
+		&record.OrgID, &record.Caller, &record.Key, &record.RequestHash,
+		&statusCode, &record.ResponseBody, &record.CreatedAt, &record.ExpiresAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	record.StatusCode = int(statusCode.Int64)
+	return &record, nil
+}
+
+func (r *IdempotencyRepository) Complete(ctx context.Context, caller, key string, statusCode int, body []byte) error {
+	ctx, span := tracing.StartQuery(ctx, "IdempotencyRepository.Complete")
+	defer span.End()
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, `
+		UPDATE idempotency_keys
+		SET status_code = $4, response_body = $5
+		WHERE org_id = $1 AND caller = $2 AND idempotency_key = $3
+	`, orgID, caller, key, statusCode, body)
+	return err
+}
+
+// Release освобождает ключ незавершённого запроса, чтобы клиент мог его повторить
+func (r *IdempotencyRepository) Release(ctx context.Context, caller, key string) error {
+	ctx, span := tracing.StartQuery(ctx, "IdempotencyRepository.Release")
+	defer span.End()
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, `
+		DELETE FROM idempotency_keys
+		WHERE org_id = $1 AND caller = $2 AND idempotency_key = $3 AND status_code IS NULL
+	`, orgID, caller, key)
+	return err
+}
+
+// DeleteExpired чистит записи всех организаций
+func (r *IdempotencyRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	ctx, span := tracing.StartQuery(ctx, "IdempotencyRepository.DeleteExpired")
+	defer span.End()
+
+	result, err := r.db.ExecContext(ctx, `
+		DELETE FROM idempotency_keys
+		WHERE expires_at <= $1
+	`, now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"pr-review-manager/internal/domain"
+	"pr-review-manager/internal/tracing"
+)
+
+// OrgRepository — единственный репозиторий, работающий вне организации вызывающего
+type OrgRepository struct {
+	db *sql.DB
+}
+
+func NewOrgRepository(db *sql.DB) *OrgRepository {
+	return &OrgRepository{db: db}
+}
+
+func (r *OrgRepository) CreateOrg(ctx context.Context, org *domain.Organization) error {
+	ctx, span := tracing.StartQuery(ctx, "OrgRepository.CreateOrg")
+	defer span.End()
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO organizations (org_id, name, created_at)
+		VALUES ($1, $2, $3)
+	`, org.OrgID, org.Name, org.CreatedAt)
+	return err
+}
+
+func (r *OrgRepository) GetOrg(ctx context.Context, orgID string) (*domain.Organization, error) {
+	ctx, span := tracing.StartQuery(ctx, "OrgRepository.GetOrg")
+	defer span.End()
+
+	var org domain.Organization
+	err := r.db.QueryRowContext(ctx, `
+		SELECT org_id, name, created_at
+		FROM organizations
+		WHERE org_id = $1
+	`, orgID).Scan(&org.OrgID, &org.Name, &org.CreatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+func (r *OrgRepository) ListOrgs(ctx context.Context) ([]domain.Organization, error) {
+	ctx, span := tracing.StartQuery(ctx, "OrgRepository.ListOrgs")
+	defer span.End()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT org_id, name, created_at
+		FROM organizations
+		ORDER BY org_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orgs := []domain.Organization{}
+	for rows.Next() {
+		var org domain.Organization
+		if err := rows.Scan(&org.OrgID, &org.Name, &org.CreatedAt); err != nil {
+			return nil, err
+		}
+		orgs = append(orgs, org)
+	}
+	return orgs, rows.Err()
+}
@@ -7,9 +7,16 @@ import (
 	"strings"
 	"time"
 
+	"pr-review-manager/internal/auth"
 	"pr-review-manager/internal/domain"
+	"pr-review-manager/internal/logging"
+	"pr-review-manager/internal/tracing"
 )
 
+// pgTimestampLayout совпадает с точностью TIMESTAMP в PostgreSQL и не содержит часового пояса:
+// все столбцы TIMESTAMP хранят время UTC, поэтому значения перед записью и сравнением приводятся к UTC
+const pgTimestampLayout = "2006-01-02 15:04:05.999999"
+
 type PRRepository struct {
 	db *sql.DB
 }
@@ -18,14 +25,39 @@ func NewPRRepository(db *sql.DB) *PRRepository {
 	return &PRRepository{db: db}
 }
 
-func (r *PRRepository) GetPRsByReviewer(ctx context.Context, userID string) ([]domain.PullRequestShort, error) {
-	rows, err := r.db.QueryContext(ctx, `
-		SELECT DISTINCT pr.pull_request_id, pr.pull_request_name, pr.author_id, pr.status, pr.created_at
+func (r *PRRepository) GetPRsByReviewer(ctx context.Context, filter domain.ReviewFilter) ([]domain.PullRequestShort, error) {
+	ctx, span := tracing.StartQuery(ctx, "PRRepository.GetPRsByReviewer")
+	defer span.End()
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	conditions := []string{"prr.org_id = $1", "prr.user_id = $2"}
+	args := []interface{}{orgID, filter.UserID}
+
+	if filter.Status != domain.StatusAll {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("pr.status = $%d", len(args)))
+	}
+	if filter.After != nil {
+		args = append(args, filter.After.CreatedAt.UTC().Format(pgTimestampLayout), filter.After.PullRequestID)
+		conditions = append(conditions, fmt.Sprintf("(pr.created_at, pr.pull_request_id) < ($%d::timestamp, $%d)", len(args)-1, len(args)))
+	}
+
+	args = append(args, filter.Limit)
+	query := fmt.Sprintf(`
+		SELECT pr.pull_request_id, pr.pull_request_name, pr.author_id, u.username, pr.status, pr.created_at
 		FROM pull_requests pr
-		JOIN pr_reviewers prr ON pr.pull_request_id = prr.pull_request_id
-		WHERE prr.user_id = $1
-		ORDER BY pr.created_at DESC
-	`, userID)
+		JOIN pr_reviewers prr ON pr.org_id = prr.org_id AND pr.pull_request_id = prr.pull_request_id
+		JOIN users u ON pr.org_id = u.org_id AND pr.author_id = u.user_id
+		WHERE %s
+		ORDER BY pr.created_at DESC, pr.pull_request_id DESC
+		LIMIT $%d
+	`, strings.Join(conditions, " AND "), len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -35,32 +67,42 @@ func (r *PRRepository) GetPRsByReviewer(ctx context.Context, userID string) ([]d
 	for rows.Next() {
 		var pr domain.PullRequestShort
 		var createdAt time.Time
-		if err := rows.Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status, &createdAt); err != nil {
+		if err := rows.Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.AuthorUsername, &pr.Status, &createdAt); err != nil {
 			return nil, err
 		}
+		pr.CreatedAt = &createdAt
 		prs = append(prs, pr)
 	}
 	return prs, nil
 }
 
 func (r *PRRepository) GetOpenPRsWithDeactivatedReviewers(ctx context.Context, tx *sql.Tx, deactivatedUserIDs []string) ([]string, error) {
+	ctx, span := tracing.StartQuery(ctx, "PRRepository.GetOpenPRsWithDeactivatedReviewers")
+	defer span.End()
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	if len(deactivatedUserIDs) == 0 {
 		return nil, nil
 	}
 
 	placeholders := make([]string, len(deactivatedUserIDs))
-	args := make([]interface{}, len(deactivatedUserIDs)+1)
-	args[0] = "OPEN"
+	args := make([]interface{}, len(deactivatedUserIDs)+2)
+	args[0] = orgID
+	args[1] = "OPEN"
 	for i, userID := range deactivatedUserIDs {
-		placeholders[i] = fmt.Sprintf("$%d", i+2)
-		args[i+1] = userID
+		placeholders[i] = fmt.Sprintf("$%d", i+3)
+		args[i+2] = userID
 	}
 
 	query := fmt.Sprintf(`
 		SELECT DISTINCT pr.pull_request_id
 		FROM pull_requests pr
-		JOIN pr_reviewers prr ON pr.pull_request_id = prr.pull_request_id
-		WHERE pr.status = $1 AND prr.user_id IN (%s)
+		JOIN pr_reviewers prr ON pr.org_id = prr.org_id AND pr.pull_request_id = prr.pull_request_id
+		WHERE pr.org_id = $1 AND pr.status = $2 AND prr.user_id IN (%s)
 	`, strings.Join(placeholders, ","))
 
 	rows, err := tx.QueryContext(ctx, query, args...)
@@ -81,55 +123,86 @@ func (r *PRRepository) GetOpenPRsWithDeactivatedReviewers(ctx context.Context, t
 }
 
 func (r *PRRepository) RemoveReviewers(ctx context.Context, tx *sql.Tx, prID string, reviewerIDs []string) error {
+	ctx, span := tracing.StartQuery(ctx, "PRRepository.RemoveReviewers")
+	defer span.End()
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return err
+	}
+
 	if len(reviewerIDs) == 0 {
 		return nil
 	}
 
 	placeholders := make([]string, len(reviewerIDs))
-	args := make([]interface{}, len(reviewerIDs)+1)
-	args[0] = prID
+	args := make([]interface{}, len(reviewerIDs)+2)
+	args[0] = orgID
+	args[1] = prID
 	for i, reviewerID := range reviewerIDs {
-		placeholders[i] = fmt.Sprintf("$%d", i+2)
-		args[i+1] = reviewerID
+		placeholders[i] = fmt.Sprintf("$%d", i+3)
+		args[i+2] = reviewerID
 	}
 
 	query := fmt.Sprintf(`
 		DELETE FROM pr_reviewers 
-		WHERE pull_request_id = $1 AND user_id IN (%s)
+		WHERE org_id = $1 AND pull_request_id = $2 AND user_id IN (%s)
 	`, strings.Join(placeholders, ","))
 
-	_, err := tx.ExecContext(ctx, query, args...)
+	_, err = tx.ExecContext(ctx, query, args...)
 	return err
 }
 
 func (r *PRRepository) RemoveDeactivatedReviewersFromAllPRs(ctx context.Context, tx *sql.Tx, deactivatedUserIDs []string) error {
+	ctx, span := tracing.StartQuery(ctx, "PRRepository.RemoveDeactivatedReviewersFromAllPRs")
+	defer span.End()
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return err
+	}
+
 	if len(deactivatedUserIDs) == 0 {
 		return nil
 	}
 
 	placeholders := make([]string, len(deactivatedUserIDs))
-	args := make([]interface{}, len(deactivatedUserIDs))
+	args := make([]interface{}, len(deactivatedUserIDs)+1)
+	args[0] = orgID
 	for i, userID := range deactivatedUserIDs {
-		placeholders[i] = fmt.Sprintf("$%d", i+1)
-		args[i] = userID
+		placeholders[i] = fmt.Sprintf("$%d", i+2)
+		args[i+1] = userID
 	}
 
 	query := fmt.Sprintf(`
 		DELETE FROM pr_reviewers 
-		WHERE user_id IN (%s)
+		WHERE org_id = $1 AND user_id IN (%s)
 		AND pull_request_id IN (
-			SELECT pull_request_id FROM pull_requests WHERE status = 'OPEN'
+			SELECT pull_request_id FROM pull_requests WHERE org_id = $1 AND status = 'OPEN'
 		)
 	`, strings.Join(placeholders, ","))
 
-	_, err := tx.ExecContext(ctx, query, args...)
-	return err
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	removed, _ := result.RowsAffected()
+	logging.FromContext(ctx).Debug("deactivated reviewers removed from open PRs", "removed", removed)
+	return nil
 }
 
 func (r *PRRepository) GetPRReviewers(ctx context.Context, tx *sql.Tx, prID string) ([]string, error) {
+	ctx, span := tracing.StartQuery(ctx, "PRRepository.GetPRReviewers")
+	defer span.End()
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	rows, err := tx.QueryContext(ctx, `
-		SELECT user_id FROM pr_reviewers WHERE pull_request_id = $1
-	`, prID)
+		SELECT user_id FROM pr_reviewers WHERE org_id = $1 AND pull_request_id = $2
+	`, orgID, prID)
 	if err != nil {
 		return nil, err
 	}
@@ -147,15 +220,24 @@ func (r *PRRepository) GetPRReviewers(ctx context.Context, tx *sql.Tx, prID stri
 }
 
 func (r *PRRepository) GetPR(ctx context.Context, tx *sql.Tx, prID string) (*domain.PullRequest, error) {
+	ctx, span := tracing.StartQuery(ctx, "PRRepository.GetPR")
+	defer span.End()
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	var pr domain.PullRequest
 	var createdAt time.Time
 	var mergedAt sql.NullTime
+	var mergedBy sql.NullString
 
-	err := tx.QueryRowContext(ctx, `
-		SELECT pull_request_id, pull_request_name, author_id, status, created_at, merged_at
+	err = tx.QueryRowContext(ctx, `
+		SELECT pull_request_id, pull_request_name, author_id, status, created_at, merged_at, merged_by
 		FROM pull_requests
-		WHERE pull_request_id = $1
-	`, prID).Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status, &createdAt, &mergedAt)
+		WHERE org_id = $1 AND pull_request_id = $2
+	`, orgID, prID).Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status, &createdAt, &mergedAt, &mergedBy)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -168,6 +250,7 @@ func (r *PRRepository) GetPR(ctx context.Context, tx *sql.Tx, prID string) (*dom
 	if mergedAt.Valid {
 		pr.MergedAt = &mergedAt.Time
 	}
+	pr.MergedBy = mergedBy.String
 
 	reviewers, err := r.GetPRReviewers(ctx, tx, prID)
 	if err != nil {
@@ -179,26 +262,33 @@ func (r *PRRepository) GetPR(ctx context.Context, tx *sql.Tx, prID string) (*dom
 }
 
 func (r *PRRepository) CreatePR(ctx context.Context, pr *domain.PullRequest) error {
+	ctx, span := tracing.StartQuery(ctx, "PRRepository.CreatePR")
+	defer span.End()
+
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
-	createdAt := time.Now()
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return err
+	}
+	createdAt := time.Now().UTC()
 	_, err = tx.ExecContext(ctx, `
-		INSERT INTO pull_requests (pull_request_id, pull_request_name, author_id, status, created_at)
-		VALUES ($1, $2, $3, $4, $5)
-	`, pr.PullRequestID, pr.PullRequestName, pr.AuthorID, domain.StatusOpen, createdAt)
+		INSERT INTO pull_requests (org_id, pull_request_id, pull_request_name, author_id, status, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, orgID, pr.PullRequestID, pr.PullRequestName, pr.AuthorID, domain.StatusOpen, createdAt)
 	if err != nil {
 		return err
 	}
 
 	for _, reviewerID := range pr.AssignedReviewers {
 		_, err = tx.ExecContext(ctx, `
-			INSERT INTO pr_reviewers (pull_request_id, user_id)
-			VALUES ($1, $2)
-		`, pr.PullRequestID, reviewerID)
+			INSERT INTO pr_reviewers (org_id, pull_request_id, user_id, assigned_at)
+			VALUES ($1, $2, $3, $4)
+		`, orgID, pr.PullRequestID, reviewerID, createdAt)
 		if err != nil {
 			return err
 		}
@@ -208,20 +298,36 @@ func (r *PRRepository) CreatePR(ctx context.Context, pr *domain.PullRequest) err
 }
 
 func (r *PRRepository) PRExists(ctx context.Context, prID string) (bool, error) {
+	ctx, span := tracing.StartQuery(ctx, "PRRepository.PRExists")
+	defer span.End()
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return false, err
+	}
+
 	var exists bool
-	err := r.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM pull_requests WHERE pull_request_id = $1)", prID).Scan(&exists)
+	err = r.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM pull_requests WHERE org_id = $1 AND pull_request_id = $2)", orgID, prID).Scan(&exists)
 	return exists, err
 }
 
 func (r *PRRepository) GetPRWithoutTx(ctx context.Context, prID string) (*domain.PullRequest, error) {
+	ctx, span := tracing.StartQuery(ctx, "PRRepository.GetPRWithoutTx")
+	defer span.End()
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return nil, err
+	}
 	var pr domain.PullRequest
 	var createdAt, mergedAt sql.NullTime
+	var mergedBy sql.NullString
 
-	err := r.db.QueryRowContext(ctx, `
-		SELECT pull_request_id, pull_request_name, author_id, status, created_at, merged_at
+	err = r.db.QueryRowContext(ctx, `
+		SELECT pull_request_id, pull_request_name, author_id, status, created_at, merged_at, merged_by
 		FROM pull_requests
-		WHERE pull_request_id = $1
-	`, prID).Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status, &createdAt, &mergedAt)
+		WHERE org_id = $1 AND pull_request_id = $2
+	`, orgID, prID).Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status, &createdAt, &mergedAt, &mergedBy)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -236,10 +342,11 @@ func (r *PRRepository) GetPRWithoutTx(ctx context.Context, prID string) (*domain
 	if mergedAt.Valid {
 		pr.MergedAt = &mergedAt.Time
 	}
+	pr.MergedBy = mergedBy.String
 
 	rows, err := r.db.QueryContext(ctx, `
-		SELECT user_id FROM pr_reviewers WHERE pull_request_id = $1
-	`, prID)
+		SELECT user_id FROM pr_reviewers WHERE org_id = $1 AND pull_request_id = $2
+	`, orgID, prID)
 	if err != nil {
 		return nil, err
 	}
@@ -256,13 +363,22 @@ func (r *PRRepository) GetPRWithoutTx(ctx context.Context, prID string) (*domain
 	return &pr, nil
 }
 
-func (r *PRRepository) MergePR(ctx context.Context, prID string) (*domain.PullRequest, error) {
-	mergedAt := time.Now()
-	_, err := r.db.ExecContext(ctx, `
+// MergePR сохраняет mergedBy, если вызывающий известен; пустая строка записывается как NULL
+func (r *PRRepository) MergePR(ctx context.Context, prID, mergedBy string) (*domain.PullRequest, error) {
+	ctx, span := tracing.StartQuery(ctx, "PRRepository.MergePR")
+	defer span.End()
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	mergedAt := time.Now().UTC()
+	_, err = r.db.ExecContext(ctx, `
 		UPDATE pull_requests 
-		SET status = $2, merged_at = $3
-		WHERE pull_request_id = $1 AND status != $2
-	`, prID, domain.StatusMerged, mergedAt)
+		SET status = $3, merged_at = $4, merged_by = NULLIF($5, '')
+		WHERE org_id = $1 AND pull_request_id = $2 AND status != $3
+	`, orgID, prID, domain.StatusMerged, mergedAt, mergedBy)
 	if err != nil {
 		return nil, err
 	}
@@ -271,61 +387,117 @@ func (r *PRRepository) MergePR(ctx context.Context, prID string) (*domain.PullRe
 }
 
 func (r *PRRepository) ReassignReviewer(ctx context.Context, prID, oldReviewerID, newReviewerID string) error {
-	_, err := r.db.ExecContext(ctx, `
+	ctx, span := tracing.StartQuery(ctx, "PRRepository.ReassignReviewer")
+	defer span.End()
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, `
+		UPDATE pr_reviewers 
+		SET user_id = $4, assigned_at = $5, reviewed_at = NULL
+		WHERE org_id = $1 AND pull_request_id = $2 AND user_id = $3
+	`, orgID, prID, oldReviewerID, newReviewerID, time.Now().UTC())
+	return err
+}
+
+// MarkReviewed фиксирует время первого ревью; повторные вызовы не сдвигают отметку
+func (r *PRRepository) MarkReviewed(ctx context.Context, prID, userID string) error {
+	ctx, span := tracing.StartQuery(ctx, "PRRepository.MarkReviewed")
+	defer span.End()
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, `
 		UPDATE pr_reviewers 
-		SET user_id = $3
-		WHERE pull_request_id = $1 AND user_id = $2
-	`, prID, oldReviewerID, newReviewerID)
+		SET reviewed_at = COALESCE(reviewed_at, $4)
+		WHERE org_id = $1 AND pull_request_id = $2 AND user_id = $3
+	`, orgID, prID, userID, time.Now().UTC())
 	return err
 }
 
 func (r *PRRepository) AddReviewer(ctx context.Context, tx *sql.Tx, prID, userID string) error {
-	_, err := tx.ExecContext(ctx, `
-		INSERT INTO pr_reviewers (pull_request_id, user_id)
-		VALUES ($1, $2)
-	`, prID, userID)
+	ctx, span := tracing.StartQuery(ctx, "PRRepository.AddReviewer")
+	defer span.End()
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO pr_reviewers (org_id, pull_request_id, user_id, assigned_at)
+		VALUES ($1, $2, $3, $4)
+	`, orgID, prID, userID, time.Now().UTC())
 	return err
 }
 
 func (r *PRRepository) BatchAddReviewers(ctx context.Context, tx *sql.Tx, assignments []struct{ PRID, UserID string }) error {
+	ctx, span := tracing.StartQuery(ctx, "PRRepository.BatchAddReviewers")
+	defer span.End()
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return err
+	}
+
 	if len(assignments) == 0 {
 		return nil
 	}
 
 	valueStrings := make([]string, len(assignments))
-	valueArgs := make([]interface{}, len(assignments)*2)
+	valueArgs := make([]interface{}, len(assignments)*2+2)
+	valueArgs[0] = orgID
+	valueArgs[1] = time.Now().UTC()
 
 	for i, assignment := range assignments {
-		valueStrings[i] = fmt.Sprintf("($%d, $%d)", i*2+1, i*2+2)
-		valueArgs[i*2] = assignment.PRID
-		valueArgs[i*2+1] = assignment.UserID
+		valueStrings[i] = fmt.Sprintf("($1, $%d, $%d, $2)", i*2+3, i*2+4)
+		valueArgs[i*2+2] = assignment.PRID
+		valueArgs[i*2+3] = assignment.UserID
 	}
 
 	query := fmt.Sprintf(`
-		INSERT INTO pr_reviewers (pull_request_id, user_id)
+		INSERT INTO pr_reviewers (org_id, pull_request_id, user_id, assigned_at)
 		VALUES %s
 	`, strings.Join(valueStrings, ","))
 
-	_, err := tx.ExecContext(ctx, query, valueArgs...)
-	return err
+	if _, err := tx.ExecContext(ctx, query, valueArgs...); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Debug("reviewers batch inserted", "assignments", len(assignments))
+	return nil
 }
 
 func (r *PRRepository) GetPRsWithReviewers(ctx context.Context, tx *sql.Tx, prIDs []string) (map[string]*domain.PullRequest, error) {
+	ctx, span := tracing.StartQuery(ctx, "PRRepository.GetPRsWithReviewers")
+	defer span.End()
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	if len(prIDs) == 0 {
 		return make(map[string]*domain.PullRequest), nil
 	}
 
 	placeholders := make([]string, len(prIDs))
-	args := make([]interface{}, len(prIDs))
+	args := make([]interface{}, len(prIDs)+1)
+	args[0] = orgID
 	for i, prID := range prIDs {
-		placeholders[i] = fmt.Sprintf("$%d", i+1)
-		args[i] = prID
+		placeholders[i] = fmt.Sprintf("$%d", i+2)
+		args[i+1] = prID
 	}
 
 	query := fmt.Sprintf(`
 		SELECT pull_request_id, pull_request_name, author_id, status, created_at, merged_at
 		FROM pull_requests
-		WHERE pull_request_id IN (%s)
+		WHERE org_id = $1 AND pull_request_id IN (%s)
 	`, strings.Join(placeholders, ","))
 
 	rows, err := tx.QueryContext(ctx, query, args...)
@@ -355,7 +527,7 @@ func (r *PRRepository) GetPRsWithReviewers(ctx context.Context, tx *sql.Tx, prID
 	reviewerQuery := fmt.Sprintf(`
 		SELECT pull_request_id, user_id
 		FROM pr_reviewers
-		WHERE pull_request_id IN (%s)
+		WHERE org_id = $1 AND pull_request_id IN (%s)
 	`, strings.Join(placeholders, ","))
 
 	reviewerRows, err := tx.QueryContext(ctx, reviewerQuery, args...)
@@ -376,3 +548,63 @@ func (r *PRRepository) GetPRsWithReviewers(ctx context.Context, tx *sql.Tx, prID
 
 	return prs, nil
 }
+
+func (r *PRRepository) GetPRDetails(ctx context.Context, prID string) (*domain.PullRequestDetails, error) {
+	ctx, span := tracing.StartQuery(ctx, "PRRepository.GetPRDetails")
+	defer span.End()
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var pr domain.PullRequestDetails
+	var createdAt, mergedAt sql.NullTime
+	var mergedBy sql.NullString
+
+	err = r.db.QueryRowContext(ctx, `
+		SELECT pr.pull_request_id, pr.pull_request_name, pr.status, pr.created_at, pr.merged_at, pr.merged_by,
+			u.user_id, u.username, u.team_name, u.is_active
+		FROM pull_requests pr
+		JOIN users u ON pr.org_id = u.org_id AND pr.author_id = u.user_id
+		WHERE pr.org_id = $1 AND pr.pull_request_id = $2
+	`, orgID, prID).Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.Status, &createdAt, &mergedAt, &mergedBy,
+		&pr.Author.UserID, &pr.Author.Username, &pr.Author.TeamName, &pr.Author.IsActive)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if createdAt.Valid {
+		pr.CreatedAt = &createdAt.Time
+	}
+	if mergedAt.Valid {
+		pr.MergedAt = &mergedAt.Time
+	}
+	pr.MergedBy = mergedBy.String
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT u.user_id, u.username, u.team_name, u.is_active
+		FROM pr_reviewers prr
+		JOIN users u ON prr.org_id = u.org_id AND prr.user_id = u.user_id
+		WHERE prr.org_id = $1 AND prr.pull_request_id = $2
+		ORDER BY u.username
+	`, orgID, prID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pr.AssignedReviewers = []domain.PRParticipant{}
+	for rows.Next() {
+		var reviewer domain.PRParticipant
+		if err := rows.Scan(&reviewer.UserID, &reviewer.Username, &reviewer.TeamName, &reviewer.IsActive); err != nil {
+			return nil, err
+		}
+		pr.AssignedReviewers = append(pr.AssignedReviewers, reviewer)
+	}
+
+	return &pr, nil
+}
@@ -3,8 +3,14 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"strings"
+	"time"
 
+	"pr-review-manager/internal/auth"
 	"pr-review-manager/internal/domain"
+	"pr-review-manager/internal/logging"
+	"pr-review-manager/internal/tracing"
 )
 
 type StatsRepository struct {
@@ -15,27 +21,54 @@ func NewStatsRepository(db *sql.DB) *StatsRepository {
 	return &StatsRepository{db: db}
 }
 
-func (r *StatsRepository) GetStats(ctx context.Context) (*domain.Stats, error) {
+// reviewerLoadColumns — счётчики назначений по строкам pr_reviewers prr и pull_requests pr.
+// Одни и те же для статистики, отчёта о справедливости и списка пользователей, чтобы нагрузка везде совпадала
+const reviewerLoadColumns = `
+			COUNT(pr.pull_request_id) as total_assigned,
+			COUNT(*) FILTER (WHERE pr.status = 'OPEN') as open_assigned,
+			COUNT(*) FILTER (WHERE pr.status = 'MERGED') as merged_assigned`
+
+// reviewerLoad — подзапрос с нагрузкой ревьюверов организации $1 по всем PR. Пользователей без назначений
+// в нём нет, поэтому его присоединяют через LEFT JOIN и COALESCE
+const reviewerLoad = `
+		SELECT prr.user_id,` + reviewerLoadColumns + `
+		FROM pr_reviewers prr
+		JOIN pull_requests pr ON prr.org_id = pr.org_id AND prr.pull_request_id = pr.pull_request_id
+		WHERE prr.org_id = $1
+		GROUP BY prr.user_id`
+
+func (r *StatsRepository) GetStats(ctx context.Context, filter domain.StatsFilter) (*domain.Stats, error) {
+	ctx, span := tracing.StartQuery(ctx, "StatsRepository.GetStats")
+	defer span.End()
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	stats := &domain.Stats{}
 
-	err := r.db.QueryRowContext(ctx, `
+	where, args := statsConditions(orgID, filter, "pr", "author")
+	err = r.db.QueryRowContext(ctx, fmt.Sprintf(`
 		SELECT 
 			COUNT(*) as total,
-			SUM(CASE WHEN status = 'OPEN' THEN 1 ELSE 0 END) as open,
-			SUM(CASE WHEN status = 'MERGED' THEN 1 ELSE 0 END) as merged
-		FROM pull_requests
-	`).Scan(&stats.TotalPRs, &stats.OpenPRs, &stats.MergedPRs)
+			COALESCE(SUM(CASE WHEN pr.status = 'OPEN' THEN 1 ELSE 0 END), 0) as open,
+			COALESCE(SUM(CASE WHEN pr.status = 'MERGED' THEN 1 ELSE 0 END), 0) as merged
+		FROM pull_requests pr
+		JOIN users author ON pr.org_id = author.org_id AND pr.author_id = author.user_id
+		%s
+	`, where), args...).Scan(&stats.TotalPRs, &stats.OpenPRs, &stats.MergedPRs)
 	if err != nil {
 		return nil, err
 	}
 
-	reviewerStats, err := r.getReviewerStats(ctx)
+	reviewerStats, err := r.getReviewerStats(ctx, filter)
 	if err != nil {
 		return nil, err
 	}
 	stats.ReviewerStats = reviewerStats
 
-	prStats, err := r.getPRStats(ctx)
+	prStats, err := r.getPRStats(ctx, filter)
 	if err != nil {
 		return nil, err
 	}
@@ -44,21 +77,28 @@ func (r *StatsRepository) GetStats(ctx context.Context) (*domain.Stats, error) {
 	return stats, nil
 }
 
-func (r *StatsRepository) getReviewerStats(ctx context.Context) ([]domain.ReviewerStat, error) {
-	rows, err := r.db.QueryContext(ctx, `
+func (r *StatsRepository) getReviewerStats(ctx context.Context, filter domain.StatsFilter) ([]domain.ReviewerStat, error) {
+	ctx, span := tracing.StartQuery(ctx, "StatsRepository.getReviewerStats")
+	defer span.End()
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	where, args := statsConditions(orgID, filter, "pr", "u")
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(`
 		SELECT 
 			u.user_id,
-			u.username,
-			COUNT(prr.pull_request_id) as total_assigned,
-			SUM(CASE WHEN pr.status = 'OPEN' THEN 1 ELSE 0 END) as open_assigned,
-			SUM(CASE WHEN pr.status = 'MERGED' THEN 1 ELSE 0 END) as merged_assigned
+			u.username,`+reviewerLoadColumns+`
 		FROM users u
-		LEFT JOIN pr_reviewers prr ON u.user_id = prr.user_id
-		LEFT JOIN pull_requests pr ON prr.pull_request_id = pr.pull_request_id
+		LEFT JOIN pr_reviewers prr ON u.org_id = prr.org_id AND u.user_id = prr.user_id
+		LEFT JOIN pull_requests pr ON prr.org_id = pr.org_id AND prr.pull_request_id = pr.pull_request_id
+		%s
 		GROUP BY u.user_id, u.username
 		HAVING COUNT(prr.pull_request_id) > 0
 		ORDER BY total_assigned DESC, u.username
-	`)
+	`, where), args...)
 	if err != nil {
 		return nil, err
 	}
@@ -80,18 +120,29 @@ func (r *StatsRepository) getReviewerStats(ctx context.Context) ([]domain.Review
 	return stats, nil
 }
 
-func (r *StatsRepository) getPRStats(ctx context.Context) ([]domain.PRStat, error) {
-	rows, err := r.db.QueryContext(ctx, `
+func (r *StatsRepository) getPRStats(ctx context.Context, filter domain.StatsFilter) ([]domain.PRStat, error) {
+	ctx, span := tracing.StartQuery(ctx, "StatsRepository.getPRStats")
+	defer span.End()
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	where, args := statsConditions(orgID, filter, "pr", "author")
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(`
 		SELECT 
 			pr.pull_request_id,
 			pr.pull_request_name,
 			pr.status,
 			COUNT(prr.user_id) as reviewers_count
 		FROM pull_requests pr
-		LEFT JOIN pr_reviewers prr ON pr.pull_request_id = prr.pull_request_id
+		JOIN users author ON pr.org_id = author.org_id AND pr.author_id = author.user_id
+		LEFT JOIN pr_reviewers prr ON pr.org_id = prr.org_id AND pr.pull_request_id = prr.pull_request_id
+		%s
 		GROUP BY pr.pull_request_id, pr.pull_request_name, pr.status
 		ORDER BY pr.created_at DESC
-	`)
+	`, where), args...)
 	if err != nil {
 		return nil, err
 	}
@@ -112,3 +163,558 @@ func (r *StatsRepository) getPRStats(ctx context.Context) ([]domain.PRStat, erro
 
 	return stats, nil
 }
+
+// statsConditions собирает WHERE по организации, окну и команде: prAlias — алиас pull_requests,
+// teamAlias — алиас users, по команде которого фильтруем (автор PR или ревьювер)
+func statsConditions(orgID string, filter domain.StatsFilter, prAlias, teamAlias string) (string, []interface{}) {
+	conditions := []string{fmt.Sprintf("%s.org_id = $1", teamAlias)}
+	args := []interface{}{orgID}
+
+	if filter.From != nil || filter.To != nil {
+		created := []string{}
+		merged := []string{fmt.Sprintf("%s.merged_at IS NOT NULL", prAlias)}
+		if filter.From != nil {
+			args = append(args, filter.From.UTC().Format(pgTimestampLayout))
+			created = append(created, fmt.Sprintf("%s.created_at >= $%d::timestamp", prAlias, len(args)))
+			merged = append(merged, fmt.Sprintf("%s.merged_at >= $%d::timestamp", prAlias, len(args)))
+		}
+		if filter.To != nil {
+			args = append(args, filter.To.UTC().Format(pgTimestampLayout))
+			created = append(created, fmt.Sprintf("%s.created_at < $%d::timestamp", prAlias, len(args)))
+			merged = append(merged, fmt.Sprintf("%s.merged_at < $%d::timestamp", prAlias, len(args)))
+		}
+		conditions = append(conditions, fmt.Sprintf("((%s) OR (%s))", strings.Join(created, " AND "), strings.Join(merged, " AND ")))
+	}
+	if filter.TeamName != "" {
+		args = append(args, filter.TeamName)
+		conditions = append(conditions, fmt.Sprintf("%s.team_name = $%d", teamAlias, len(args)))
+	}
+
+	return "WHERE " + strings.Join(conditions, " AND "), args
+}
+
+func (r *StatsRepository) GetCycleTime(ctx context.Context, filter domain.StatsFilter) (*domain.CycleTimeStats, error) {
+	ctx, span := tracing.StartQuery(ctx, "StatsRepository.GetCycleTime")
+	defer span.End()
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	where, args := statsConditions(orgID, filter, "pr", "author")
+	// GROUPING SETS возвращает строки по командам и итоговую строку с team_name = NULL
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(`
+		WITH first_reviews AS (
+			SELECT org_id, pull_request_id, MIN(reviewed_at) as first_reviewed_at
+			FROM pr_reviewers
+			WHERE org_id = $1 AND reviewed_at IS NOT NULL
+			GROUP BY org_id, pull_request_id
+		)
+		SELECT 
+			author.team_name,
+			COUNT(pr.merged_at) as merged_count,
+			percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM pr.merged_at - pr.created_at)),
+			percentile_cont(0.9) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM pr.merged_at - pr.created_at)),
+			COUNT(fr.first_reviewed_at) as reviewed_count,
+			percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM fr.first_reviewed_at - pr.created_at)),
+			percentile_cont(0.9) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM fr.first_reviewed_at - pr.created_at))
+		FROM pull_requests pr
+		JOIN users author ON pr.org_id = author.org_id AND pr.author_id = author.user_id
+		LEFT JOIN first_reviews fr ON pr.org_id = fr.org_id AND pr.pull_request_id = fr.pull_request_id
+		%s
+		GROUP BY GROUPING SETS ((author.team_name), ())
+		ORDER BY author.team_name NULLS FIRST
+	`, where), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := &domain.CycleTimeStats{
+		Teams: []domain.TeamCycleTime{},
+	}
+	for rows.Next() {
+		var teamName sql.NullString
+		var metrics domain.CycleTimeMetrics
+		var mergeMedian, mergeP90, reviewMedian, reviewP90 sql.NullFloat64
+		if err := rows.Scan(&teamName,
+			&metrics.TimeToMerge.Count, &mergeMedian, &mergeP90,
+			&metrics.TimeToFirstReview.Count, &reviewMedian, &reviewP90); err != nil {
+			return nil, err
+		}
+		metrics.TimeToMerge.MedianSeconds = nullFloat(mergeMedian)
+		metrics.TimeToMerge.P90Seconds = nullFloat(mergeP90)
+		metrics.TimeToFirstReview.MedianSeconds = nullFloat(reviewMedian)
+		metrics.TimeToFirstReview.P90Seconds = nullFloat(reviewP90)
+
+		if !teamName.Valid {
+			stats.Overall = metrics
+			continue
+		}
+		stats.Teams = append(stats.Teams, domain.TeamCycleTime{
+			TeamName:         teamName.String,
+			CycleTimeMetrics: metrics,
+		})
+	}
+
+	reviewers, err := r.getReviewerCycleTime(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	stats.Reviewers = reviewers
+
+	return stats, nil
+}
+
+func (r *StatsRepository) getReviewerCycleTime(ctx context.Context, filter domain.StatsFilter) ([]domain.ReviewerCycleTime, error) {
+	ctx, span := tracing.StartQuery(ctx, "StatsRepository.getReviewerCycleTime")
+	defer span.End()
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	where, args := statsConditions(orgID, filter, "pr", "u")
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT 
+			u.user_id,
+			u.username,
+			u.team_name,
+			COUNT(prr.reviewed_at) as reviewed_count,
+			percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM prr.reviewed_at - prr.assigned_at)) as median,
+			percentile_cont(0.9) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM prr.reviewed_at - prr.assigned_at)) as p90
+		FROM pr_reviewers prr
+		JOIN users u ON prr.org_id = u.org_id AND prr.user_id = u.user_id
+		JOIN pull_requests pr ON prr.org_id = pr.org_id AND prr.pull_request_id = pr.pull_request_id
+		%s
+		GROUP BY u.user_id, u.username, u.team_name
+		HAVING COUNT(prr.reviewed_at) > 0
+		ORDER BY median, u.username
+	`, where), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reviewers := []domain.ReviewerCycleTime{}
+	for rows.Next() {
+		var reviewer domain.ReviewerCycleTime
+		var median, p90 sql.NullFloat64
+		if err := rows.Scan(&reviewer.UserID, &reviewer.Username, &reviewer.TeamName,
+			&reviewer.TimeToReview.Count, &median, &p90); err != nil {
+			return nil, err
+		}
+		reviewer.TimeToReview.MedianSeconds = nullFloat(median)
+		reviewer.TimeToReview.P90Seconds = nullFloat(p90)
+		reviewers = append(reviewers, reviewer)
+	}
+	return reviewers, nil
+}
+
+func nullFloat(value sql.NullFloat64) *float64 {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Float64
+}
+
+// GetTeamLoad возвращает нагрузку всех активных участников по командам, включая тех, у кого нет назначений
+func (r *StatsRepository) GetTeamLoad(ctx context.Context, teamName string) ([]domain.TeamFairness, error) {
+	ctx, span := tracing.StartQuery(ctx, "StatsRepository.GetTeamLoad")
+	defer span.End()
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	where := "WHERE u.org_id = $1 AND u.is_active = true"
+	args := []interface{}{orgID}
+	if teamName != "" {
+		args = append(args, teamName)
+		where += " AND u.team_name = $2"
+	}
+
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT 
+			u.team_name,
+			u.user_id,
+			u.username,
+			COALESCE(reviewer_load.total_assigned, 0) as total_assigned,
+			COALESCE(reviewer_load.open_assigned, 0) as open_assigned,
+			COALESCE(reviewer_load.merged_assigned, 0) as merged_assigned
+		FROM users u
+		LEFT JOIN (`+reviewerLoad+`
+		) reviewer_load ON reviewer_load.user_id = u.user_id
+		%s
+		ORDER BY u.team_name, total_assigned DESC, u.username
+	`, where), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	teams := []domain.TeamFairness{}
+	for rows.Next() {
+		var team string
+		var stat domain.ReviewerStat
+		if err := rows.Scan(&team, &stat.UserID, &stat.Username, &stat.TotalAssigned, &stat.OpenAssigned, &stat.MergedAssigned); err != nil {
+			return nil, err
+		}
+		if len(teams) == 0 || teams[len(teams)-1].TeamName != team {
+			teams = append(teams, domain.TeamFairness{TeamName: team})
+		}
+		current := &teams[len(teams)-1]
+		current.Members = append(current.Members, stat)
+	}
+	return teams, nil
+}
+
+// ageBucketColumns раскладывает открытые PR из CTE open_prs по корзинам возраста
+const ageBucketColumns = `
+	COALESCE(SUM(CASE WHEN op.age < INTERVAL '1 day' THEN 1 ELSE 0 END), 0),
+	COALESCE(SUM(CASE WHEN op.age >= INTERVAL '1 day' AND op.age < INTERVAL '3 days' THEN 1 ELSE 0 END), 0),
+	COALESCE(SUM(CASE WHEN op.age >= INTERVAL '3 days' AND op.age < INTERVAL '7 days' THEN 1 ELSE 0 END), 0),
+	COALESCE(SUM(CASE WHEN op.age >= INTERVAL '7 days' THEN 1 ELSE 0 END), 0)`
+
+func (r *StatsRepository) GetAging(ctx context.Context, now time.Time, teamName string, oldestLimit int) (*domain.AgingReport, error) {
+	ctx, span := tracing.StartQuery(ctx, "StatsRepository.GetAging")
+	defer span.End()
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	openPRs := `
+		WITH open_prs AS (
+			SELECT pr.org_id, pr.pull_request_id, author.team_name, $1::timestamp - pr.created_at as age
+			FROM pull_requests pr
+			JOIN users author ON pr.org_id = author.org_id AND pr.author_id = author.user_id
+			WHERE pr.org_id = $3 AND pr.status = 'OPEN' AND ($2 = '' OR author.team_name = $2)
+		)`
+	args := []interface{}{now.UTC().Format(pgTimestampLayout), teamName, orgID}
+
+	report := &domain.AgingReport{
+		Teams:     []domain.TeamAging{},
+		Reviewers: []domain.ReviewerAging{},
+	}
+
+	rows, err := r.db.QueryContext(ctx, openPRs+`
+		SELECT op.team_name, `+ageBucketColumns+`
+		FROM open_prs op
+		GROUP BY GROUPING SETS ((op.team_name), ())
+		ORDER BY op.team_name NULLS FIRST
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var team sql.NullString
+		var buckets domain.AgeBuckets
+		if err := rows.Scan(&team, &buckets.LessThan1Day, &buckets.From1To3Days, &buckets.From3To7Days, &buckets.MoreThan7Days); err != nil {
+			return nil, err
+		}
+		if !team.Valid {
+			report.Buckets = buckets
+			continue
+		}
+		report.Teams = append(report.Teams, domain.TeamAging{TeamName: team.String, Buckets: buckets})
+	}
+
+	reviewerRows, err := r.db.QueryContext(ctx, openPRs+`
+		SELECT u.user_id, u.username, u.team_name, `+ageBucketColumns+`
+		FROM open_prs op
+		JOIN pr_reviewers prr ON op.org_id = prr.org_id AND op.pull_request_id = prr.pull_request_id
+		JOIN users u ON prr.org_id = u.org_id AND prr.user_id = u.user_id
+		GROUP BY u.user_id, u.username, u.team_name
+		ORDER BY u.username
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer reviewerRows.Close()
+
+	for reviewerRows.Next() {
+		var reviewer domain.ReviewerAging
+		if err := reviewerRows.Scan(&reviewer.UserID, &reviewer.Username, &reviewer.TeamName,
+			&reviewer.Buckets.LessThan1Day, &reviewer.Buckets.From1To3Days, &reviewer.Buckets.From3To7Days, &reviewer.Buckets.MoreThan7Days); err != nil {
+			return nil, err
+		}
+		report.Reviewers = append(report.Reviewers, reviewer)
+	}
+
+	oldest, err := r.getOldestOpenPRs(ctx, teamName, oldestLimit)
+	if err != nil {
+		return nil, err
+	}
+	report.Oldest = oldest
+
+	return report, nil
+}
+
+func (r *StatsRepository) getOldestOpenPRs(ctx context.Context, teamName string, limit int) ([]domain.AgingPR, error) {
+	ctx, span := tracing.StartQuery(ctx, "StatsRepository.getOldestOpenPRs")
+	defer span.End()
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT pr.pull_request_id, pr.pull_request_name, pr.created_at,
+			author.user_id, author.username, author.team_name, author.is_active
+		FROM pull_requests pr
+		JOIN users author ON pr.org_id = author.org_id AND pr.author_id = author.user_id
+		WHERE pr.org_id = $1 AND pr.status = 'OPEN' AND ($2 = '' OR author.team_name = $2)
+		ORDER BY pr.created_at, pr.pull_request_id
+		LIMIT $3
+	`, orgID, teamName, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prs := []domain.AgingPR{}
+	index := make(map[string]int)
+	for rows.Next() {
+		var pr domain.AgingPR
+		if err := rows.Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.CreatedAt,
+			&pr.Author.UserID, &pr.Author.Username, &pr.Author.TeamName, &pr.Author.IsActive); err != nil {
+			return nil, err
+		}
+		pr.Reviewers = []domain.PRParticipant{}
+		index[pr.PullRequestID] = len(prs)
+		prs = append(prs, pr)
+	}
+
+	if len(prs) == 0 {
+		return prs, nil
+	}
+
+	placeholders := make([]string, len(prs))
+	args := make([]interface{}, len(prs)+1)
+	args[0] = orgID
+	for i, pr := range prs {
+		placeholders[i] = fmt.Sprintf("$%d", i+2)
+		args[i+1] = pr.PullRequestID
+	}
+
+	reviewerRows, err := r.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT prr.pull_request_id, u.user_id, u.username, u.team_name, u.is_active
+		FROM pr_reviewers prr
+		JOIN users u ON prr.org_id = u.org_id AND prr.user_id = u.user_id
+		WHERE prr.org_id = $1 AND prr.pull_request_id IN (%s)
+		ORDER BY u.username
+	`, strings.Join(placeholders, ",")), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer reviewerRows.Close()
+
+	for reviewerRows.Next() {
+		var prID string
+		var reviewer domain.PRParticipant
+		if err := reviewerRows.Scan(&prID, &reviewer.UserID, &reviewer.Username, &reviewer.TeamName, &reviewer.IsActive); err != nil {
+			return nil, err
+		}
+		if i, ok := index[prID]; ok {
+			prs[i].Reviewers = append(prs[i].Reviewers, reviewer)
+		}
+	}
+
+	return prs, nil
+}
+
+// SaveSnapshots рассчитывает снимки за каждый день [from, to] по created_at/merged_at, поэтому подходит
+// и для ежедневного задания, и для заполнения истории задним числом. Снимки за дни до today окончательные:
+// уже сохранённый окончательный снимок не пересчитывается, иначе переназначения и деактивации переписали бы
+// историю. Снимки за today промежуточные и заменяются при каждом расчёте.
+// Дни, впервые рассчитанные задним числом, приблизительны: ревьюверы берутся из текущих назначений
+func (r *StatsRepository) SaveSnapshots(ctx context.Context, from, to, today time.Time) error {
+	ctx, span := tracing.StartQuery(ctx, "StatsRepository.SaveSnapshots")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM stats_snapshots
+		WHERE org_id = $1 AND snapshot_date BETWEEN $2::date AND $3::date AND NOT final
+	`, orgID, from.Format(time.DateOnly), to.Format(time.DateOnly)); err != nil {
+		return err
+	}
+
+	days := `
+		WITH days AS (
+			SELECT d::date as day, d::date < $4::date as final
+			FROM generate_series($1::date, $2::date, INTERVAL '1 day') d
+		)`
+	counts := `
+			COUNT(pr.pull_request_id),
+			COALESCE(SUM(CASE WHEN pr.merged_at IS NULL OR pr.merged_at >= days.day + 1 THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN pr.merged_at < days.day + 1 THEN 1 ELSE 0 END), 0)`
+	keepFinal := `
+		ON CONFLICT (org_id, snapshot_date, scope, scope_id) DO NOTHING`
+
+	queries := []string{
+		days + `
+		INSERT INTO stats_snapshots (org_id, snapshot_date, scope, scope_id, final, total_prs, open_prs, merged_prs)
+		SELECT $3, days.day, 'overall', '', days.final,` + counts + `
+		FROM days
+		LEFT JOIN pull_requests pr ON pr.org_id = $3 AND pr.created_at < days.day + 1
+		GROUP BY days.day, days.final` + keepFinal,
+		days + `
+		INSERT INTO stats_snapshots (org_id, snapshot_date, scope, scope_id, final, total_prs, open_prs, merged_prs)
+		SELECT $3, days.day, 'team', author.team_name, days.final,` + counts + `
+		FROM days
+		JOIN pull_requests pr ON pr.org_id = $3 AND pr.created_at < days.day + 1
+		JOIN users author ON pr.org_id = author.org_id AND pr.author_id = author.user_id
+		GROUP BY days.day, days.final, author.team_name` + keepFinal,
+		days + `
+		INSERT INTO stats_snapshots (org_id, snapshot_date, scope, scope_id, final, total_prs, open_prs, merged_prs)
+		SELECT $3, days.day, 'reviewer', prr.user_id, days.final,` + counts + `
+		FROM days
+		JOIN pull_requests pr ON pr.org_id = $3 AND pr.created_at < days.day + 1
+		JOIN pr_reviewers prr ON pr.org_id = prr.org_id AND pr.pull_request_id = prr.pull_request_id
+		GROUP BY days.day, days.final, prr.user_id` + keepFinal,
+	}
+
+	for _, query := range queries {
+		if _, err := tx.ExecContext(ctx, query, from.Format(time.DateOnly), to.Format(time.DateOnly), orgID, today.Format(time.DateOnly)); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Debug("stats snapshots saved", "org_id", orgID, "from", from.Format(time.DateOnly), "to", to.Format(time.DateOnly))
+	return nil
+}
+
+func (r *StatsRepository) GetSnapshots(ctx context.Context, scope, scopeID string, from, to time.Time) ([]domain.StatsSnapshot, error) {
+	ctx, span := tracing.StartQuery(ctx, "StatsRepository.GetSnapshots")
+	defer span.End()
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT snapshot_date, scope, scope_id, total_prs, open_prs, merged_prs
+		FROM stats_snapshots
+		WHERE org_id = $1 AND scope = $2 AND ($3 = '' OR scope_id = $3)
+			AND snapshot_date >= $4::date AND snapshot_date <= $5::date
+		ORDER BY scope_id, snapshot_date
+	`, orgID, scope, scopeID, from.Format(time.DateOnly), to.Format(time.DateOnly))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var snapshots []domain.StatsSnapshot
+	for rows.Next() {
+		var snapshot domain.StatsSnapshot
+		if err := rows.Scan(&snapshot.Date, &snapshot.Scope, &snapshot.ScopeID, &snapshot.TotalPRs, &snapshot.OpenPRs, &snapshot.MergedPRs); err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots, nil
+}
+
+// GetHistoryBounds возвращает дату первого PR и признак того, что снимки уже есть
+func (r *StatsRepository) GetHistoryBounds(ctx context.Context) (*time.Time, bool, error) {
+	ctx, span := tracing.StartQuery(ctx, "StatsRepository.GetHistoryBounds")
+	defer span.End()
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var firstPR sql.NullTime
+	var hasSnapshots bool
+	err = r.db.QueryRowContext(ctx, `
+		SELECT 
+			(SELECT MIN(created_at) FROM pull_requests WHERE org_id = $1),
+			EXISTS(SELECT 1 FROM stats_snapshots WHERE org_id = $1)
+	`, orgID).Scan(&firstPR, &hasSnapshots)
+	if err != nil {
+		return nil, false, err
+	}
+	if !firstPR.Valid {
+		return nil, hasSnapshots, nil
+	}
+	return &firstPR.Time, hasSnapshots, nil
+}
+
+// GetOpenPRsByTeam считает по всем организациям: метрики снимает оператор сервиса, а не вызывающий
+func (r *StatsRepository) GetOpenPRsByTeam(ctx context.Context) (map[domain.OrgKey]int, error) {
+	ctx, span := tracing.StartQuery(ctx, "StatsRepository.GetOpenPRsByTeam")
+	defer span.End()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT author.org_id, author.team_name, COUNT(*)
+		FROM pull_requests pr
+		JOIN users author ON pr.org_id = author.org_id AND pr.author_id = author.user_id
+		WHERE pr.status = 'OPEN'
+		GROUP BY author.org_id, author.team_name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[domain.OrgKey]int)
+	for rows.Next() {
+		var team domain.OrgKey
+		var count int
+		if err := rows.Scan(&team.OrgID, &team.ID, &count); err != nil {
+			return nil, err
+		}
+		counts[team] = count
+	}
+	return counts, nil
+}
+
+func (r *StatsRepository) GetOpenReviewsByUser(ctx context.Context) (map[domain.OrgKey]int, error) {
+	ctx, span := tracing.StartQuery(ctx, "StatsRepository.GetOpenReviewsByUser")
+	defer span.End()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT prr.org_id, prr.user_id, COUNT(*)
+		FROM pr_reviewers prr
+		JOIN pull_requests pr ON prr.org_id = pr.org_id AND prr.pull_request_id = pr.pull_request_id
+		WHERE pr.status = 'OPEN'
+		GROUP BY prr.org_id, prr.user_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[domain.OrgKey]int)
+	for rows.Next() {
+		var user domain.OrgKey
+		var count int
+		if err := rows.Scan(&user.OrgID, &user.ID, &count); err != nil {
+			return nil, err
+		}
+		counts[user] = count
+	}
+	return counts, nil
+}
@@ -0,0 +1,277 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+
+	"pr-review-manager/internal/auth"
+	"pr-review-manager/internal/domain"
+	"pr-review-manager/internal/tracing"
+)
+
+type SubscriptionRepository struct {
+	db *sql.DB
+}
+
+func NewSubscriptionRepository(db *sql.DB) *SubscriptionRepository {
+	return &SubscriptionRepository{db: db}
+}
+
+const subscriptionColumns = "subscription_id, org_id, url, secret, event_types, created_at"
+
+func scanSubscription(row interface{ Scan(...any) error }) (*domain.Subscription, error) {
+	var sub domain.Subscription
+	if err := row.Scan(&sub.SubscriptionID, &sub.OrgID, &sub.URL, &sub.Secret, pq.Array(&sub.EventTypes), &sub.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+const deliveryColumns = "d.delivery_id, d.org_id, d.subscription_id, d.event_id, d.event_type, d.payload, d.status, d.attempts, " +
+	"d.next_attempt_at, d.last_status_code, d.last_error, d.created_at, d.delivered_at"
+
+func scanDelivery(row interface{ Scan(...any) error }, extra ...any) (*domain.Delivery, error) {
+	var delivery domain.Delivery
+	var nextAttemptAt, deliveredAt sql.NullTime
+	var lastStatusCode sql.NullInt64
+	var lastError sql.NullString
+	dest := []any{
+		&delivery.DeliveryID, &delivery.OrgID, &delivery.SubscriptionID, &delivery.EventID, &delivery.EventType,
+		&delivery.Payload, &delivery.Status, &delivery.Attempts,
+		&nextAttemptAt, &lastStatusCode, &lastError, &delivery.CreatedAt, &deliveredAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	if nextAttemptAt.Valid {
+		delivery.NextAttemptAt = &nextAttemptAt.Time
+	}
+	if lastStatusCode.Valid {
+		code := int(lastStatusCode.Int64)
+		delivery.LastStatusCode = &code
+	}
+	delivery.LastError = lastError.String
+	if deliveredAt.Valid {
+		delivery.DeliveredAt = &deliveredAt.Time
+	}
+	return &delivery, nil
+}
+
+func scanDeliveries(rows *sql.Rows, withTarget bool) ([]domain.Delivery, error) {
+	defer rows.Close()
+
+	deliveries := []domain.Delivery{}
+	for rows.Next() {
+		var url, secret string
+		extra := []any{}
+		if withTarget {
+			extra = append(extra, &url, &secret)
+		}
+		delivery, err := scanDelivery(rows, extra...)
+		if err != nil {
+			return nil, err
+		}
+		delivery.URL, delivery.Secret = url, secret
+		deliveries = append(deliveries, *delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+func (r *SubscriptionRepository) CreateSubscription(ctx context.Context, sub *domain.Subscription) error {
+	ctx, span := tracing.StartQuery(ctx, "SubscriptionRepository.CreateSubscription")
+	defer span.End()
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO webhook_subscriptions (org_id, subscription_id, url, secret, event_types, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, sub.OrgID, sub.SubscriptionID, sub.URL, sub.Secret, pq.Array(sub.EventTypes), sub.CreatedAt)
+	return err
+}
+
+func (r *SubscriptionRepository) ListSubscriptions(ctx context.Context) ([]domain.Subscription, error) {
+	ctx, span := tracing.StartQuery(ctx, "SubscriptionRepository.ListSubscriptions")
+	defer span.End()
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+subscriptionColumns+`
+		FROM webhook_subscriptions
+		WHERE org_id = $1
+		ORDER BY created_at, subscription_id
+	`, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subs := []domain.Subscription{}
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, *sub)
+	}
+	return subs, rows.Err()
+}
+
+// DeleteSubscription удаляет подписку вместе с журналом её доставок
+func (r *SubscriptionRepository) DeleteSubscription(ctx context.Context, subscriptionID string) (*domain.Subscription, error) {
+	ctx, span := tracing.StartQuery(ctx, "SubscriptionRepository.DeleteSubscription")
+	defer span.End()
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sub, err := scanSubscription(r.db.QueryRowContext(ctx, `
+		DELETE FROM webhook_subscriptions
+		WHERE org_id = $1 AND subscription_id = $2
+		RETURNING `+subscriptionColumns, orgID, subscriptionID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return sub, err
+}
+
+// EnqueueEvent ставит событие в очередь каждой подписке организации на этот тип и возвращает число доставок
+func (r *SubscriptionRepository) EnqueueEvent(ctx context.Context, event *domain.Event, payload []byte) (int64, error) {
+	ctx, span := tracing.StartQuery(ctx, "SubscriptionRepository.EnqueueEvent")
+	defer span.End()
+
+	result, err := r.db.ExecContext(ctx, `
+		INSERT INTO webhook_deliveries (org_id, subscription_id, event_id, event_type, payload, status, next_attempt_at, created_at)
+		SELECT org_id, subscription_id, $2, $3, $4, $5, $6, $6
+		FROM webhook_subscriptions
+		WHERE org_id = $1 AND $3 = ANY(event_types)
+	`, event.OrgID, event.EventID, event.Type, payload, domain.DeliveryPending, event.OccurredAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// ListDeliveries возвращает журнал доставок от новых к старым; пустые subscriptionID и status не фильтруют
+func (r *SubscriptionRepository) ListDeliveries(ctx context.Context, subscriptionID, status string, limit int) ([]domain.Delivery, error) {
+	ctx, span := tracing.StartQuery(ctx, "SubscriptionRepository.ListDeliveries")
+	defer span.End()
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+deliveryColumns+`
+		FROM webhook_deliveries d
+		WHERE d.org_id = $1 AND ($2 = '' OR d.subscription_id = $2) AND ($3 = '' OR d.status = $3)
+		ORDER BY d.delivery_id DESC
+		LIMIT $4
+	`, orgID, subscriptionID, status, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanDeliveries(rows, false)
+}
+
+// Redeliver создаёт новую доставку с тем же телом; исходная запись остаётся в журнале
+func (r *SubscriptionRepository) Redeliver(ctx context.Context, deliveryID int64, now time.Time) (*domain.Delivery, error) {
+	ctx, span := tracing.StartQuery(ctx, "SubscriptionRepository.Redeliver")
+	defer span.End()
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery, err := scanDelivery(r.db.QueryRowContext(ctx, `
+		INSERT INTO webhook_deliveries AS d (org_id, subscription_id, event_id, event_type, payload, status, next_attempt_at, created_at)
+		SELECT org_id, subscription_id, event_id, event_type, payload, $3, $4, $4
+		FROM webhook_deliveries
+		WHERE org_id = $1 AND delivery_id = $2
+		RETURNING `+deliveryColumns, orgID, deliveryID, domain.DeliveryPending, now))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return delivery, err
+}
+
+// ClaimDue забирает доставки всех организаций, срок которых наступил, и сдвигает их next_attempt_at на leaseUntil,
+// чтобы другой экземпляр сервиса не отправил их одновременно. Попытка засчитывается сразу
+func (r *SubscriptionRepository) ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]domain.Delivery, error) {
+	ctx, span := tracing.StartQuery(ctx, "SubscriptionRepository.ClaimDue")
+	defer span.End()
+
+	rows, err := r.db.QueryContext(ctx, `
+		UPDATE webhook_deliveries d
+		SET attempts = d.attempts + 1, next_attempt_at = $2
+		FROM webhook_subscriptions s
+		WHERE d.delivery_id IN (
+			SELECT delivery_id
+			FROM webhook_deliveries
+			WHERE status = $4 AND next_attempt_at <= $1
+			ORDER BY next_attempt_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		) AND s.org_id = d.org_id AND s.subscription_id = d.subscription_id
+		RETURNING `+deliveryColumns+`, s.url, s.secret
+	`, now, leaseUntil, limit, domain.DeliveryPending)
+	if err != nil {
+		return nil, err
+	}
+	return scanDeliveries(rows, true)
+}
+
+func (r *SubscriptionRepository) MarkDelivered(ctx context.Context, deliveryID int64, statusCode int, deliveredAt time.Time) error {
+	ctx, span := tracing.StartQuery(ctx, "SubscriptionRepository.MarkDelivered")
+	defer span.End()
+
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = $2, last_status_code = $3, last_error = NULL, next_attempt_at = NULL, delivered_at = $4
+		WHERE delivery_id = $1
+	`, deliveryID, domain.DeliveryDelivered, statusCode, deliveredAt)
+	return err
+}
+
+// MarkAttemptFailed записывает неудачную попытку. Без nextAttemptAt доставка помечается как failed;
+// statusCode 0 — ответа не было
+func (r *SubscriptionRepository) MarkAttemptFailed(ctx context.Context, deliveryID int64, statusCode int, lastError string, nextAttemptAt *time.Time) error {
+	ctx, span := tracing.StartQuery(ctx, "SubscriptionRepository.MarkAttemptFailed")
+	defer span.End()
+
+	status := domain.DeliveryPending
+	if nextAttemptAt == nil {
+		status = domain.DeliveryFailed
+	}
+	code := sql.NullInt64{Int64: int64(statusCode), Valid: statusCode != 0}
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = $2, last_status_code = $3, last_error = $4, next_attempt_at = $5
+		WHERE delivery_id = $1
+	`, deliveryID, status, code, lastError, nextAttemptAt)
+	return err
+}
+
+// DeleteFinishedBefore чистит журнал всех организаций; ожидающие отправки доставки не трогает
+func (r *SubscriptionRepository) DeleteFinishedBefore(ctx context.Context, before time.Time) (int64, error) {
+	ctx, span := tracing.StartQuery(ctx, "SubscriptionRepository.DeleteFinishedBefore")
+	defer span.End()
+
+	result, err := r.db.ExecContext(ctx, `
+		DELETE FROM webhook_deliveries
+		WHERE status <> $1 AND created_at < $2
+	`, domain.DeliveryPending, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -5,7 +5,9 @@ import (
 	"database/sql"
 	"fmt"
 
+	"pr-review-manager/internal/auth"
 	"pr-review-manager/internal/domain"
+	"pr-review-manager/internal/tracing"
 )
 
 type TeamRepository struct {
@@ -17,19 +19,42 @@ func NewTeamRepository(db *sql.DB) *TeamRepository {
 }
 
 func (r *TeamRepository) CreateTeam(ctx context.Context, tx *sql.Tx, teamName string) error {
-	_, err := tx.ExecContext(ctx, "INSERT INTO teams (team_name) VALUES ($1)", teamName)
+	ctx, span := tracing.StartQuery(ctx, "TeamRepository.CreateTeam")
+	defer span.End()
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO teams (org_id, team_name) VALUES ($1, $2)", orgID, teamName)
 	return err
 }
 
 func (r *TeamRepository) TeamExists(ctx context.Context, teamName string) (bool, error) {
+	ctx, span := tracing.StartQuery(ctx, "TeamRepository.TeamExists")
+	defer span.End()
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return false, err
+	}
+
 	var exists bool
-	err := r.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM teams WHERE team_name = $1)", teamName).Scan(&exists)
+	err = r.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM teams WHERE org_id = $1 AND team_name = $2)", orgID, teamName).Scan(&exists)
 	return exists, err
 }
 
 func (r *TeamRepository) GetTeam(ctx context.Context, teamName string) (*domain.Team, error) {
+	ctx, span := tracing.StartQuery(ctx, "TeamRepository.GetTeam")
+	defer span.End()
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return nil, err
+	}
 	var exists bool
-	err := r.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM teams WHERE team_name = $1)", teamName).Scan(&exists)
+	err = r.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM teams WHERE org_id = $1 AND team_name = $2)", orgID, teamName).Scan(&exists)
 	if err != nil {
 		return nil, err
 	}
@@ -40,9 +65,9 @@ func (r *TeamRepository) GetTeam(ctx context.Context, teamName string) (*domain.
 	rows, err := r.db.QueryContext(ctx, `
 		SELECT user_id, username, is_active 
 		FROM users 
-		WHERE team_name = $1
+		WHERE org_id = $1 AND team_name = $2
 		ORDER BY username
-	`, teamName)
+	`, orgID, teamName)
 	if err != nil {
 		return nil, err
 	}
@@ -3,8 +3,15 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"strings"
 
+	"github.com/lib/pq"
+
+	"pr-review-manager/internal/auth"
 	"pr-review-manager/internal/domain"
+	"pr-review-manager/internal/logging"
+	"pr-review-manager/internal/tracing"
 )
 
 type UserRepository struct {
@@ -16,22 +23,38 @@ func NewUserRepository(db *sql.DB) *UserRepository {
 }
 
 func (r *UserRepository) UpsertUser(ctx context.Context, tx *sql.Tx, user *domain.User) error {
-	_, err := tx.ExecContext(ctx, `
-		INSERT INTO users (user_id, username, team_name, is_active)
-		VALUES ($1, $2, $3, $4)
-		ON CONFLICT (user_id) 
-		DO UPDATE SET username = $2, team_name = $3, is_active = $4
-	`, user.UserID, user.Username, user.TeamName, user.IsActive)
+	ctx, span := tracing.StartQuery(ctx, "UserRepository.UpsertUser")
+	defer span.End()
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO users (org_id, user_id, username, team_name, is_active)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (org_id, user_id) 
+		DO UPDATE SET username = $3, team_name = $4, is_active = $5
+	`, orgID, user.UserID, user.Username, user.TeamName, user.IsActive)
 	return err
 }
 
 func (r *UserRepository) GetUser(ctx context.Context, userID string) (*domain.User, error) {
+	ctx, span := tracing.StartQuery(ctx, "UserRepository.GetUser")
+	defer span.End()
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	var user domain.User
-	err := r.db.QueryRowContext(ctx, `
-		SELECT user_id, username, team_name, is_active 
+	err = r.db.QueryRowContext(ctx, `
+		SELECT user_id, username, team_name, is_active, role
 		FROM users 
-		WHERE user_id = $1
-	`, userID).Scan(&user.UserID, &user.Username, &user.TeamName, &user.IsActive)
+		WHERE org_id = $1 AND user_id = $2
+	`, orgID, userID).Scan(&user.UserID, &user.Username, &user.TeamName, &user.IsActive, &user.Role)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -43,13 +66,47 @@ func (r *UserRepository) GetUser(ctx context.Context, userID string) (*domain.Us
 }
 
 func (r *UserRepository) SetIsActive(ctx context.Context, userID string, isActive bool) (*domain.User, error) {
+	ctx, span := tracing.StartQuery(ctx, "UserRepository.SetIsActive")
+	defer span.End()
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	var user domain.User
-	err := r.db.QueryRowContext(ctx, `
+	err = r.db.QueryRowContext(ctx, `
 		UPDATE users 
-		SET is_active = $2 
-		WHERE user_id = $1
-		RETURNING user_id, username, team_name, is_active
-	`, userID, isActive).Scan(&user.UserID, &user.Username, &user.TeamName, &user.IsActive)
+		SET is_active = $3 
+		WHERE org_id = $1 AND user_id = $2
+		RETURNING user_id, username, team_name, is_active, role
+	`, orgID, userID, isActive).Scan(&user.UserID, &user.Username, &user.TeamName, &user.IsActive, &user.Role)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *UserRepository) SetRole(ctx context.Context, userID, role string) (*domain.User, error) {
+	ctx, span := tracing.StartQuery(ctx, "UserRepository.SetRole")
+	defer span.End()
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var user domain.User
+	err = r.db.QueryRowContext(ctx, `
+		UPDATE users
+		SET role = $3
+		WHERE org_id = $1 AND user_id = $2
+		RETURNING user_id, username, team_name, is_active, role
+	`, orgID, userID, role).Scan(&user.UserID, &user.Username, &user.TeamName, &user.IsActive, &user.Role)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -61,11 +118,19 @@ func (r *UserRepository) SetIsActive(ctx context.Context, userID string, isActiv
 }
 
 func (r *UserRepository) GetActiveTeamMembers(ctx context.Context, teamName, excludeUserID string) ([]domain.User, error) {
+	ctx, span := tracing.StartQuery(ctx, "UserRepository.GetActiveTeamMembers")
+	defer span.End()
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	rows, err := r.db.QueryContext(ctx, `
 		SELECT user_id, username, team_name, is_active 
 		FROM users 
-		WHERE team_name = $1 AND is_active = true AND user_id != $2
-	`, teamName, excludeUserID)
+		WHERE org_id = $1 AND team_name = $2 AND is_active = true AND user_id != $3
+	`, orgID, teamName, excludeUserID)
 	if err != nil {
 		return nil, err
 	}
@@ -83,12 +148,20 @@ func (r *UserRepository) GetActiveTeamMembers(ctx context.Context, teamName, exc
 }
 
 func (r *UserRepository) DeactivateTeamUsers(ctx context.Context, tx *sql.Tx, teamName string) ([]string, error) {
+	ctx, span := tracing.StartQuery(ctx, "UserRepository.DeactivateTeamUsers")
+	defer span.End()
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	rows, err := tx.QueryContext(ctx, `
 		UPDATE users 
 		SET is_active = false 
-		WHERE team_name = $1 AND is_active = true
+		WHERE org_id = $1 AND team_name = $2 AND is_active = true
 		RETURNING user_id
-	`, teamName)
+	`, orgID, teamName)
 	if err != nil {
 		return nil, err
 	}
@@ -102,15 +175,24 @@ func (r *UserRepository) DeactivateTeamUsers(ctx context.Context, tx *sql.Tx, te
 		}
 		userIDs = append(userIDs, userID)
 	}
+	logging.FromContext(ctx).Debug("team users deactivated", "user_ids", userIDs)
 	return userIDs, nil
 }
 
 func (r *UserRepository) GetActiveUsers(ctx context.Context, tx *sql.Tx) ([]domain.User, error) {
+	ctx, span := tracing.StartQuery(ctx, "UserRepository.GetActiveUsers")
+	defer span.End()
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	rows, err := tx.QueryContext(ctx, `
 		SELECT user_id, username, team_name, is_active 
 		FROM users 
-		WHERE is_active = true
-	`)
+		WHERE org_id = $1 AND is_active = true
+	`, orgID)
 	if err != nil {
 		return nil, err
 	}
@@ -126,3 +208,168 @@ func (r *UserRepository) GetActiveUsers(ctx context.Context, tx *sql.Tx) ([]doma
 	}
 	return users, nil
 }
+
+func (r *UserRepository) ListUsers(ctx context.Context, filter domain.UserListFilter) ([]domain.UserWithLoad, int, error) {
+	ctx, span := tracing.StartQuery(ctx, "UserRepository.ListUsers")
+	defer span.End()
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	conditions := []string{"u.org_id = $1"}
+	args := []interface{}{orgID}
+
+	if filter.TeamName != "" {
+		args = append(args, filter.TeamName)
+		conditions = append(conditions, fmt.Sprintf("u.team_name = $%d", len(args)))
+	}
+	if filter.IsActive != nil {
+		args = append(args, *filter.IsActive)
+		conditions = append(conditions, fmt.Sprintf("u.is_active = $%d", len(args)))
+	}
+	if filter.MinOpenReviews != nil {
+		args = append(args, *filter.MinOpenReviews)
+		conditions = append(conditions, fmt.Sprintf("COALESCE(reviewer_load.open_assigned, 0) >= $%d", len(args)))
+	}
+	if filter.MaxOpenReviews != nil {
+		args = append(args, *filter.MaxOpenReviews)
+		conditions = append(conditions, fmt.Sprintf("COALESCE(reviewer_load.open_assigned, 0) <= $%d", len(args)))
+	}
+
+	// Нагрузка считается тем же подзапросом, что и в статистике
+	baseQuery := fmt.Sprintf(`
+		SELECT 
+			u.user_id,
+			u.username,
+			u.team_name,
+			u.is_active,
+			COALESCE(reviewer_load.open_assigned, 0) as open_reviews,
+			COALESCE(reviewer_load.total_assigned, 0) as total_reviews
+		FROM users u
+		LEFT JOIN (`+reviewerLoad+`
+		) reviewer_load ON reviewer_load.user_id = u.user_id
+		WHERE %s
+	`, strings.Join(conditions, " AND "))
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM ("+baseQuery+") filtered", args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+	query := fmt.Sprintf(`%s
+		ORDER BY open_reviews, u.username, u.user_id
+		LIMIT $%d OFFSET $%d
+	`, baseQuery, len(args)-1, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	users := []domain.UserWithLoad{}
+	for rows.Next() {
+		var user domain.UserWithLoad
+		if err := rows.Scan(&user.UserID, &user.Username, &user.TeamName, &user.IsActive, &user.OpenReviews, &user.TotalReviews); err != nil {
+			return nil, 0, err
+		}
+		users = append(users, user)
+	}
+	return users, total, nil
+}
+
+// LinkAccount привязывает логин к пользователю; повторная привязка логина переносит его на нового пользователя
+func (r *UserRepository) LinkAccount(ctx context.Context, account *domain.UserAccount) error {
+	ctx, span := tracing.StartQuery(ctx, "UserRepository.LinkAccount")
+	defer span.End()
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, `
+		INSERT INTO user_accounts (org_id, provider, login, user_id)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (org_id, provider, login)
+		DO UPDATE SET user_id = $4
+	`, orgID, account.Provider, account.Login, account.UserID)
+	return err
+}
+
+func (r *UserRepository) UnlinkAccount(ctx context.Context, provider, login string) error {
+	ctx, span := tracing.StartQuery(ctx, "UserRepository.UnlinkAccount")
+	defer span.End()
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, `
+		DELETE FROM user_accounts
+		WHERE org_id = $1 AND provider = $2 AND login = $3
+	`, orgID, provider, login)
+	return err
+}
+
+// GetAccountLogins возвращает логины пользователей у провайдера; пользователи без привязки пропускаются
+func (r *UserRepository) GetAccountLogins(ctx context.Context, provider string, userIDs []string) (map[string]string, error) {
+	ctx, span := tracing.StartQuery(ctx, "UserRepository.GetAccountLogins")
+	defer span.End()
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT user_id, MIN(login)
+		FROM user_accounts
+		WHERE org_id = $1 AND provider = $2 AND user_id = ANY($3)
+		GROUP BY user_id
+	`, orgID, provider, pq.Array(userIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	logins := make(map[string]string)
+	for rows.Next() {
+		var userID, login string
+		if err := rows.Scan(&userID, &login); err != nil {
+			return nil, err
+		}
+		logins[userID] = login
+	}
+	return logins, rows.Err()
+}
+
+func (r *UserRepository) GetUserByAccount(ctx context.Context, provider, login string) (*domain.User, error) {
+	ctx, span := tracing.StartQuery(ctx, "UserRepository.GetUserByAccount")
+	defer span.End()
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var user domain.User
+	err = r.db.QueryRowContext(ctx, `
+		SELECT u.user_id, u.username, u.team_name, u.is_active, u.role
+		FROM user_accounts a
+		JOIN users u ON u.org_id = a.org_id AND u.user_id = a.user_id
+		WHERE a.org_id = $1 AND a.provider = $2 AND a.login = $3
+	`, orgID, provider, login).Scan(&user.UserID, &user.Username, &user.TeamName, &user.IsActive, &user.Role)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
@@ -1,45 +1,101 @@
 package router
 
 import (
-	"encoding/json"
-	"net/http"
+	"log/slog"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"pr-review-manager/internal/domain"
 	"pr-review-manager/internal/handler"
+	"pr-review-manager/internal/logging"
+	"pr-review-manager/internal/metrics"
+	"pr-review-manager/internal/tracing"
 )
 
-func NewRouter(teamHandler *handler.TeamHandler, userHandler *handler.UserHandler, prHandler *handler.PRHandler, statsHandler *handler.StatsHandler) *chi.Mux {
+func NewRouter(teamHandler *handler.TeamHandler, userHandler *handler.UserHandler, prHandler *handler.PRHandler, statsHandler *handler.StatsHandler, healthHandler *handler.HealthHandler, apiKeyHandler *handler.APIKeyHandler, authenticator *handler.Authenticator, rateLimiter *handler.RateLimiter, idempotency *handler.Idempotency, githubWebhookHandler *handler.GitHubWebhookHandler, gitlabWebhookHandler *handler.GitLabWebhookHandler, subscriptionHandler *handler.SubscriptionHandler) *chi.Mux {
 	r := chi.NewRouter()
 
-	r.Use(middleware.Logger)
-	r.Use(middleware.Recoverer)
 	r.Use(middleware.RequestID)
+	r.Use(tracing.Middleware)
+	r.Use(logging.Middleware(slog.Default()))
+	r.Use(middleware.Recoverer)
+	r.Use(metrics.Middleware)
 
-	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
-	})
+	r.Get("/health", healthHandler.Liveness)
+	r.Get("/livez", healthHandler.Liveness)
+	r.Get("/readyz", healthHandler.Readiness)
 
-	r.Get("/stats", statsHandler.GetStats)
+	// Вебхуки аутентифицируются подписью или токеном провайдера, а не API-ключом
+	r.Post("/webhooks/github", githubWebhookHandler.Handle)
+	r.Post("/webhooks/gitlab", gitlabWebhookHandler.Handle)
 
-	r.Route("/team", func(r chi.Router) {
-		r.Post("/add", teamHandler.AddTeam)
-		r.Get("/get", teamHandler.GetTeam)
-		r.Post("/deactivate", teamHandler.DeactivateTeam)
-	})
+	// Пробы открыты, остальные маршруты ограничены по частоте и требуют API-ключ с нужным scope
+	read := authenticator.Require(domain.ScopeRead)
+	prWrite := authenticator.Require(domain.ScopePRWrite)
+	teamAdmin := authenticator.Require(domain.ScopeTeamAdmin)
+	idempotent := idempotency.Handle
 
-	r.Route("/users", func(r chi.Router) {
-		r.Post("/setIsActive", userHandler.SetIsActive)
-		r.Get("/getReview", userHandler.GetReview)
-	})
+	r.Group(func(r chi.Router) {
+		r.Use(rateLimiter.Limit)
+
+		r.Route("/stats", func(r chi.Router) {
+			r.With(read).Get("/", statsHandler.GetStats)
+			r.With(read).Get("/cycle-time", statsHandler.GetCycleTime)
+			r.With(read).Get("/fairness", statsHandler.GetFairness)
+			r.With(read).Get("/aging", statsHandler.GetAging)
+			r.With(read).Get("/history", statsHandler.GetHistory)
+			r.With(teamAdmin, idempotent).Post("/history/backfill", statsHandler.BackfillHistory)
+		})
+
+		r.Route("/team", func(r chi.Router) {
+			r.With(teamAdmin, idempotent).Post("/add", teamHandler.AddTeam)
+			r.With(read).Get("/get", teamHandler.GetTeam)
+			r.With(teamAdmin, idempotent).Post("/deactivate", teamHandler.DeactivateTeam)
+		})
+
+		r.Route("/users", func(r chi.Router) {
+			r.With(teamAdmin, idempotent).Post("/setIsActive", userHandler.SetIsActive)
+			r.With(teamAdmin, idempotent).Post("/setRole", userHandler.SetRole)
+			r.With(teamAdmin, idempotent).Post("/linkAccount", userHandler.LinkAccount)
+			r.With(teamAdmin, idempotent).Post("/unlinkAccount", userHandler.UnlinkAccount)
+			r.With(read).Get("/getReview", userHandler.GetReview)
+			r.With(read).Get("/list", userHandler.ListUsers)
+		})
 
-	r.Route("/pullRequest", func(r chi.Router) {
-		r.Post("/create", prHandler.CreatePR)
-		r.Post("/merge", prHandler.MergePR)
-		r.Post("/reassign", prHandler.ReassignReviewer)
+		r.Route("/pullRequest", func(r chi.Router) {
+			r.With(prWrite, idempotent).Post("/create", prHandler.CreatePR)
+			r.With(read).Get("/get", prHandler.GetPR)
+			r.With(prWrite, idempotent).Post("/merge", prHandler.MergePR)
+			r.With(prWrite, idempotent).Post("/reassign", prHandler.ReassignReviewer)
+			r.With(prWrite, idempotent).Post("/review", prHandler.SubmitReview)
+		})
+
+		r.Route("/apiKeys", func(r chi.Router) {
+			r.Use(teamAdmin)
+			// Открытое значение ключа не сохраняется: повтор возвращает ответ без него
+			r.With(idempotency.Redacting(handler.RedactAPIKeySecret)).Post("/create", apiKeyHandler.CreateKey)
+			r.Get("/list", apiKeyHandler.ListKeys)
+			r.With(idempotent).Post("/revoke", apiKeyHandler.RevokeKey)
+		})
+
+		r.Route("/subscriptions", func(r chi.Router) {
+			r.Use(teamAdmin)
+			r.With(idempotent).Post("/create", subscriptionHandler.CreateSubscription)
+			r.Get("/list", subscriptionHandler.ListSubscriptions)
+			r.With(idempotent).Post("/delete", subscriptionHandler.DeleteSubscription)
+			r.Get("/deliveries", subscriptionHandler.ListDeliveries)
+			r.With(idempotent).Post("/redeliver", subscriptionHandler.Redeliver)
+		})
 	})
 
 	return r
 }
+
+// NewInternalRouter отдаёт /metrics: метрики размечены организациями и командами, поэтому
+// слушаются на отдельном адресе, закрытом от внешней сети
+func NewInternalRouter() *chi.Mux {
+	r := chi.NewRouter()
+	r.Use(middleware.Recoverer)
+	r.Handle("/metrics", metrics.Handler())
+	return r
+}
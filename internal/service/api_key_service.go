@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"slices"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"pr-review-manager/internal/auth"
+	"pr-review-manager/internal/domain"
+	"pr-review-manager/internal/errors"
+	"pr-review-manager/internal/logging"
+	"pr-review-manager/internal/policy"
+	"pr-review-manager/internal/repository"
+	"pr-review-manager/internal/tracing"
+)
+
+// apiKeyPrefix помогает опознать ключ сервиса в логах и сканерах секретов
+const apiKeyPrefix = "prm_"
+
+type APIKeyService struct {
+	apiKeyRepo *repository.APIKeyRepository
+}
+
+func NewAPIKeyService(apiKeyRepo *repository.APIKeyRepository) *APIKeyService {
+	return &APIKeyService{apiKeyRepo: apiKeyRepo}
+}
+
+// CreateKey генерирует ключ в организации вызывающего и сохраняет только его SHA-256;
+// открытое значение возвращается один раз
+func (s *APIKeyService) CreateKey(ctx context.Context, name string, scopes []string) (*domain.CreatedAPIKey, error) {
+	ctx, span := tracing.Start(ctx, "APIKeyService.CreateKey")
+	defer span.End()
+
+	if err := policy.RequireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	if len(scopes) == 0 {
+		return nil, errors.ErrInvalidScope
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(domain.Scopes, scope) {
+			return nil, errors.ErrInvalidScope
+		}
+	}
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return nil, err
+	}
+	keyID, err := randomToken(8)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	key := domain.APIKey{
+		KeyID:     "key_" + keyID,
+		OrgID:     orgID,
+		Name:      name,
+		Scopes:    slices.Compact(slices.Sorted(slices.Values(scopes))),
+		CreatedAt: time.Now().UTC(),
+	}
+	plain := apiKeyPrefix + secret
+	if err := s.apiKeyRepo.CreateKey(ctx, &key, hashAPIKey(plain)); err != nil {
+		return nil, err
+	}
+	logging.FromContext(ctx).Info("api key created", "key_id", key.KeyID, "org_id", key.OrgID, "scopes", key.Scopes)
+
+	return &domain.CreatedAPIKey{APIKey: key, Key: plain}, nil
+}
+
+func (s *APIKeyService) ListKeys(ctx context.Context) ([]domain.APIKey, error) {
+	ctx, span := tracing.Start(ctx, "APIKeyService.ListKeys")
+	defer span.End()
+
+	if err := policy.RequireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	return s.apiKeyRepo.ListKeys(ctx)
+}
+
+func (s *APIKeyService) RevokeKey(ctx context.Context, keyID string) (*domain.APIKey, error) {
+	ctx, span := tracing.Start(ctx, "APIKeyService.RevokeKey")
+	defer span.End()
+
+	if err := policy.RequireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	key, err := s.apiKeyRepo.RevokeKey(ctx, keyID, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, errors.ErrNotFound
+	}
+	logging.FromContext(ctx).Info("api key revoked", "key_id", key.KeyID)
+	return key, nil
+}
+
+// Authenticate находит действующий ключ по хешу и отмечает время его использования
+func (s *APIKeyService) Authenticate(ctx context.Context, plain string) (*domain.APIKey, error) {
+	ctx, span := tracing.Start(ctx, "APIKeyService.Authenticate")
+	defer span.End()
+
+	// Значение другого формата не может быть ключом сервиса, в БД за ним не ходим
+	if !strings.HasPrefix(plain, apiKeyPrefix) {
+		return nil, errors.ErrUnauthorized
+	}
+
+	key, err := s.apiKeyRepo.GetKeyByHash(ctx, hashAPIKey(plain))
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, errors.ErrUnauthorized
+	}
+	span.SetAttributes(attribute.String("auth.key_id", key.KeyID))
+
+	// Ошибка учёта last_used не должна блокировать запрос
+	if err := s.apiKeyRepo.TouchLastUsed(ctx, key.KeyID, time.Now().UTC()); err != nil {
+		logging.FromContext(ctx).Warn("failed to update api key last_used_at", "key_id", key.KeyID, "error", err)
+	}
+	return key, nil
+}
+
+func hashAPIKey(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomToken(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"pr-review-manager/internal/auth"
+	"pr-review-manager/internal/domain"
+	"pr-review-manager/internal/errors"
+	"pr-review-manager/internal/logging"
+	"pr-review-manager/internal/repository"
+	"pr-review-manager/internal/tracing"
+)
+
+// AuthService определяет вызывающего по API-ключу, bearer-токену OIDC-провайдера
+// или заголовку доверенного прокси
+type AuthService struct {
+	apiKeyService *APIKeyService
+	userRepo      *repository.UserRepository
+	verifier      *auth.JWTVerifier
+}
+
+// NewAuthService принимает nil verifier, если bearer-токены не настроены
+func NewAuthService(apiKeyService *APIKeyService, userRepo *repository.UserRepository, verifier *auth.JWTVerifier) *AuthService {
+	return &AuthService{
+		apiKeyService: apiKeyService,
+		userRepo:      userRepo,
+		verifier:      verifier,
+	}
+}
+
+func (s *AuthService) AuthenticateAPIKey(ctx context.Context, plain string) (*domain.Principal, error) {
+	key, err := s.apiKeyService.Authenticate(ctx, plain)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.Principal{APIKeyID: key.KeyID, OrgID: key.OrgID, Scopes: key.Scopes}, nil
+}
+
+// AuthenticateBearer проверяет токен и сопоставляет его claim'ы с существующим активным пользователем организации
+func (s *AuthService) AuthenticateBearer(ctx context.Context, token string) (*domain.Principal, error) {
+	ctx, span := tracing.Start(ctx, "AuthService.AuthenticateBearer")
+	defer span.End()
+
+	if s.verifier == nil {
+		return nil, errors.ErrInvalidToken
+	}
+
+	userID, orgID, err := s.verifier.Verify(ctx, token)
+	if err != nil {
+		logging.FromContext(ctx).Info("bearer token rejected", "reason", err.Error())
+		return nil, errors.ErrInvalidToken
+	}
+
+	principal, err := s.userPrincipal(ctx, orgID, userID)
+	if err != nil {
+		return nil, err
+	}
+	span.SetAttributes(attribute.String("auth.user_id", principal.UserID), attribute.String("auth.org_id", principal.OrgID))
+	return principal, nil
+}
+
+// AuthenticateTrustedUser доверяет user_id и org_id, которые проставил аутентифицирующий прокси перед сервисом
+func (s *AuthService) AuthenticateTrustedUser(ctx context.Context, orgID, userID string) (*domain.Principal, error) {
+	ctx, span := tracing.Start(ctx, "AuthService.AuthenticateTrustedUser")
+	defer span.End()
+
+	return s.userPrincipal(ctx, orgID, userID)
+}
+
+// userPrincipal выдаёт scope по роли пользователя; неизвестный или неактивный пользователь не аутентифицируется.
+// org_id пуст, только когда claim или заголовок организации не настроены (одна организация),
+// и тогда пользователь ищется в организации по умолчанию
+func (s *AuthService) userPrincipal(ctx context.Context, orgID, userID string) (*domain.Principal, error) {
+	if orgID == "" {
+		orgID = domain.DefaultOrgID
+	}
+
+	user, err := s.userRepo.GetUser(auth.WithOrg(ctx, orgID), userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || !user.IsActive {
+		logging.FromContext(ctx).Info("caller rejected", "reason", "unknown or inactive user", "caller_user_id", userID, "org_id", orgID)
+		return nil, errors.ErrInvalidToken
+	}
+
+	return &domain.Principal{
+		UserID:   user.UserID,
+		OrgID:    orgID,
+		Role:     user.Role,
+		TeamName: user.TeamName,
+		Scopes:   domain.RoleScopes(user.Role),
+	}, nil
+}
+
+// callerUserID возвращает пользователя из bearer-токена; для API-ключей и без аутентификации — пустую строку
+func callerUserID(ctx context.Context) string {
+	if principal := auth.PrincipalFromContext(ctx); principal != nil {
+		return principal.UserID
+	}
+	return ""
+}
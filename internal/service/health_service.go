@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"pr-review-manager/internal/domain"
+	"pr-review-manager/internal/repository"
+)
+
+type HealthService struct {
+	healthRepo       *repository.HealthRepository
+	expectedVersion  uint
+	readinessTimeout time.Duration
+}
+
+func NewHealthService(healthRepo *repository.HealthRepository, expectedVersion uint, readinessTimeout time.Duration) *HealthService {
+	return &HealthService{
+		healthRepo:       healthRepo,
+		expectedVersion:  expectedVersion,
+		readinessTimeout: readinessTimeout,
+	}
+}
+
+// Readiness проверяет доступность БД и то, что схема соответствует версии миграций из поставки
+func (s *HealthService) Readiness(ctx context.Context) *domain.HealthReport {
+	ctx, cancel := context.WithTimeout(ctx, s.readinessTimeout)
+	defer cancel()
+
+	report := &domain.HealthReport{
+		Status:     domain.HealthStatusOK,
+		Components: map[string]domain.ComponentHealth{},
+	}
+
+	start := time.Now()
+	database := domain.ComponentHealth{Status: domain.HealthStatusOK}
+	if err := s.healthRepo.Ping(ctx); err != nil {
+		database.Status = domain.HealthStatusUnavailable
+		database.Error = err.Error()
+	}
+	database.LatencyMs = time.Since(start).Milliseconds()
+	report.Components["database"] = database
+
+	migrations := domain.ComponentHealth{
+		Status:  domain.HealthStatusOK,
+		Details: map[string]interface{}{"expected_version": s.expectedVersion},
+	}
+	if database.Status != domain.HealthStatusOK {
+		migrations.Status = domain.HealthStatusUnavailable
+		migrations.Error = "database is unavailable"
+	} else {
+		start = time.Now()
+		version, dirty, err := s.healthRepo.MigrationVersion(ctx)
+		migrations.LatencyMs = time.Since(start).Milliseconds()
+		migrations.Details["current_version"] = version
+		migrations.Details["dirty"] = dirty
+
+		switch {
+		case err != nil:
+			migrations.Status = domain.HealthStatusUnavailable
+			migrations.Error = err.Error()
+		case dirty:
+			migrations.Status = domain.HealthStatusUnavailable
+			migrations.Error = fmt.Sprintf("migration %d is dirty", version)
+		case version != s.expectedVersion:
+			migrations.Status = domain.HealthStatusUnavailable
+			migrations.Error = fmt.Sprintf("schema version %d does not match expected %d", version, s.expectedVersion)
+		}
+	}
+	report.Components["migrations"] = migrations
+
+	for _, component := range report.Components {
+		if component.Status != domain.HealthStatusOK {
+			report.Status = domain.HealthStatusUnavailable
+		}
+	}
+	return report
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"pr-review-manager/internal/domain"
+	"pr-review-manager/internal/errors"
+	"pr-review-manager/internal/logging"
+	"pr-review-manager/internal/repository"
+	"pr-review-manager/internal/tracing"
+)
+
+const maxIdempotencyKeyLength = 255
+
+// IdempotencyService хранит результаты запросов с Idempotency-Key, чтобы повтор после таймаута
+// получил тот же ответ, а не повторное выполнение операции
+type IdempotencyService struct {
+	idempotencyRepo *repository.IdempotencyRepository
+	ttl             time.Duration
+}
+
+func NewIdempotencyService(idempotencyRepo *repository.IdempotencyRepository, ttl time.Duration) *IdempotencyService {
+	return &IdempotencyService{
+		idempotencyRepo: idempotencyRepo,
+		ttl:             ttl,
+	}
+}
+
+// Begin занимает ключ за запросом и возвращает nil, если его нужно выполнить.
+// Для повтора того же запроса возвращает сохранённый ответ; ключ с другим телом или путём отклоняется
+func (s *IdempotencyService) Begin(ctx context.Context, caller, key, path string, body []byte) (*domain.IdempotencyRecord, error) {
+	ctx, span := tracing.Start(ctx, "IdempotencyService.Begin")
+	defer span.End()
+
+	if len(key) > maxIdempotencyKeyLength {
+		return nil, errors.ErrInvalidIdempotencyKey
+	}
+
+	now := time.Now().UTC()
+	record := &domain.IdempotencyRecord{
+		Caller:      caller,
+		Key:         key,
+		RequestHash: requestHash(path, body),
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(s.ttl),
+	}
+	reserved, err := s.idempotencyRepo.Reserve(ctx, record)
+	if err != nil {
+		return nil, err
+	}
+	if reserved {
+		return nil, nil
+	}
+
+	stored, err := s.idempotencyRepo.GetRecord(ctx, caller, key)
+	if err != nil {
+		return nil, err
+	}
+	// Запись могла быть удалена между Reserve и GetRecord: клиенту достаточно повторить запрос
+	if stored == nil {
+		return nil, errors.ErrIdempotencyInProgress
+	}
+	if stored.RequestHash != record.RequestHash {
+		return nil, errors.ErrIdempotencyKeyReused
+	}
+	if stored.StatusCode == 0 {
+		return nil, errors.ErrIdempotencyInProgress
+	}
+	logging.FromContext(ctx).Info("replaying idempotent response", "status", stored.StatusCode)
+	return stored, nil
+}
+
+func (s *IdempotencyService) Complete(ctx context.Context, caller, key string, statusCode int, body []byte) error {
+	ctx, span := tracing.Start(ctx, "IdempotencyService.Complete")
+	defer span.End()
+
+	return s.idempotencyRepo.Complete(ctx, caller, key, statusCode, body)
+}
+
+// Release вызывается, если запрос завершился ошибкой сервера: такой результат не сохраняется
+func (s *IdempotencyService) Release(ctx context.Context, caller, key string) error {
+	ctx, span := tracing.Start(ctx, "IdempotencyService.Release")
+	defer span.End()
+
+	return s.idempotencyRepo.Release(ctx, caller, key)
+}
+
+func (s *IdempotencyService) PurgeExpired(ctx context.Context) (int64, error) {
+	ctx, span := tracing.Start(ctx, "IdempotencyService.PurgeExpired")
+	defer span.End()
+
+	return s.idempotencyRepo.DeleteExpired(ctx, time.Now().UTC())
+}
+
+func requestHash(path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(path))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
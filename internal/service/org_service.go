@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"pr-review-manager/internal/domain"
+	"pr-review-manager/internal/errors"
+	"pr-review-manager/internal/logging"
+	"pr-review-manager/internal/repository"
+	"pr-review-manager/internal/tracing"
+)
+
+var orgIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,63}$`)
+
+// OrgService управляет организациями. Доступен только из CLI: через API вызывающий
+// не может выйти за пределы своей организации
+type OrgService struct {
+	orgRepo *repository.OrgRepository
+}
+
+func NewOrgService(orgRepo *repository.OrgRepository) *OrgService {
+	return &OrgService{orgRepo: orgRepo}
+}
+
+func (s *OrgService) CreateOrg(ctx context.Context, orgID, name string) (*domain.Organization, error) {
+	ctx, span := tracing.Start(ctx, "OrgService.CreateOrg")
+	defer span.End()
+
+	if !orgIDPattern.MatchString(orgID) {
+		return nil, errors.ErrInvalidOrgID
+	}
+
+	existing, err := s.orgRepo.GetOrg(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, errors.ErrOrgExists
+	}
+
+	if name == "" {
+		name = orgID
+	}
+	org := &domain.Organization{OrgID: orgID, Name: name, CreatedAt: time.Now().UTC()}
+	if err := s.orgRepo.CreateOrg(ctx, org); err != nil {
+		return nil, err
+	}
+	logging.FromContext(ctx).Info("organization created", "org_id", orgID)
+	return org, nil
+}
+
+func (s *OrgService) GetOrg(ctx context.Context, orgID string) (*domain.Organization, error) {
+	ctx, span := tracing.Start(ctx, "OrgService.GetOrg")
+	defer span.End()
+
+	org, err := s.orgRepo.GetOrg(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if org == nil {
+		return nil, errors.ErrNotFound
+	}
+	return org, nil
+}
+
+func (s *OrgService) ListOrgs(ctx context.Context) ([]domain.Organization, error) {
+	ctx, span := tracing.Start(ctx, "OrgService.ListOrgs")
+	defer span.End()
+
+	return s.orgRepo.ListOrgs(ctx)
+}
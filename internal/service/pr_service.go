@@ -3,25 +3,45 @@ package service
 import (
 	"context"
 	"math/rand"
+	"time"
 
 	"pr-review-manager/internal/domain"
 	"pr-review-manager/internal/errors"
+	"pr-review-manager/internal/logging"
+	"pr-review-manager/internal/metrics"
+	"pr-review-manager/internal/policy"
 	"pr-review-manager/internal/repository"
+	"pr-review-manager/internal/tracing"
 )
 
+// ReviewerSync получает изменения ревьюверов PR, чтобы отразить их во внешней системе.
+// Вызывается после сохранения изменений и не должен блокировать запрос
+type ReviewerSync interface {
+	ReviewersChanged(ctx context.Context, prID string, added, removed []string)
+}
+
 type PRService struct {
-	prRepo   *repository.PRRepository
-	userRepo *repository.UserRepository
+	prRepo       *repository.PRRepository
+	userRepo     *repository.UserRepository
+	reviewerSync ReviewerSync
+	events       EventPublisher
 }
 
-func NewPRService(prRepo *repository.PRRepository, userRepo *repository.UserRepository) *PRService {
+// NewPRService: reviewerSync и events могут быть nil, если синхронизация ревьюверов и вебхуки не настроены
+func NewPRService(prRepo *repository.PRRepository, userRepo *repository.UserRepository, reviewerSync ReviewerSync, events EventPublisher) *PRService {
 	return &PRService{
-		prRepo:   prRepo,
-		userRepo: userRepo,
+		prRepo:       prRepo,
+		userRepo:     userRepo,
+		reviewerSync: reviewerSync,
+		events:       events,
 	}
 }
 
 func (s *PRService) CreatePR(ctx context.Context, prID, prName, authorID string) (*domain.PullRequest, error) {
+	ctx, span := tracing.Start(ctx, "PRService.CreatePR")
+	defer span.End()
+	ctx = logging.With(ctx, "pr_id", prID, "author_id", authorID)
+
 	exists, err := s.prRepo.PRExists(ctx, prID)
 	if err != nil {
 		return nil, err
@@ -37,6 +57,9 @@ func (s *PRService) CreatePR(ctx context.Context, prID, prName, authorID string)
 	if author == nil {
 		return nil, errors.ErrNotFound
 	}
+	if err := policy.CanCreatePR(ctx, author); err != nil {
+		return nil, err
+	}
 
 	candidates, err := s.userRepo.GetActiveTeamMembers(ctx, author.TeamName, authorID)
 	if err != nil {
@@ -56,11 +79,53 @@ func (s *PRService) CreatePR(ctx context.Context, prID, prName, authorID string)
 	if err := s.prRepo.CreatePR(ctx, pr); err != nil {
 		return nil, err
 	}
+	logging.FromContext(ctx).Info("pull request created", "reviewers", reviewers)
+	s.reviewersChanged(ctx, prID, reviewers, nil)
 
-	return s.prRepo.GetPRWithoutTx(ctx, prID)
+	created, err := s.prRepo.GetPRWithoutTx(ctx, prID)
+	if err != nil {
+		return nil, err
+	}
+	publish(ctx, s.events, domain.EventPRCreated, map[string]any{"pr": created})
+	if len(reviewers) > 0 {
+		publish(ctx, s.events, domain.EventReviewerAssigned, domain.ReviewerAssignment{
+			PullRequestID: prID,
+			ReviewerIDs:   reviewers,
+			Source:        "create_pr",
+		})
+	}
+	return created, nil
+}
+
+func (s *PRService) GetPR(ctx context.Context, prID string) (*domain.PullRequestDetails, error) {
+	ctx, span := tracing.Start(ctx, "PRService.GetPR")
+	defer span.End()
+	ctx = logging.With(ctx, "pr_id", prID)
+
+	pr, err := s.prRepo.GetPRDetails(ctx, prID)
+	if err != nil {
+		return nil, err
+	}
+	if pr == nil {
+		return nil, errors.ErrNotFound
+	}
+
+	if pr.CreatedAt != nil {
+		pr.AgeSeconds = int64(time.Since(*pr.CreatedAt).Seconds())
+		if pr.MergedAt != nil {
+			timeToMerge := int64(pr.MergedAt.Sub(*pr.CreatedAt).Seconds())
+			pr.TimeToMergeSeconds = &timeToMerge
+		}
+	}
+
+	return pr, nil
 }
 
 func (s *PRService) MergePR(ctx context.Context, prID string) (*domain.PullRequest, error) {
+	ctx, span := tracing.Start(ctx, "PRService.MergePR")
+	defer span.End()
+	ctx = logging.With(ctx, "pr_id", prID)
+
 	pr, err := s.prRepo.GetPRWithoutTx(ctx, prID)
 	if err != nil {
 		return nil, err
@@ -69,14 +134,36 @@ func (s *PRService) MergePR(ctx context.Context, prID string) (*domain.PullReque
 		return nil, errors.ErrNotFound
 	}
 
+	author, err := s.userRepo.GetUser(ctx, pr.AuthorID)
+	if err != nil {
+		return nil, err
+	}
+	if author == nil {
+		return nil, errors.ErrNotFound
+	}
+	if err := policy.CanActOnPR(ctx, pr, author.TeamName); err != nil {
+		return nil, err
+	}
+
 	if pr.Status == domain.StatusMerged {
 		return pr, nil
 	}
 
-	return s.prRepo.MergePR(ctx, prID)
+	mergedBy := callerUserID(ctx)
+	merged, err := s.prRepo.MergePR(ctx, prID, mergedBy)
+	if err != nil {
+		return nil, err
+	}
+	logging.FromContext(ctx).Info("pull request merged", "merged_by", mergedBy)
+	publish(ctx, s.events, domain.EventPRMerged, map[string]any{"pr": merged})
+	return merged, nil
 }
 
 func (s *PRService) ReassignReviewer(ctx context.Context, prID, oldReviewerID string) (*domain.PullRequest, string, error) {
+	ctx, span := tracing.Start(ctx, "PRService.ReassignReviewer")
+	defer span.End()
+	ctx = logging.With(ctx, "pr_id", prID, "old_user_id", oldReviewerID)
+
 	pr, err := s.prRepo.GetPRWithoutTx(ctx, prID)
 	if err != nil {
 		return nil, "", err
@@ -107,6 +194,9 @@ func (s *PRService) ReassignReviewer(ctx context.Context, prID, oldReviewerID st
 	if oldReviewer == nil {
 		return nil, "", errors.ErrNotFound
 	}
+	if err := policy.CanReassign(ctx, pr, oldReviewer); err != nil {
+		return nil, "", err
+	}
 
 	candidates, err := s.userRepo.GetActiveTeamMembers(ctx, oldReviewer.TeamName, "")
 	if err != nil {
@@ -117,6 +207,8 @@ func (s *PRService) ReassignReviewer(ctx context.Context, prID, oldReviewerID st
 	filteredCandidates := filterUsers(candidates, excludeIDs)
 
 	if len(filteredCandidates) == 0 {
+		metrics.NoCandidateErrors.Inc()
+		logging.FromContext(ctx).Warn("no replacement candidate", "team_name", oldReviewer.TeamName)
 		return nil, "", errors.ErrNoCandidate
 	}
 
@@ -125,11 +217,70 @@ func (s *PRService) ReassignReviewer(ctx context.Context, prID, oldReviewerID st
 	if err := s.prRepo.ReassignReviewer(ctx, prID, oldReviewerID, newReviewer.UserID); err != nil {
 		return nil, "", err
 	}
+	metrics.Reassignments.WithLabelValues("manual").Inc()
+	logging.FromContext(ctx).Info("reviewer reassigned", "new_user_id", newReviewer.UserID)
+	s.reviewersChanged(ctx, prID, []string{newReviewer.UserID}, []string{oldReviewerID})
+	publish(ctx, s.events, domain.EventReviewerReassigned, domain.ReviewerReassignment{
+		PullRequestID: prID,
+		OldReviewerID: oldReviewerID,
+		NewReviewerID: newReviewer.UserID,
+	})
 
 	updatedPR, err := s.prRepo.GetPRWithoutTx(ctx, prID)
 	return updatedPR, newReviewer.UserID, err
 }
 
+// SubmitReview при вызове по bearer-токену отмечает ревью от имени вызывающего;
+// reviewerID из запроса может быть пустым, а чужой запрещён
+func (s *PRService) SubmitReview(ctx context.Context, prID, reviewerID string) (*domain.PullRequest, error) {
+	ctx, span := tracing.Start(ctx, "PRService.SubmitReview")
+	defer span.End()
+
+	if caller := callerUserID(ctx); caller != "" {
+		if reviewerID != "" && reviewerID != caller {
+			return nil, errors.ErrNotCaller
+		}
+		reviewerID = caller
+	}
+	ctx = logging.With(ctx, "pr_id", prID, "user_id", reviewerID)
+
+	pr, err := s.prRepo.GetPRWithoutTx(ctx, prID)
+	if err != nil {
+		return nil, err
+	}
+	if pr == nil {
+		return nil, errors.ErrNotFound
+	}
+
+	if pr.Status == domain.StatusMerged {
+		return nil, errors.ErrReviewMerged
+	}
+
+	isAssigned := false
+	for _, id := range pr.AssignedReviewers {
+		if id == reviewerID {
+			isAssigned = true
+			break
+		}
+	}
+	if !isAssigned {
+		return nil, errors.ErrNotAssigned
+	}
+
+	if err := s.prRepo.MarkReviewed(ctx, prID, reviewerID); err != nil {
+		return nil, err
+	}
+	logging.FromContext(ctx).Info("review submitted")
+
+	return pr, nil
+}
+
+func (s *PRService) reviewersChanged(ctx context.Context, prID string, added, removed []string) {
+	if s.reviewerSync != nil && (len(added) > 0 || len(removed) > 0) {
+		s.reviewerSync.ReviewersChanged(ctx, prID, added, removed)
+	}
+}
+
 func selectRandomReviewers(candidates []domain.User, maxCount int) []string {
 	if len(candidates) == 0 {
 		return []string{}
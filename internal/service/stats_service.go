@@ -2,21 +2,265 @@ package service
 
 import (
 	"context"
+	"math"
+	"sort"
+	"time"
 
 	"pr-review-manager/internal/domain"
+	"pr-review-manager/internal/errors"
+	"pr-review-manager/internal/policy"
 	"pr-review-manager/internal/repository"
+	"pr-review-manager/internal/tracing"
 )
 
+const (
+	defaultAgingOldestLimit = 10
+	maxAgingOldestLimit     = 100
+	defaultHistoryDays      = 30
+	maxSnapshotRangeDays    = 3660
+)
+
+// StatsService: giniThreshold — порог коэффициента Джини, выше которого команда считается перекошенной,
+// если запрос не задал свой
 type StatsService struct {
-	statsRepo *repository.StatsRepository
+	statsRepo     *repository.StatsRepository
+	giniThreshold float64
 }
 
-func NewStatsService(statsRepo *repository.StatsRepository) *StatsService {
+func NewStatsService(statsRepo *repository.StatsRepository, giniThreshold float64) *StatsService {
 	return &StatsService{
-		statsRepo: statsRepo,
+		statsRepo:     statsRepo,
+		giniThreshold: giniThreshold,
+	}
+}
+
+func (s *StatsService) GetStats(ctx context.Context, filter domain.StatsFilter) (*domain.Stats, error) {
+	ctx, span := tracing.Start(ctx, "StatsService.GetStats")
+	defer span.End()
+
+	return s.statsRepo.GetStats(ctx, filter)
+}
+
+func (s *StatsService) GetCycleTime(ctx context.Context, filter domain.StatsFilter) (*domain.CycleTimeStats, error) {
+	ctx, span := tracing.Start(ctx, "StatsService.GetCycleTime")
+	defer span.End()
+
+	return s.statsRepo.GetCycleTime(ctx, filter)
+}
+
+// GetFairness считает распределение нагрузки по командам; nil в giniThreshold — порог из конфигурации
+func (s *StatsService) GetFairness(ctx context.Context, teamName string, giniThreshold *float64) (*domain.FairnessReport, error) {
+	ctx, span := tracing.Start(ctx, "StatsService.GetFairness")
+	defer span.End()
+
+	threshold := s.giniThreshold
+	if giniThreshold != nil {
+		threshold = *giniThreshold
+	}
+
+	teams, err := s.statsRepo.GetTeamLoad(ctx, teamName)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range teams {
+		team := &teams[i]
+		open := make([]int, len(team.Members))
+		total := make([]int, len(team.Members))
+		for j, member := range team.Members {
+			open[j] = member.OpenAssigned
+			total[j] = member.TotalAssigned
+		}
+
+		team.ActiveMembers = len(team.Members)
+		team.OpenAssignments = loadDistribution(open)
+		team.TotalAssignments = loadDistribution(total)
+		team.Imbalanced = team.OpenAssignments.Gini > threshold || team.TotalAssignments.Gini > threshold
+	}
+
+	return &domain.FairnessReport{
+		GiniThreshold: threshold,
+		Teams:         teams,
+	}, nil
+}
+
+func loadDistribution(values []int) domain.LoadDistribution {
+	dist := domain.LoadDistribution{}
+	if len(values) == 0 {
+		return dist
+	}
+
+	sorted := make([]int, len(values))
+	copy(sorted, values)
+	sort.Ints(sorted)
+
+	n := float64(len(sorted))
+	sum := 0
+	weighted := 0
+	for i, v := range sorted {
+		sum += v
+		weighted += (i + 1) * v
+	}
+
+	dist.Min = sorted[0]
+	dist.Max = sorted[len(sorted)-1]
+	dist.Mean = float64(sum) / n
+
+	variance := 0.0
+	for _, v := range sorted {
+		variance += (float64(v) - dist.Mean) * (float64(v) - dist.Mean)
+	}
+	dist.StdDev = math.Sqrt(variance / n)
+
+	// G = 2·Σ(i·x_i) / (n·Σx) − (n+1)/n для значений, отсортированных по возрастанию
+	if sum > 0 {
+		dist.Gini = 2*float64(weighted)/(n*float64(sum)) - (n+1)/n
+	}
+
+	switch {
+	case dist.Max == 0:
+		ratio := 1.0
+		dist.MaxMinRatio = &ratio
+	case dist.Min > 0:
+		ratio := float64(dist.Max) / float64(dist.Min)
+		dist.MaxMinRatio = &ratio
+	}
+
+	return dist
+}
+
+func (s *StatsService) GetAging(ctx context.Context, teamName string, oldestLimit int) (*domain.AgingReport, error) {
+	ctx, span := tracing.Start(ctx, "StatsService.GetAging")
+	defer span.End()
+
+	if oldestLimit <= 0 {
+		oldestLimit = defaultAgingOldestLimit
+	}
+	oldestLimit = min(oldestLimit, maxAgingOldestLimit)
+
+	now := time.Now().UTC()
+	report, err := s.statsRepo.GetAging(ctx, now, teamName, oldestLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range report.Oldest {
+		report.Oldest[i].AgeSeconds = int64(now.Sub(report.Oldest[i].CreatedAt).Seconds())
+	}
+	return report, nil
+}
+
+// SnapshotDaily обновляет снимки за сегодня (промежуточные) и фиксирует вчерашние, если они ещё
+// не окончательные
+func (s *StatsService) SnapshotDaily(ctx context.Context, now time.Time) error {
+	ctx, span := tracing.Start(ctx, "StatsService.SnapshotDaily")
+	defer span.End()
+
+	today := truncateDay(now)
+	return s.statsRepo.SaveSnapshots(ctx, today.AddDate(0, 0, -1), today, today)
+}
+
+// BackfillSnapshots восстанавливает историю по created_at/merged_at.
+// По умолчанию — с даты первого PR по сегодняшний день; уже окончательные дни не меняются
+func (s *StatsService) BackfillSnapshots(ctx context.Context, from, to *time.Time) (int, error) {
+	ctx, span := tracing.Start(ctx, "StatsService.BackfillSnapshots")
+	defer span.End()
+
+	if err := policy.RequireAdmin(ctx); err != nil {
+		return 0, err
+	}
+
+	today := truncateDay(time.Now().UTC())
+	end := today
+	if to != nil {
+		end = truncateDay(*to)
+	}
+
+	var start time.Time
+	if from != nil {
+		start = truncateDay(*from)
+	} else {
+		firstPR, _, err := s.statsRepo.GetHistoryBounds(ctx)
+		if err != nil {
+			return 0, err
+		}
+		if firstPR == nil {
+			return 0, nil
+		}
+		start = truncateDay(*firstPR)
+	}
+
+	if start.After(end) {
+		return 0, nil
+	}
+	days := int(end.Sub(start).Hours()/24) + 1
+	if days > maxSnapshotRangeDays {
+		return 0, errors.ErrRangeTooWide
+	}
+
+	if err := s.statsRepo.SaveSnapshots(ctx, start, end, today); err != nil {
+		return 0, err
+	}
+	return days, nil
+}
+
+// EnsureHistory заполняет историю при первом запуске, когда снимков ещё нет
+func (s *StatsService) EnsureHistory(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "StatsService.EnsureHistory")
+	defer span.End()
+
+	firstPR, hasSnapshots, err := s.statsRepo.GetHistoryBounds(ctx)
+	if err != nil {
+		return err
+	}
+	if hasSnapshots || firstPR == nil {
+		return nil
+	}
+	_, err = s.BackfillSnapshots(ctx, firstPR, nil)
+	return err
+}
+
+func (s *StatsService) GetHistory(ctx context.Context, scope, scopeID string, from, to *time.Time) (*domain.StatsHistory, error) {
+	ctx, span := tracing.Start(ctx, "StatsService.GetHistory")
+	defer span.End()
+
+	end := truncateDay(time.Now().UTC())
+	if to != nil {
+		end = truncateDay(*to)
+	}
+	start := end.AddDate(0, 0, -defaultHistoryDays+1)
+	if from != nil {
+		start = truncateDay(*from)
+	}
+	if end.Sub(start).Hours()/24 > maxSnapshotRangeDays {
+		return nil, errors.ErrRangeTooWide
+	}
+
+	snapshots, err := s.statsRepo.GetSnapshots(ctx, scope, scopeID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	history := &domain.StatsHistory{
+		Scope:  scope,
+		Series: []domain.StatsSeries{},
+	}
+	for _, snapshot := range snapshots {
+		if len(history.Series) == 0 || history.Series[len(history.Series)-1].ScopeID != snapshot.ScopeID {
+			history.Series = append(history.Series, domain.StatsSeries{ScopeID: snapshot.ScopeID})
+		}
+		series := &history.Series[len(history.Series)-1]
+		series.Points = append(series.Points, domain.StatsPoint{
+			Date:      snapshot.Date.Format(time.DateOnly),
+			TotalPRs:  snapshot.TotalPRs,
+			OpenPRs:   snapshot.OpenPRs,
+			MergedPRs: snapshot.MergedPRs,
+		})
 	}
+	return history, nil
 }
 
-func (s *StatsService) GetStats(ctx context.Context) (*domain.Stats, error) {
-	return s.statsRepo.GetStats(ctx)
+func truncateDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
 }
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestLoadDistribution(t *testing.T) {
+	ratio := func(v float64) *float64 { return &v }
+	cases := []struct {
+		name   string
+		values []int
+		min    int
+		max    int
+		mean   float64
+		stdDev float64
+		gini   float64
+		ratio  *float64
+	}{
+		{"empty", nil, 0, 0, 0, 0, 0, nil},
+		{"single user", []int{3}, 3, 3, 3, 0, 0, ratio(1)},
+		{"single idle user", []int{0}, 0, 0, 0, 0, 0, ratio(1)},
+		{"all zero", []int{0, 0, 0}, 0, 0, 0, 0, 0, ratio(1)},
+		{"equal", []int{2, 2, 2, 2}, 2, 2, 2, 0, 0, ratio(1)},
+		{"unsorted", []int{3, 1}, 1, 3, 2, 1, 0.25, ratio(3)},
+		{"one does everything", []int{0, 4, 0, 0}, 0, 4, 1, math.Sqrt(3), 0.75, nil},
+	}
+
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+	for _, c := range cases {
+		input := slices.Clone(c.values)
+		dist := loadDistribution(input)
+
+		if dist.Min != c.min || dist.Max != c.max || !near(dist.Mean, c.mean) || !near(dist.StdDev, c.stdDev) || !near(dist.Gini, c.gini) {
+			t.Errorf("%s: unexpected distribution %+v", c.name, dist)
+		}
+		switch {
+		case c.ratio == nil && dist.MaxMinRatio != nil:
+			t.Errorf("%s: expected no max/min ratio, got %v", c.name, *dist.MaxMinRatio)
+		case c.ratio != nil && (dist.MaxMinRatio == nil || !near(*dist.MaxMinRatio, *c.ratio)):
+			t.Errorf("%s: expected max/min ratio %v, got %v", c.name, *c.ratio, dist.MaxMinRatio)
+		}
+		if !slices.Equal(input, c.values) {
+			t.Errorf("%s: input was modified: %v", c.name, input)
+		}
+	}
+}
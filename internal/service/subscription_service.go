@@ -0,0 +1,396 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"pr-review-manager/internal/auth"
+	"pr-review-manager/internal/domain"
+	"pr-review-manager/internal/errors"
+	"pr-review-manager/internal/logging"
+	"pr-review-manager/internal/metrics"
+	"pr-review-manager/internal/policy"
+	"pr-review-manager/internal/repository"
+	"pr-review-manager/internal/tracing"
+)
+
+const (
+	defaultDeliveriesPageSize = 50
+	maxDeliveriesPageSize     = 200
+
+	// deliveryBatchSize — сколько доставок отправляется параллельно за один проход
+	deliveryBatchSize = 20
+)
+
+// EventPublisher получает доменные события после сохранения изменений. Ошибка публикации
+// не отменяет само изменение, поэтому Publish ничего не возвращает
+type EventPublisher interface {
+	Publish(ctx context.Context, eventType string, data any)
+}
+
+// DeliveryOptions: после MaxAttempts неудачных попыток доставка помечается failed;
+// задержка перед повтором удваивается от RetryBackoff, но не больше MaxRetryBackoff.
+// AllowPrivateNetworks разрешает подписчиков на loopback, link-local и частных адресах
+type DeliveryOptions struct {
+	Timeout              time.Duration
+	MaxAttempts          int
+	RetryBackoff         time.Duration
+	MaxRetryBackoff      time.Duration
+	AllowPrivateNetworks bool
+}
+
+// sharedAddressSpace — 100.64.0.0/10 (CGNAT), его используют и внутренние сервисы облаков
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+type SubscriptionService struct {
+	subscriptionRepo *repository.SubscriptionRepository
+	opts             DeliveryOptions
+	client           *http.Client
+}
+
+func NewSubscriptionService(subscriptionRepo *repository.SubscriptionRepository, opts DeliveryOptions) *SubscriptionService {
+	return &SubscriptionService{
+		subscriptionRepo: subscriptionRepo,
+		opts:             opts,
+		client:           newDeliveryClient(opts),
+	}
+}
+
+// newDeliveryClient проверяет адрес при установке соединения, а не только при создании подписки:
+// DNS-имя подписчика может указывать на внутренний адрес или смениться позже. Прокси из окружения
+// не используется, иначе проверялся бы адрес прокси. Редиректы не выполняются, 3xx — неудачная доставка
+func newDeliveryClient(opts DeliveryOptions) *http.Client {
+	dialer := &net.Dialer{Timeout: opts.Timeout}
+	if !opts.AllowPrivateNetworks {
+		dialer.Control = func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip, err := netip.ParseAddr(host); err != nil || !isPublicAddr(ip) {
+				return fmt.Errorf("destination %s is not a public address", host)
+			}
+			return nil
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   opts.Timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func isPublicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsGlobalUnicast() && !ip.IsPrivate() && !sharedAddressSpace.Contains(ip)
+}
+
+// validTarget отсекает заведомо внутренние адреса сразу при создании подписки; имена хостов
+// проверяются при каждой доставке
+func (s *SubscriptionService) validTarget(target *url.URL) bool {
+	if (target.Scheme != "http" && target.Scheme != "https") || target.Hostname() == "" {
+		return false
+	}
+	if s.opts.AllowPrivateNetworks {
+		return true
+	}
+	host := strings.ToLower(strings.TrimSuffix(target.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return false
+	}
+	if ip, err := netip.ParseAddr(host); err == nil {
+		return isPublicAddr(ip)
+	}
+	return true
+}
+
+func (s *SubscriptionService) CreateSubscription(ctx context.Context, rawURL, secret string, eventTypes []string) (*domain.Subscription, error) {
+	ctx, span := tracing.Start(ctx, "SubscriptionService.CreateSubscription")
+	defer span.End()
+
+	if err := policy.RequireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	target, err := url.Parse(rawURL)
+	if err != nil || !s.validTarget(target) {
+		return nil, errors.ErrInvalidWebhookURL
+	}
+	if len(eventTypes) == 0 {
+		return nil, errors.ErrInvalidEventType
+	}
+	for _, eventType := range eventTypes {
+		if !slices.Contains(domain.EventTypes, eventType) {
+			return nil, errors.ErrInvalidEventType
+		}
+	}
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return nil, err
+	}
+	subscriptionID, err := randomToken(8)
+	if err != nil {
+		return nil, err
+	}
+
+	sub := domain.Subscription{
+		SubscriptionID: "sub_" + subscriptionID,
+		OrgID:          orgID,
+		URL:            rawURL,
+		Secret:         secret,
+		EventTypes:     slices.Compact(slices.Sorted(slices.Values(eventTypes))),
+		CreatedAt:      time.Now().UTC(),
+	}
+	if err := s.subscriptionRepo.CreateSubscription(ctx, &sub); err != nil {
+		return nil, err
+	}
+	logging.FromContext(ctx).Info("webhook subscription created", "subscription_id", sub.SubscriptionID, "event_types", sub.EventTypes)
+
+	return &sub, nil
+}
+
+func (s *SubscriptionService) ListSubscriptions(ctx context.Context) ([]domain.Subscription, error) {
+	ctx, span := tracing.Start(ctx, "SubscriptionService.ListSubscriptions")
+	defer span.End()
+
+	if err := policy.RequireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	return s.subscriptionRepo.ListSubscriptions(ctx)
+}
+
+func (s *SubscriptionService) DeleteSubscription(ctx context.Context, subscriptionID string) (*domain.Subscription, error) {
+	ctx, span := tracing.Start(ctx, "SubscriptionService.DeleteSubscription")
+	defer span.End()
+
+	if err := policy.RequireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	sub, err := s.subscriptionRepo.DeleteSubscription(ctx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if sub == nil {
+		return nil, errors.ErrNotFound
+	}
+	logging.FromContext(ctx).Info("webhook subscription deleted", "subscription_id", sub.SubscriptionID)
+	return sub, nil
+}
+
+func (s *SubscriptionService) ListDeliveries(ctx context.Context, subscriptionID, status string, limit int) ([]domain.Delivery, error) {
+	ctx, span := tracing.Start(ctx, "SubscriptionService.ListDeliveries")
+	defer span.End()
+
+	if err := policy.RequireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = defaultDeliveriesPageSize
+	}
+	limit = min(limit, maxDeliveriesPageSize)
+
+	return s.subscriptionRepo.ListDeliveries(ctx, subscriptionID, status, limit)
+}
+
+// Redeliver ставит событие доставки в очередь ещё раз, в том числе после успешной или окончательно неудачной отправки
+func (s *SubscriptionService) Redeliver(ctx context.Context, deliveryID int64) (*domain.Delivery, error) {
+	ctx, span := tracing.Start(ctx, "SubscriptionService.Redeliver")
+	defer span.End()
+
+	if err := policy.RequireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	delivery, err := s.subscriptionRepo.Redeliver(ctx, deliveryID, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	if delivery == nil {
+		return nil, errors.ErrNotFound
+	}
+	logging.FromContext(ctx).Info("webhook redelivery queued", "delivery_id", deliveryID, "new_delivery_id", delivery.DeliveryID)
+	return delivery, nil
+}
+
+// Publish ставит событие в очередь подписчикам организации вызывающего. Запись не отменяется
+// вместе с запросом: изменение уже сохранено, и событие не должно потеряться
+func (s *SubscriptionService) Publish(ctx context.Context, eventType string, data any) {
+	ctx, span := tracing.Start(context.WithoutCancel(ctx), "SubscriptionService.Publish")
+	defer span.End()
+	ctx = logging.With(ctx, "event_type", eventType)
+
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to publish event", "error", err)
+		return
+	}
+	eventID, err := randomToken(12)
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to publish event", "error", err)
+		return
+	}
+	event := domain.Event{
+		EventID:    "evt_" + eventID,
+		Type:       eventType,
+		OrgID:      orgID,
+		OccurredAt: time.Now().UTC(),
+		Data:       data,
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to publish event", "error", err)
+		return
+	}
+
+	queued, err := s.subscriptionRepo.EnqueueEvent(ctx, &event, payload)
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to publish event", "event_id", event.EventID, "error", err)
+		return
+	}
+	if queued > 0 {
+		logging.FromContext(ctx).Debug("event queued for webhooks", "event_id", event.EventID, "deliveries", queued)
+	}
+}
+
+// DeliverDue отправляет доставки, срок которых наступил, и возвращает их число.
+// Доставки всех организаций обрабатываются вместе
+func (s *SubscriptionService) DeliverDue(ctx context.Context) (int, error) {
+	ctx, span := tracing.Start(ctx, "SubscriptionService.DeliverDue")
+	defer span.End()
+
+	// Аренда с запасом покрывает таймаут запроса: раньше её конца доставку никто не заберёт повторно
+	now := time.Now().UTC()
+	deliveries, err := s.subscriptionRepo.ClaimDue(ctx, now, now.Add(2*s.opts.Timeout), deliveryBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.deliver(ctx, delivery)
+		}()
+	}
+	wg.Wait()
+
+	return len(deliveries), nil
+}
+
+// PurgeDeliveries удаляет из журнала завершённые доставки старше retention
+func (s *SubscriptionService) PurgeDeliveries(ctx context.Context, retention time.Duration) (int64, error) {
+	ctx, span := tracing.Start(ctx, "SubscriptionService.PurgeDeliveries")
+	defer span.End()
+
+	return s.subscriptionRepo.DeleteFinishedBefore(ctx, time.Now().UTC().Add(-retention))
+}
+
+func (s *SubscriptionService) deliver(ctx context.Context, delivery domain.Delivery) {
+	ctx = logging.With(ctx,
+		"org_id", delivery.OrgID,
+		"subscription_id", delivery.SubscriptionID,
+		"delivery_id", delivery.DeliveryID,
+		"event_type", delivery.EventType,
+	)
+
+	statusCode, err := s.send(ctx, delivery)
+	// Результат записывается, даже если задание уже останавливают
+	saveCtx := context.WithoutCancel(ctx)
+
+	if err == nil {
+		metrics.WebhookDeliveries.WithLabelValues("delivered").Inc()
+		if saveErr := s.subscriptionRepo.MarkDelivered(saveCtx, delivery.DeliveryID, statusCode, time.Now().UTC()); saveErr != nil {
+			logging.FromContext(ctx).Error("Failed to save webhook delivery", "error", saveErr)
+		}
+		return
+	}
+
+	var nextAttemptAt *time.Time
+	if delivery.Attempts < s.opts.MaxAttempts {
+		next := time.Now().UTC().Add(s.retryDelay(delivery.Attempts))
+		nextAttemptAt = &next
+		metrics.WebhookDeliveries.WithLabelValues("retry").Inc()
+		logging.FromContext(ctx).Warn("Webhook delivery failed, will retry", "attempt", delivery.Attempts, "next_attempt_at", next, "error", err)
+	} else {
+		metrics.WebhookDeliveries.WithLabelValues("failed").Inc()
+		logging.FromContext(ctx).Error("Webhook delivery failed", "attempts", delivery.Attempts, "error", err)
+	}
+	if saveErr := s.subscriptionRepo.MarkAttemptFailed(saveCtx, delivery.DeliveryID, statusCode, err.Error(), nextAttemptAt); saveErr != nil {
+		logging.FromContext(ctx).Error("Failed to save webhook delivery", "error", saveErr)
+	}
+}
+
+// send отправляет тело доставки с подписью HMAC-SHA256 секретом подписки; успех — любой ответ 2xx
+func (s *SubscriptionService) send(ctx context.Context, delivery domain.Delivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "pr-review-manager")
+	req.Header.Set("X-PR-Review-Event", delivery.EventType)
+	req.Header.Set("X-PR-Review-Delivery", strconv.FormatInt(delivery.DeliveryID, 10))
+	req.Header.Set("X-PR-Review-Signature-256", SignPayload(delivery.Secret, delivery.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("subscriber responded %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// retryDelay удваивает задержку после каждой неудачной попытки
+func (s *SubscriptionService) retryDelay(attempts int) time.Duration {
+	delay := s.opts.RetryBackoff
+	for i := 1; i < attempts && delay < s.opts.MaxRetryBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, s.opts.MaxRetryBackoff)
+}
+
+// SignPayload возвращает значение заголовка X-PR-Review-Signature-256: "sha256=" и HMAC тела в hex
+func SignPayload(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// publish не требует настроенной публикации: events может быть nil
+func publish(ctx context.Context, events EventPublisher, eventType string, data any) {
+	if events != nil {
+		events.Publish(ctx, eventType, data)
+	}
+}
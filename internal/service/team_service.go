@@ -3,26 +3,45 @@ package service
 import (
 	"context"
 
+	"go.opentelemetry.io/otel/attribute"
+
 	"pr-review-manager/internal/domain"
 	"pr-review-manager/internal/errors"
+	"pr-review-manager/internal/logging"
+	"pr-review-manager/internal/metrics"
+	"pr-review-manager/internal/policy"
 	"pr-review-manager/internal/repository"
+	"pr-review-manager/internal/tracing"
 )
 
 type TeamService struct {
-	teamRepo *repository.TeamRepository
-	userRepo *repository.UserRepository
-	prRepo   *repository.PRRepository
+	teamRepo     *repository.TeamRepository
+	userRepo     *repository.UserRepository
+	prRepo       *repository.PRRepository
+	reviewerSync ReviewerSync
+	events       EventPublisher
 }
 
-func NewTeamService(teamRepo *repository.TeamRepository, userRepo *repository.UserRepository, prRepo *repository.PRRepository) *TeamService {
+// NewTeamService: reviewerSync и events могут быть nil, если синхронизация ревьюверов и вебхуки не настроены
+func NewTeamService(teamRepo *repository.TeamRepository, userRepo *repository.UserRepository, prRepo *repository.PRRepository, reviewerSync ReviewerSync, events EventPublisher) *TeamService {
 	return &TeamService{
-		teamRepo: teamRepo,
-		userRepo: userRepo,
-		prRepo:   prRepo,
+		teamRepo:     teamRepo,
+		userRepo:     userRepo,
+		prRepo:       prRepo,
+		reviewerSync: reviewerSync,
+		events:       events,
 	}
 }
 
 func (s *TeamService) AddTeam(ctx context.Context, team *domain.Team) (*domain.Team, error) {
+	ctx, span := tracing.Start(ctx, "TeamService.AddTeam")
+	defer span.End()
+	ctx = logging.With(ctx, "team_name", team.TeamName)
+
+	if err := policy.RequireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
 	exists, err := s.teamRepo.TeamExists(ctx, team.TeamName)
 	if err != nil {
 		return nil, err
@@ -56,11 +75,15 @@ func (s *TeamService) AddTeam(ctx context.Context, team *domain.Team) (*domain.T
 	if err := tx.Commit(); err != nil {
 		return nil, err
 	}
+	logging.FromContext(ctx).Info("team created", "members", len(team.Members))
 
 	return s.teamRepo.GetTeam(ctx, team.TeamName)
 }
 
 func (s *TeamService) GetTeam(ctx context.Context, teamName string) (*domain.Team, error) {
+	ctx, span := tracing.Start(ctx, "TeamService.GetTeam")
+	defer span.End()
+
 	team, err := s.teamRepo.GetTeam(ctx, teamName)
 	if err != nil {
 		return nil, errors.ErrNotFound
@@ -71,6 +94,14 @@ func (s *TeamService) GetTeam(ctx context.Context, teamName string) (*domain.Tea
 // DeactivateTeam массово деактивирует команду и безопасно переназначает открытые PR
 // Все операции выполняются атомарно в одной транзакции
 func (s *TeamService) DeactivateTeam(ctx context.Context, teamName string) (int, int, error) {
+	ctx, span := tracing.Start(ctx, "TeamService.DeactivateTeam", attribute.String("team.name", teamName))
+	defer span.End()
+	ctx = logging.With(ctx, "team_name", teamName)
+
+	if err := policy.CanManageTeam(ctx, teamName); err != nil {
+		return 0, 0, err
+	}
+
 	tx, err := s.teamRepo.BeginTx(ctx)
 	if err != nil {
 		return 0, 0, err
@@ -83,6 +114,9 @@ func (s *TeamService) DeactivateTeam(ctx context.Context, teamName string) (int,
 	}
 
 	affectedPRs := 0
+	reassigned := 0
+	newReviewers := map[string][]string{}
+	removedReviewers := map[string][]string{}
 	if len(deactivatedUserIDs) > 0 {
 		prIDs, err := s.prRepo.GetOpenPRsWithDeactivatedReviewers(ctx, tx, deactivatedUserIDs)
 		if err != nil {
@@ -93,16 +127,19 @@ func (s *TeamService) DeactivateTeam(ctx context.Context, teamName string) (int,
 			if err := tx.Commit(); err != nil {
 				return 0, 0, err
 			}
+			logging.FromContext(ctx).Info("team deactivated", "user_ids", deactivatedUserIDs, "affected_prs", 0)
+			s.publishDeactivation(ctx, teamName, deactivatedUserIDs, 0, nil)
 			return len(deactivatedUserIDs), 0, nil
 		}
 
-		// Batch-удаление деактивированных ревьюверов
-		if err := s.prRepo.RemoveDeactivatedReviewersFromAllPRs(ctx, tx, deactivatedUserIDs); err != nil {
+		// Ревьюверы читаются до удаления, чтобы знать, кого снять с PR во внешней системе
+		prsMap, err := s.prRepo.GetPRsWithReviewers(ctx, tx, prIDs)
+		if err != nil {
 			return 0, 0, err
 		}
 
-		prsMap, err := s.prRepo.GetPRsWithReviewers(ctx, tx, prIDs)
-		if err != nil {
+		// Batch-удаление деактивированных ревьюверов
+		if err := s.prRepo.RemoveDeactivatedReviewersFromAllPRs(ctx, tx, deactivatedUserIDs); err != nil {
 			return 0, 0, err
 		}
 
@@ -125,7 +162,14 @@ func (s *TeamService) DeactivateTeam(ctx context.Context, teamName string) (int,
 				continue
 			}
 
-			currentReviewersCount := len(pr.AssignedReviewers)
+			currentReviewersCount := 0
+			for _, r := range pr.AssignedReviewers {
+				if deactivatedMap[r] {
+					removedReviewers[prID] = append(removedReviewers[prID], r)
+				} else {
+					currentReviewersCount++
+				}
+			}
 			needed := 2 - currentReviewersCount
 
 			if needed > 0 {
@@ -158,16 +202,58 @@ func (s *TeamService) DeactivateTeam(ctx context.Context, teamName string) (int,
 			batchAssignments := make([]struct{ PRID, UserID string }, len(assignments))
 			for i, a := range assignments {
 				batchAssignments[i] = struct{ PRID, UserID string }{PRID: a.PRID, UserID: a.UserID}
+				newReviewers[a.PRID] = append(newReviewers[a.PRID], a.UserID)
 			}
 			if err := s.prRepo.BatchAddReviewers(ctx, tx, batchAssignments); err != nil {
 				return 0, 0, err
 			}
+			reassigned = len(assignments)
 		}
 	}
 
 	if err := tx.Commit(); err != nil {
 		return 0, 0, err
 	}
+	metrics.Reassignments.WithLabelValues("team_deactivation").Add(float64(reassigned))
+	span.SetAttributes(
+		attribute.Int("team.deactivated_users", len(deactivatedUserIDs)),
+		attribute.Int("team.affected_prs", affectedPRs),
+	)
+	logging.FromContext(ctx).Info("team deactivated",
+		"user_ids", deactivatedUserIDs,
+		"affected_prs", affectedPRs,
+		"reassigned_reviewers", reassigned,
+	)
+	s.publishDeactivation(ctx, teamName, deactivatedUserIDs, affectedPRs, newReviewers)
+	if s.reviewerSync != nil {
+		for prID, removed := range removedReviewers {
+			s.reviewerSync.ReviewersChanged(ctx, prID, newReviewers[prID], removed)
+		}
+	}
 
 	return len(deactivatedUserIDs), affectedPRs, nil
 }
+
+// publishDeactivation сообщает подписчикам о деактивации и о ревьюверах, назначенных взамен деактивированных.
+// Повторная деактивация уже неактивной команды событий не создаёт
+func (s *TeamService) publishDeactivation(ctx context.Context, teamName string, userIDs []string, affectedPRs int, newReviewers map[string][]string) {
+	if len(userIDs) == 0 {
+		return
+	}
+
+	reassigned := 0
+	for prID, reviewerIDs := range newReviewers {
+		reassigned += len(reviewerIDs)
+		publish(ctx, s.events, domain.EventReviewerAssigned, domain.ReviewerAssignment{
+			PullRequestID: prID,
+			ReviewerIDs:   reviewerIDs,
+			Source:        "team_deactivation",
+		})
+	}
+	publish(ctx, s.events, domain.EventTeamDeactivated, domain.TeamDeactivation{
+		TeamName:            teamName,
+		DeactivatedUserIDs:  userIDs,
+		AffectedPRs:         affectedPRs,
+		ReassignedReviewers: reassigned,
+	})
+}
@@ -2,25 +2,57 @@ package service
 
 import (
 	"context"
+	"encoding/base64"
+	"slices"
+	"strings"
+	"time"
 
 	"pr-review-manager/internal/domain"
 	"pr-review-manager/internal/errors"
+	"pr-review-manager/internal/logging"
+	"pr-review-manager/internal/policy"
 	"pr-review-manager/internal/repository"
+	"pr-review-manager/internal/tracing"
+)
+
+const (
+	defaultUsersPageSize   = 50
+	maxUsersPageSize       = 500
+	defaultReviewsPageSize = 50
+	maxReviewsPageSize     = 200
 )
 
 type UserService struct {
 	userRepo *repository.UserRepository
 	prRepo   *repository.PRRepository
+	events   EventPublisher
 }
 
-func NewUserService(userRepo *repository.UserRepository, prRepo *repository.PRRepository) *UserService {
+// NewUserService: events может быть nil, если вебхуки не настроены
+func NewUserService(userRepo *repository.UserRepository, prRepo *repository.PRRepository, events EventPublisher) *UserService {
 	return &UserService{
 		userRepo: userRepo,
 		prRepo:   prRepo,
+		events:   events,
 	}
 }
 
 func (s *UserService) SetIsActive(ctx context.Context, userID string, isActive bool) (*domain.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.SetIsActive")
+	defer span.End()
+	ctx = logging.With(ctx, "user_id", userID)
+
+	target, err := s.userRepo.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if target == nil {
+		return nil, errors.ErrNotFound
+	}
+	if err := policy.CanManageTeam(ctx, target.TeamName); err != nil {
+		return nil, err
+	}
+
 	user, err := s.userRepo.SetIsActive(ctx, userID, isActive)
 	if err != nil {
 		return nil, err
@@ -28,16 +60,196 @@ func (s *UserService) SetIsActive(ctx context.Context, userID string, isActive b
 	if user == nil {
 		return nil, errors.ErrNotFound
 	}
+	logging.FromContext(ctx).Info("user activity changed", "is_active", isActive)
+	if target.IsActive != isActive {
+		publish(ctx, s.events, domain.EventUserActivityChanged, map[string]any{"user": user})
+	}
 	return user, nil
 }
 
-func (s *UserService) GetReview(ctx context.Context, userID string) ([]domain.PullRequestShort, error) {
-	prs, err := s.prRepo.GetPRsByReviewer(ctx, userID)
+// SetRole назначает роль; менять роли может только админ
+func (s *UserService) SetRole(ctx context.Context, userID, role string) (*domain.User, error) {
+	ctx, span := tracing.Start(ctx, "UserService.SetRole")
+	defer span.End()
+	ctx = logging.With(ctx, "user_id", userID)
+
+	if err := policy.RequireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if !slices.Contains(domain.Roles, role) {
+		return nil, errors.ErrInvalidRole
+	}
+
+	user, err := s.userRepo.SetRole(ctx, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.ErrNotFound
+	}
+	logging.FromContext(ctx).Info("user role changed", "role", role)
+	return user, nil
+}
+
+// LinkAccount привязывает логин внешней системы к пользователю, чтобы вебхуки могли его найти.
+// Как и активностью, привязками управляет админ или лид команды пользователя
+func (s *UserService) LinkAccount(ctx context.Context, userID, provider, login string) (*domain.UserAccount, error) {
+	ctx, span := tracing.Start(ctx, "UserService.LinkAccount")
+	defer span.End()
+	ctx = logging.With(ctx, "user_id", userID, "provider", provider)
+
+	if !slices.Contains(domain.Providers, provider) {
+		return nil, errors.ErrInvalidProvider
+	}
+
+	user, err := s.userRepo.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.ErrNotFound
+	}
+	if err := policy.CanManageTeam(ctx, user.TeamName); err != nil {
+		return nil, err
+	}
+
+	account := &domain.UserAccount{
+		Provider: provider,
+		Login:    strings.ToLower(login),
+		UserID:   userID,
+	}
+	if err := s.userRepo.LinkAccount(ctx, account); err != nil {
+		return nil, err
+	}
+	logging.FromContext(ctx).Info("user account linked", "login", account.Login)
+	return account, nil
+}
+
+// UnlinkAccount возвращает удалённую привязку
+func (s *UserService) UnlinkAccount(ctx context.Context, provider, login string) (*domain.UserAccount, error) {
+	ctx, span := tracing.Start(ctx, "UserService.UnlinkAccount")
+	defer span.End()
+	login = strings.ToLower(login)
+	ctx = logging.With(ctx, "provider", provider, "login", login)
+
+	user, err := s.userRepo.GetUserByAccount(ctx, provider, login)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errors.ErrNotFound
+	}
+	if err := policy.CanManageTeam(ctx, user.TeamName); err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.UnlinkAccount(ctx, provider, login); err != nil {
+		return nil, err
+	}
+	logging.FromContext(ctx).Info("user account unlinked", "user_id", user.UserID)
+	return &domain.UserAccount{Provider: provider, Login: login, UserID: user.UserID}, nil
+}
+
+// AccountLogins — логины пользователей у провайдера для исходящих вызовов; пользователи без привязки пропускаются
+func (s *UserService) AccountLogins(ctx context.Context, provider string, userIDs []string) (map[string]string, error) {
+	ctx, span := tracing.Start(ctx, "UserService.AccountLogins")
+	defer span.End()
+
+	return s.userRepo.GetAccountLogins(ctx, provider, userIDs)
+}
+
+func (s *UserService) GetReview(ctx context.Context, userID, status, cursor string, limit int) (*domain.ReviewPage, error) {
+	ctx, span := tracing.Start(ctx, "UserService.GetReview")
+	defer span.End()
+	ctx = logging.With(ctx, "user_id", userID)
+
+	filter := domain.ReviewFilter{
+		UserID: userID,
+		Status: status,
+		Limit:  limit,
+	}
+	if filter.Status == "" {
+		filter.Status = domain.StatusOpen
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultReviewsPageSize
+	}
+	filter.Limit = min(filter.Limit, maxReviewsPageSize)
+
+	if cursor != "" {
+		after, err := decodeReviewCursor(cursor)
+		if err != nil {
+			return nil, errors.ErrBadCursor
+		}
+		filter.After = after
+	}
+
+	// Запрашиваем на одну запись больше, чтобы понять, есть ли следующая страница
+	pageSize := filter.Limit
+	filter.Limit++
+
+	prs, err := s.prRepo.GetPRsByReviewer(ctx, filter)
 	if err != nil {
 		return nil, err
 	}
-	if prs == nil {
-		prs = []domain.PullRequestShort{}
+
+	page := &domain.ReviewPage{
+		UserID:       userID,
+		PullRequests: prs,
+	}
+	if len(prs) > pageSize {
+		page.PullRequests = prs[:pageSize]
+		page.NextCursor = encodeReviewCursor(page.PullRequests[pageSize-1])
+	}
+	if page.PullRequests == nil {
+		page.PullRequests = []domain.PullRequestShort{}
 	}
-	return prs, nil
+	return page, nil
+}
+
+func (s *UserService) ListUsers(ctx context.Context, filter domain.UserListFilter) (*domain.UserPage, error) {
+	ctx, span := tracing.Start(ctx, "UserService.ListUsers")
+	defer span.End()
+
+	if filter.Limit <= 0 {
+		filter.Limit = defaultUsersPageSize
+	}
+	filter.Limit = min(filter.Limit, maxUsersPageSize)
+	filter.Offset = max(filter.Offset, 0)
+
+	users, total, err := s.userRepo.ListUsers(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.UserPage{
+		Users:  users,
+		Total:  total,
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	}, nil
+}
+
+func encodeReviewCursor(pr domain.PullRequestShort) string {
+	raw := pr.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + pr.PullRequestID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeReviewCursor(cursor string) (*domain.ReviewCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	createdAtRaw, prID, found := strings.Cut(string(raw), "|")
+	if !found || prID == "" {
+		return nil, errors.ErrBadCursor
+	}
+
+	createdAt, err := time.Parse(time.RFC3339Nano, createdAtRaw)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.ReviewCursor{CreatedAt: createdAt, PullRequestID: prID}, nil
 }
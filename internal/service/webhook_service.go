@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"pr-review-manager/internal/auth"
+	"pr-review-manager/internal/domain"
+	"pr-review-manager/internal/errors"
+	"pr-review-manager/internal/logging"
+	"pr-review-manager/internal/metrics"
+	"pr-review-manager/internal/repository"
+	"pr-review-manager/internal/tracing"
+)
+
+// WebhookService применяет события PR из внешних систем через PRService.
+// Обработка идемпотентна: повторная доставка события не меняет результат
+type WebhookService struct {
+	prService *PRService
+	userRepo  *repository.UserRepository
+}
+
+func NewWebhookService(prService *PRService, userRepo *repository.UserRepository) *WebhookService {
+	return &WebhookService{
+		prService: prService,
+		userRepo:  userRepo,
+	}
+}
+
+// HandlePREvent не возвращает ошибку для событий, которые нельзя применить (автор не привязан,
+// ревьювер не назначен): провайдер не сможет их исправить повтором, поэтому они только игнорируются
+func (s *WebhookService) HandlePREvent(ctx context.Context, event domain.PREvent) (*domain.WebhookResult, error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.HandlePREvent")
+	defer span.End()
+	ctx = logging.With(ctx, "provider", event.Provider, "action", event.Action, "pr_id", event.PullRequestID)
+
+	var result *domain.WebhookResult
+	var err error
+	switch event.Action {
+	case domain.PREventOpened:
+		result, err = s.ensurePR(ctx, event)
+	case domain.PREventMerged:
+		result, err = s.merge(ctx, event)
+	case domain.PREventReviewed:
+		result, err = s.review(ctx, event)
+	default:
+		result = ignored(event, "action "+event.Action+" is not tracked")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	metrics.WebhookEvents.WithLabelValues(event.Provider, event.Action, result.Outcome).Inc()
+	logging.FromContext(ctx).Info("webhook event handled", "outcome", result.Outcome, "reason", result.Reason)
+	return result, nil
+}
+
+// ensurePR создаёт PR, если его ещё нет; существующий PR оставляется как есть
+func (s *WebhookService) ensurePR(ctx context.Context, event domain.PREvent) (*domain.WebhookResult, error) {
+	if event.AuthorLogin == "" {
+		return ignored(event, "author is unknown"), nil
+	}
+	author, err := s.resolveUser(ctx, event.Provider, event.AuthorLogin)
+	if err != nil {
+		return nil, err
+	}
+	if author == nil {
+		return ignored(event, "author "+event.AuthorLogin+" is not linked to a user"), nil
+	}
+
+	_, err = s.prService.CreatePR(ctx, event.PullRequestID, event.Title, author.UserID)
+	if err == errors.ErrPRExists {
+		return ignored(event, "pull request already exists"), nil
+	}
+	if err != nil {
+		return rejected(event, err)
+	}
+	return applied(event), nil
+}
+
+// merge создаёт PR, открытый до подключения вебхука, и мержит его от имени смержившего
+func (s *WebhookService) merge(ctx context.Context, event domain.PREvent) (*domain.WebhookResult, error) {
+	pr, err := s.prService.GetPR(ctx, event.PullRequestID)
+	switch {
+	case err == errors.ErrNotFound:
+		result, err := s.ensurePR(ctx, event)
+		if err != nil || result.Outcome == domain.WebhookIgnored {
+			return result, err
+		}
+	case err != nil:
+		return nil, err
+	case pr.Status == domain.StatusMerged:
+		return ignored(event, "pull request is already merged"), nil
+	}
+
+	actorCtx, err := s.asActor(ctx, event)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.prService.MergePR(actorCtx, event.PullRequestID); err != nil {
+		return rejected(event, err)
+	}
+	return applied(event), nil
+}
+
+func (s *WebhookService) review(ctx context.Context, event domain.PREvent) (*domain.WebhookResult, error) {
+	reviewer, err := s.resolveUser(ctx, event.Provider, event.ActorLogin)
+	if err != nil {
+		return nil, err
+	}
+	if reviewer == nil {
+		return ignored(event, "reviewer "+event.ActorLogin+" is not linked to a user"), nil
+	}
+
+	if _, err := s.prService.SubmitReview(ctx, event.PullRequestID, reviewer.UserID); err != nil {
+		return rejected(event, err)
+	}
+	return applied(event), nil
+}
+
+// asActor выполняет действие от имени привязанного пользователя, чтобы оно было ему атрибутировано.
+// Подпись вебхука уже проверена, поэтому ролевые ограничения к нему не применяются
+func (s *WebhookService) asActor(ctx context.Context, event domain.PREvent) (context.Context, error) {
+	orgID, err := auth.RequireOrg(ctx)
+	if err != nil {
+		return ctx, err
+	}
+	actor, err := s.resolveUser(ctx, event.Provider, event.ActorLogin)
+	if err != nil || actor == nil {
+		return ctx, err
+	}
+	return auth.WithPrincipal(ctx, &domain.Principal{
+		UserID:   actor.UserID,
+		OrgID:    orgID,
+		Role:     domain.RoleAdmin,
+		TeamName: actor.TeamName,
+		Scopes:   domain.RoleScopes(domain.RoleAdmin),
+	}), nil
+}
+
+func (s *WebhookService) resolveUser(ctx context.Context, provider, login string) (*domain.User, error) {
+	if login == "" {
+		return nil, nil
+	}
+	return s.userRepo.GetUserByAccount(ctx, provider, strings.ToLower(login))
+}
+
+func applied(event domain.PREvent) *domain.WebhookResult {
+	return &domain.WebhookResult{Outcome: domain.WebhookApplied, PullRequestID: event.PullRequestID}
+}
+
+func ignored(event domain.PREvent, reason string) *domain.WebhookResult {
+	return &domain.WebhookResult{Outcome: domain.WebhookIgnored, PullRequestID: event.PullRequestID, Reason: reason}
+}
+
+// rejected превращает доменную ошибку в пропущенное событие; прочие ошибки (БД) возвращаются,
+// чтобы провайдер показал неудачную доставку
+func rejected(event domain.PREvent, err error) (*domain.WebhookResult, error) {
+	if appErr, ok := err.(*errors.AppError); ok {
+		return ignored(event, appErr.Message), nil
+	}
+	return nil, err
+}
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_pr_reviewers_reviewed_at;
+ALTER TABLE pr_reviewers DROP COLUMN IF EXISTS reviewed_at;
+ALTER TABLE pr_reviewers DROP COLUMN IF EXISTS assigned_at;
@@ -0,0 +1,9 @@
+ALTER TABLE pr_reviewers ADD COLUMN assigned_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE pr_reviewers ADD COLUMN reviewed_at TIMESTAMP;
+
+UPDATE pr_reviewers prr
+SET assigned_at = pr.created_at
+FROM pull_requests pr
+WHERE prr.pull_request_id = pr.pull_request_id;
+
+CREATE INDEX idx_pr_reviewers_reviewed_at ON pr_reviewers(reviewed_at);
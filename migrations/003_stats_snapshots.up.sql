@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS stats_snapshots (
+    snapshot_date DATE NOT NULL,
+    scope VARCHAR(20) NOT NULL,
+    scope_id VARCHAR(255) NOT NULL DEFAULT '',
+    total_prs INTEGER NOT NULL DEFAULT 0,
+    open_prs INTEGER NOT NULL DEFAULT 0,
+    merged_prs INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (snapshot_date, scope, scope_id)
+);
+
+CREATE INDEX idx_stats_snapshots_scope ON stats_snapshots(scope, scope_id, snapshot_date);
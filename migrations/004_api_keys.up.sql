@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+    key_id VARCHAR(64) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    key_hash CHAR(64) NOT NULL UNIQUE,
+    scopes TEXT[] NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP
+);
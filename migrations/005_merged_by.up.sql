@@ -0,0 +1 @@
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS merged_by VARCHAR(255) REFERENCES users(user_id);
@@ -0,0 +1,47 @@
+-- Возврат к единому пространству имён возможен только для организации по умолчанию
+DELETE FROM api_keys WHERE org_id <> 'default';
+DELETE FROM stats_snapshots WHERE org_id <> 'default';
+DELETE FROM teams WHERE org_id <> 'default';
+
+DROP INDEX IF EXISTS idx_api_keys_org_id;
+DROP INDEX IF EXISTS idx_users_team_name;
+DROP INDEX IF EXISTS idx_pr_author_id;
+DROP INDEX IF EXISTS idx_pr_reviewers_user_id;
+DROP INDEX IF EXISTS idx_stats_snapshots_scope;
+
+ALTER TABLE pr_reviewers DROP CONSTRAINT pr_reviewers_pull_request_id_fkey;
+ALTER TABLE pr_reviewers DROP CONSTRAINT pr_reviewers_user_id_fkey;
+ALTER TABLE pull_requests DROP CONSTRAINT pull_requests_author_id_fkey;
+ALTER TABLE pull_requests DROP CONSTRAINT pull_requests_merged_by_fkey;
+ALTER TABLE users DROP CONSTRAINT users_team_name_fkey;
+
+ALTER TABLE stats_snapshots DROP CONSTRAINT stats_snapshots_pkey, ADD PRIMARY KEY (snapshot_date, scope, scope_id);
+ALTER TABLE pr_reviewers DROP CONSTRAINT pr_reviewers_pkey, ADD PRIMARY KEY (pull_request_id, user_id);
+ALTER TABLE pull_requests DROP CONSTRAINT pull_requests_pkey, ADD PRIMARY KEY (pull_request_id);
+ALTER TABLE users DROP CONSTRAINT users_pkey, ADD PRIMARY KEY (user_id);
+ALTER TABLE teams DROP CONSTRAINT teams_pkey, ADD PRIMARY KEY (team_name);
+
+ALTER TABLE api_keys DROP COLUMN org_id;
+ALTER TABLE stats_snapshots DROP COLUMN org_id;
+ALTER TABLE pr_reviewers DROP COLUMN org_id;
+ALTER TABLE pull_requests DROP COLUMN org_id;
+ALTER TABLE users DROP COLUMN org_id;
+ALTER TABLE teams DROP COLUMN org_id;
+
+ALTER TABLE users ADD CONSTRAINT users_team_name_fkey
+    FOREIGN KEY (team_name) REFERENCES teams(team_name) ON DELETE CASCADE;
+ALTER TABLE pull_requests ADD CONSTRAINT pull_requests_author_id_fkey
+    FOREIGN KEY (author_id) REFERENCES users(user_id) ON DELETE CASCADE;
+ALTER TABLE pull_requests ADD CONSTRAINT pull_requests_merged_by_fkey
+    FOREIGN KEY (merged_by) REFERENCES users(user_id);
+ALTER TABLE pr_reviewers ADD CONSTRAINT pr_reviewers_pull_request_id_fkey
+    FOREIGN KEY (pull_request_id) REFERENCES pull_requests(pull_request_id) ON DELETE CASCADE;
+ALTER TABLE pr_reviewers ADD CONSTRAINT pr_reviewers_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE;
+
+CREATE INDEX idx_users_team_name ON users(team_name);
+CREATE INDEX idx_pr_author_id ON pull_requests(author_id);
+CREATE INDEX idx_pr_reviewers_user_id ON pr_reviewers(user_id);
+CREATE INDEX idx_stats_snapshots_scope ON stats_snapshots(scope, scope_id, snapshot_date);
+
+DROP TABLE IF EXISTS organizations;
@@ -0,0 +1,57 @@
+CREATE TABLE IF NOT EXISTS organizations (
+    org_id VARCHAR(64) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Существующие данные переходят в организацию по умолчанию
+INSERT INTO organizations (org_id, name) VALUES ('default', 'Default') ON CONFLICT DO NOTHING;
+
+ALTER TABLE pr_reviewers DROP CONSTRAINT pr_reviewers_pull_request_id_fkey;
+ALTER TABLE pr_reviewers DROP CONSTRAINT pr_reviewers_user_id_fkey;
+ALTER TABLE pull_requests DROP CONSTRAINT pull_requests_author_id_fkey;
+ALTER TABLE pull_requests DROP CONSTRAINT pull_requests_merged_by_fkey;
+ALTER TABLE users DROP CONSTRAINT users_team_name_fkey;
+
+ALTER TABLE teams ADD COLUMN org_id VARCHAR(64) NOT NULL DEFAULT 'default' REFERENCES organizations(org_id);
+ALTER TABLE users ADD COLUMN org_id VARCHAR(64) NOT NULL DEFAULT 'default' REFERENCES organizations(org_id);
+ALTER TABLE pull_requests ADD COLUMN org_id VARCHAR(64) NOT NULL DEFAULT 'default' REFERENCES organizations(org_id);
+ALTER TABLE pr_reviewers ADD COLUMN org_id VARCHAR(64) NOT NULL DEFAULT 'default' REFERENCES organizations(org_id);
+ALTER TABLE stats_snapshots ADD COLUMN org_id VARCHAR(64) NOT NULL DEFAULT 'default' REFERENCES organizations(org_id);
+ALTER TABLE api_keys ADD COLUMN org_id VARCHAR(64) NOT NULL DEFAULT 'default' REFERENCES organizations(org_id);
+
+-- Без значения по умолчанию запрос, забывший про организацию, упадёт вместо записи в чужую
+ALTER TABLE teams ALTER COLUMN org_id DROP DEFAULT;
+ALTER TABLE users ALTER COLUMN org_id DROP DEFAULT;
+ALTER TABLE pull_requests ALTER COLUMN org_id DROP DEFAULT;
+ALTER TABLE pr_reviewers ALTER COLUMN org_id DROP DEFAULT;
+ALTER TABLE stats_snapshots ALTER COLUMN org_id DROP DEFAULT;
+ALTER TABLE api_keys ALTER COLUMN org_id DROP DEFAULT;
+
+ALTER TABLE teams DROP CONSTRAINT teams_pkey, ADD PRIMARY KEY (org_id, team_name);
+ALTER TABLE users DROP CONSTRAINT users_pkey, ADD PRIMARY KEY (org_id, user_id);
+ALTER TABLE pull_requests DROP CONSTRAINT pull_requests_pkey, ADD PRIMARY KEY (org_id, pull_request_id);
+ALTER TABLE pr_reviewers DROP CONSTRAINT pr_reviewers_pkey, ADD PRIMARY KEY (org_id, pull_request_id, user_id);
+ALTER TABLE stats_snapshots DROP CONSTRAINT stats_snapshots_pkey, ADD PRIMARY KEY (org_id, snapshot_date, scope, scope_id);
+
+ALTER TABLE users ADD CONSTRAINT users_team_name_fkey
+    FOREIGN KEY (org_id, team_name) REFERENCES teams(org_id, team_name) ON DELETE CASCADE;
+ALTER TABLE pull_requests ADD CONSTRAINT pull_requests_author_id_fkey
+    FOREIGN KEY (org_id, author_id) REFERENCES users(org_id, user_id) ON DELETE CASCADE;
+ALTER TABLE pull_requests ADD CONSTRAINT pull_requests_merged_by_fkey
+    FOREIGN KEY (org_id, merged_by) REFERENCES users(org_id, user_id);
+ALTER TABLE pr_reviewers ADD CONSTRAINT pr_reviewers_pull_request_id_fkey
+    FOREIGN KEY (org_id, pull_request_id) REFERENCES pull_requests(org_id, pull_request_id) ON DELETE CASCADE;
+ALTER TABLE pr_reviewers ADD CONSTRAINT pr_reviewers_user_id_fkey
+    FOREIGN KEY (org_id, user_id) REFERENCES users(org_id, user_id) ON DELETE CASCADE;
+
+DROP INDEX IF EXISTS idx_users_team_name;
+DROP INDEX IF EXISTS idx_pr_author_id;
+DROP INDEX IF EXISTS idx_pr_reviewers_user_id;
+DROP INDEX IF EXISTS idx_stats_snapshots_scope;
+
+CREATE INDEX idx_users_team_name ON users(org_id, team_name);
+CREATE INDEX idx_pr_author_id ON pull_requests(org_id, author_id);
+CREATE INDEX idx_pr_reviewers_user_id ON pr_reviewers(org_id, user_id);
+CREATE INDEX idx_stats_snapshots_scope ON stats_snapshots(org_id, scope, scope_id, snapshot_date);
+CREATE INDEX idx_api_keys_org_id ON api_keys(org_id);
@@ -0,0 +1,13 @@
+-- Учётные записи пользователей во внешних системах (логины GitHub), по которым вебхуки находят user_id.
+-- Логины хранятся в нижнем регистре
+CREATE TABLE IF NOT EXISTS user_accounts (
+    org_id VARCHAR(64) NOT NULL,
+    provider VARCHAR(20) NOT NULL,
+    login VARCHAR(255) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (org_id, provider, login),
+    FOREIGN KEY (org_id, user_id) REFERENCES users(org_id, user_id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_user_accounts_user_id ON user_accounts(org_id, user_id);
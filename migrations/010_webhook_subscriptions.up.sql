@@ -0,0 +1,33 @@
+-- Подписки на исходящие вебхуки. Секрет хранится открыто: им подписывается каждая доставка
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    org_id VARCHAR(64) NOT NULL REFERENCES organizations(org_id) ON DELETE CASCADE,
+    subscription_id VARCHAR(64) NOT NULL,
+    url TEXT NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    event_types TEXT[] NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (org_id, subscription_id)
+);
+
+-- Журнал доставок и очередь повторов: pending ждёт отправки в next_attempt_at,
+-- delivered и failed — итог. Тело хранится как есть, чтобы повторная доставка совпадала байт в байт
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    delivery_id BIGSERIAL PRIMARY KEY,
+    org_id VARCHAR(64) NOT NULL,
+    subscription_id VARCHAR(64) NOT NULL,
+    event_id VARCHAR(64) NOT NULL,
+    event_type VARCHAR(64) NOT NULL,
+    payload BYTEA NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP,
+    last_status_code INTEGER,
+    last_error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    delivered_at TIMESTAMP,
+    FOREIGN KEY (org_id, subscription_id) REFERENCES webhook_subscriptions(org_id, subscription_id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_webhook_deliveries_subscription ON webhook_deliveries(org_id, subscription_id, delivery_id);
+CREATE INDEX idx_webhook_deliveries_pending ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_webhook_deliveries_created_at ON webhook_deliveries(created_at);
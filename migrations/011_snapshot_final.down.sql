@@ -0,0 +1 @@
+ALTER TABLE stats_snapshots DROP COLUMN IF EXISTS final;
@@ -0,0 +1,4 @@
+-- Снимок за прошедший день окончательный и при повторном расчёте не перезаписывается
+ALTER TABLE stats_snapshots ADD COLUMN final BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE stats_snapshots SET final = snapshot_date < (CURRENT_TIMESTAMP AT TIME ZONE 'UTC')::date;
@@ -0,0 +1,32 @@
+DO $$
+DECLARE
+    tz TEXT := NULLIF(current_setting('app.legacy_timezone', true), '');
+BEGIN
+    IF tz IS NULL THEN
+        RETURN;
+    END IF;
+
+    UPDATE pull_requests SET
+        created_at = (created_at AT TIME ZONE 'UTC') AT TIME ZONE tz,
+        merged_at = (merged_at AT TIME ZONE 'UTC') AT TIME ZONE tz;
+    UPDATE pr_reviewers SET
+        assigned_at = (assigned_at AT TIME ZONE 'UTC') AT TIME ZONE tz,
+        reviewed_at = (reviewed_at AT TIME ZONE 'UTC') AT TIME ZONE tz;
+    UPDATE api_keys SET
+        created_at = (created_at AT TIME ZONE 'UTC') AT TIME ZONE tz,
+        last_used_at = (last_used_at AT TIME ZONE 'UTC') AT TIME ZONE tz,
+        revoked_at = (revoked_at AT TIME ZONE 'UTC') AT TIME ZONE tz;
+    UPDATE organizations SET
+        created_at = (created_at AT TIME ZONE 'UTC') AT TIME ZONE tz;
+    UPDATE idempotency_keys SET
+        created_at = (created_at AT TIME ZONE 'UTC') AT TIME ZONE tz,
+        expires_at = (expires_at AT TIME ZONE 'UTC') AT TIME ZONE tz;
+    UPDATE user_accounts SET
+        created_at = (created_at AT TIME ZONE 'UTC') AT TIME ZONE tz;
+    UPDATE webhook_subscriptions SET
+        created_at = (created_at AT TIME ZONE 'UTC') AT TIME ZONE tz;
+    UPDATE webhook_deliveries SET
+        next_attempt_at = (next_attempt_at AT TIME ZONE 'UTC') AT TIME ZONE tz,
+        created_at = (created_at AT TIME ZONE 'UTC') AT TIME ZONE tz,
+        delivered_at = (delivered_at AT TIME ZONE 'UTC') AT TIME ZONE tz;
+END $$;
@@ -0,0 +1,36 @@
+-- Раньше столбцы TIMESTAMP заполнялись локальным временем сервера, теперь приложение и сессии БД пишут UTC.
+-- Если сервер работал не в UTC, перед обновлением укажите его часовой пояс:
+--   ALTER DATABASE pr_review_db SET app.legacy_timezone = 'Europe/Moscow';
+-- Без этой настройки миграция данные не меняет
+DO $$
+DECLARE
+    tz TEXT := NULLIF(current_setting('app.legacy_timezone', true), '');
+BEGIN
+    IF tz IS NULL THEN
+        RETURN;
+    END IF;
+
+    UPDATE pull_requests SET
+        created_at = (created_at AT TIME ZONE tz) AT TIME ZONE 'UTC',
+        merged_at = (merged_at AT TIME ZONE tz) AT TIME ZONE 'UTC';
+    UPDATE pr_reviewers SET
+        assigned_at = (assigned_at AT TIME ZONE tz) AT TIME ZONE 'UTC',
+        reviewed_at = (reviewed_at AT TIME ZONE tz) AT TIME ZONE 'UTC';
+    UPDATE api_keys SET
+        created_at = (created_at AT TIME ZONE tz) AT TIME ZONE 'UTC',
+        last_used_at = (last_used_at AT TIME ZONE tz) AT TIME ZONE 'UTC',
+        revoked_at = (revoked_at AT TIME ZONE tz) AT TIME ZONE 'UTC';
+    UPDATE organizations SET
+        created_at = (created_at AT TIME ZONE tz) AT TIME ZONE 'UTC';
+    UPDATE idempotency_keys SET
+        created_at = (created_at AT TIME ZONE tz) AT TIME ZONE 'UTC',
+        expires_at = (expires_at AT TIME ZONE tz) AT TIME ZONE 'UTC';
+    UPDATE user_accounts SET
+        created_at = (created_at AT TIME ZONE tz) AT TIME ZONE 'UTC';
+    UPDATE webhook_subscriptions SET
+        created_at = (created_at AT TIME ZONE tz) AT TIME ZONE 'UTC';
+    UPDATE webhook_deliveries SET
+        next_attempt_at = (next_attempt_at AT TIME ZONE tz) AT TIME ZONE 'UTC',
+        created_at = (created_at AT TIME ZONE tz) AT TIME ZONE 'UTC',
+        delivered_at = (delivered_at AT TIME ZONE tz) AT TIME ZONE 'UTC';
+END $$;
@@ -3,8 +3,10 @@ package database
 import (
 	"database/sql"
 	"fmt"
-	"log"
-	"os"
+	"log/slog"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/golang-migrate/migrate/v4"
@@ -14,60 +16,55 @@ import (
 )
 
 type Config struct {
-	Host     string
-	Port     string
-	User     string
-	Password string
-	DBName   string
-	SSLMode  string
-}
-
-func LoadConfigFromEnv() Config {
-	return Config{
-		Host:     getEnv("DB_HOST", "localhost"),
-		Port:     getEnv("DB_PORT", "5432"),
-		User:     getEnv("DB_USER", "pruser"),
-		Password: getEnv("DB_PASSWORD", "prpass"),
-		DBName:   getEnv("DB_NAME", "pr_review_db"),
-		SSLMode:  getEnv("DB_SSLMODE", "disable"),
-	}
-}
+	Host     string `yaml:"host"`
+	Port     string `yaml:"port"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	DBName   string `yaml:"dbname"`
+	SSLMode  string `yaml:"sslmode"`
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
-	}
-	return defaultValue
+	MaxOpenConns         int           `yaml:"max_open_conns"`
+	MaxIdleConns         int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime      time.Duration `yaml:"conn_max_lifetime"`
+	ConnectRetries       int           `yaml:"connect_retries"`
+	ConnectRetryInterval time.Duration `yaml:"connect_retry_interval"`
+	MigrationsPath       string        `yaml:"migrations_path"`
 }
 
+// Connect открывает сессии в UTC: столбцы TIMESTAMP хранят время UTC, и значения по умолчанию
+// CURRENT_TIMESTAMP должны совпадать с временем, которое записывает приложение
 func Connect(cfg Config) (*sql.DB, error) {
 	dsn := fmt.Sprintf(
-		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s timezone=UTC",
 		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.DBName, cfg.SSLMode,
 	)
 
 	var db *sql.DB
 	var err error
 
-	for i := 0; i < 10; i++ {
+	attempts := max(cfg.ConnectRetries, 1)
+	for i := 0; i < attempts; i++ {
 		db, err = sql.Open("postgres", dsn)
 		if err == nil {
 			err = db.Ping()
 			if err == nil {
 				break
 			}
+			db.Close()
+		}
+		slog.Warn("Failed to connect to database", "attempt", i+1, "max_attempts", attempts, "error", err)
+		if i+1 < attempts {
+			time.Sleep(cfg.ConnectRetryInterval)
 		}
-		log.Printf("Failed to connect to database (attempt %d/10): %v", i+1, err)
-		time.Sleep(2 * time.Second)
 	}
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database after retries: %w", err)
 	}
 
-	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(5)
-	db.SetConnMaxLifetime(5 * time.Minute)
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
 
 	return db, nil
 }
@@ -91,6 +88,32 @@ func RunMigrations(db *sql.DB, migrationsPath string) error {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
 
-	log.Println("Migrations applied successfully")
+	slog.Info("Migrations applied successfully")
 	return nil
 }
+
+// LatestMigrationVersion возвращает номер последней up-миграции в каталоге (префикс NNN_ в имени файла)
+func LatestMigrationVersion(migrationsPath string) (uint, error) {
+	files, err := filepath.Glob(filepath.Join(migrationsPath, "*.up.sql"))
+	if err != nil {
+		return 0, err
+	}
+
+	var latest uint
+	for _, file := range files {
+		prefix, _, found := strings.Cut(filepath.Base(file), "_")
+		if !found {
+			continue
+		}
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			continue
+		}
+		latest = max(latest, uint(version))
+	}
+
+	if latest == 0 {
+		return 0, fmt.Errorf("no migrations found in %s", migrationsPath)
+	}
+	return latest, nil
+}
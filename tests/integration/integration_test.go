@@ -2,29 +2,132 @@ package integration
 
 import (
 	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"maps"
+	"math"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"pr-review-manager/internal/auth"
+	"pr-review-manager/internal/config"
 	"pr-review-manager/internal/domain"
+	apperrors "pr-review-manager/internal/errors"
+	"pr-review-manager/internal/github"
 	"pr-review-manager/internal/handler"
+	"pr-review-manager/internal/jobs"
+	"pr-review-manager/internal/logging"
+	"pr-review-manager/internal/policy"
+	"pr-review-manager/internal/ratelimit"
 	"pr-review-manager/internal/repository"
 	"pr-review-manager/internal/router"
+	"pr-review-manager/internal/server"
 	"pr-review-manager/internal/service"
 	"pr-review-manager/pkg/database"
 )
 
+const (
+	githubWebhookSecret = "github-test-secret"
+	gitlabWebhookToken  = "gitlab-test-token"
+)
+
 func setup() (http.Handler, func()) {
+	r, _, teardown := setupWithAuth(false, nil)
+	return r, teardown
+}
+
+func setupWithAuth(authEnabled bool, verifier *auth.JWTVerifier) (http.Handler, *service.APIKeyService, func()) {
+	db := connectTestDB()
+
+	_, _ = db.Exec("TRUNCATE TABLE pull_requests, users, teams, api_keys, idempotency_keys, user_accounts, webhook_subscriptions CASCADE")
+	_, _ = db.Exec("DELETE FROM stats_snapshots WHERE org_id <> 'default'")
+	_, _ = db.Exec("DELETE FROM organizations WHERE org_id <> 'default'")
+
+	teamRepo := repository.NewTeamRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	prRepo := repository.NewPRRepository(db)
+	statsRepo := repository.NewStatsRepository(db)
+	healthRepo := repository.NewHealthRepository(db)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	idempotencyRepo := repository.NewIdempotencyRepository(db)
+	subscriptionRepo := repository.NewSubscriptionRepository(db)
+
+	expectedVersion, err := database.LatestMigrationVersion("../../migrations")
+	if err != nil {
+		panic("failed to read migrations: " + err.Error())
+	}
+
+	// Доставку тесты запускают сами через свой SubscriptionService
+	subscriptionService := service.NewSubscriptionService(subscriptionRepo, service.DeliveryOptions{Timeout: time.Second, AllowPrivateNetworks: true})
+	teamService := service.NewTeamService(teamRepo, userRepo, prRepo, nil, subscriptionService)
+	userService := service.NewUserService(userRepo, prRepo, subscriptionService)
+	prService := service.NewPRService(prRepo, userRepo, nil, subscriptionService)
+	statsService := service.NewStatsService(statsRepo, config.Default().Stats.Fairness.GiniThreshold)
+	healthService := service.NewHealthService(healthRepo, expectedVersion, 2*time.Second)
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo)
+	authService := service.NewAuthService(apiKeyService, userRepo, verifier)
+	idempotencyService := service.NewIdempotencyService(idempotencyRepo, time.Hour)
+	webhookService := service.NewWebhookService(prService, userRepo)
+
+	teamHandler := handler.NewTeamHandler(teamService)
+	userHandler := handler.NewUserHandler(userService)
+	prHandler := handler.NewPRHandler(prService)
+	statsHandler := handler.NewStatsHandler(statsService)
+	healthHandler := handler.NewHealthHandler(healthService)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
+	subscriptionHandler := handler.NewSubscriptionHandler(subscriptionService)
+	authenticator := handler.NewAuthenticator(authService, authEnabled, "X-Forwarded-User", "X-Forwarded-Org")
+
+	rateLimiter := handler.NewRateLimiter(ratelimit.Config{})
+	idempotency := handler.NewIdempotency(idempotencyService)
+	githubWebhookHandler := handler.NewGitHubWebhookHandler(webhookService, githubWebhookSecret, domain.DefaultOrgID)
+	gitlabWebhookHandler := handler.NewGitLabWebhookHandler(webhookService, gitlabWebhookToken, domain.DefaultOrgID)
+
+	r := router.NewRouter(teamHandler, userHandler, prHandler, statsHandler, healthHandler, apiKeyHandler, authenticator, rateLimiter, idempotency, githubWebhookHandler, gitlabWebhookHandler, subscriptionHandler)
+
+	return r, apiKeyService, func() {
+		db.Close()
+	}
+}
+
+func connectTestDB() *sql.DB {
 	os.Setenv("DB_HOST", "localhost")
 	os.Setenv("DB_PORT", "5432")
 	os.Setenv("DB_USER", "pruser")
 	os.Setenv("DB_PASSWORD", "prpass")
 	os.Setenv("DB_NAME", "pr_review_db")
 
-	cfg := database.LoadConfigFromEnv()
-	db, err := database.Connect(cfg)
+	cfg, err := config.Load(nil, os.LookupEnv)
+	if err != nil {
+		panic("failed to load config: " + err.Error())
+	}
+	db, err := database.Connect(cfg.Database)
 	if err != nil {
 		panic("failed to connect to db: " + err.Error())
 	}
@@ -33,29 +136,85 @@ func setup() (http.Handler, func()) {
 	if err != nil {
 		panic("failed to run migrations: " + err.Error())
 	}
+	return db
+}
 
-	_, _ = db.Exec("TRUNCATE TABLE pull_requests, users, teams CASCADE")
+// testRouterOptions задаёт обработчики, которые нужны тесту без БД; остальные создаются с пустыми сервисами
+type testRouterOptions struct {
+	health        *handler.HealthHandler
+	authenticator *handler.Authenticator
+	rateLimiter   *handler.RateLimiter
+	github        *handler.GitHubWebhookHandler
+	gitlab        *handler.GitLabWebhookHandler
+	subscriptions *handler.SubscriptionHandler
+}
 
-	teamRepo := repository.NewTeamRepository(db)
-	userRepo := repository.NewUserRepository(db)
-	prRepo := repository.NewPRRepository(db)
-	statsRepo := repository.NewStatsRepository(db)
+func newTestRouter(opts testRouterOptions) http.Handler {
+	if opts.health == nil {
+		opts.health = handler.NewHealthHandler(nil)
+	}
+	if opts.authenticator == nil {
+		opts.authenticator = handler.NewAuthenticator(nil, false, "", "")
+	}
+	if opts.rateLimiter == nil {
+		opts.rateLimiter = handler.NewRateLimiter(ratelimit.Config{})
+	}
+	if opts.github == nil {
+		opts.github = handler.NewGitHubWebhookHandler(nil, "", "")
+	}
+	if opts.gitlab == nil {
+		opts.gitlab = handler.NewGitLabWebhookHandler(nil, "", "")
+	}
+	if opts.subscriptions == nil {
+		opts.subscriptions = handler.NewSubscriptionHandler(nil)
+	}
 
-	teamService := service.NewTeamService(teamRepo, userRepo, prRepo)
-	userService := service.NewUserService(userRepo, prRepo)
-	prService := service.NewPRService(prRepo, userRepo)
-	statsService := service.NewStatsService(statsRepo)
+	return router.NewRouter(
+		handler.NewTeamHandler(nil),
+		handler.NewUserHandler(nil),
+		handler.NewPRHandler(nil),
+		handler.NewStatsHandler(nil),
+		opts.health,
+		handler.NewAPIKeyHandler(nil),
+		opts.authenticator,
+		opts.rateLimiter,
+		handler.NewIdempotency(nil),
+		opts.github,
+		opts.gitlab,
+		opts.subscriptions,
+	)
+}
 
-	teamHandler := handler.NewTeamHandler(teamService)
-	userHandler := handler.NewUserHandler(userService)
-	prHandler := handler.NewPRHandler(prService)
-	statsHandler := handler.NewStatsHandler(statsService)
+// newJSONRequest собирает запрос с телом body в JSON (nil — без тела); заголовки с пустым значением пропускаются
+func newJSONRequest(method, path string, body interface{}, headers ...map[string]string) *http.Request {
+	var reader io.Reader
+	if body != nil {
+		payload, _ := json.Marshal(body)
+		reader = bytes.NewReader(payload)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	for _, h := range headers {
+		for k, v := range h {
+			if v != "" {
+				req.Header.Set(k, v)
+			}
+		}
+	}
+	return req
+}
+
+func doRequest(r http.Handler, method, path string, body interface{}, headers ...map[string]string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newJSONRequest(method, path, body, headers...))
+	return w
+}
 
-	r := router.NewRouter(teamHandler, userHandler, prHandler, statsHandler)
+func apiKey(key string) map[string]string {
+	return map[string]string{"X-API-Key": key}
+}
 
-	return r, func() {
-		db.Close()
-	}
+func idempotencyKey(key string) map[string]string {
+	return map[string]string{"Idempotency-Key": key}
 }
 
 func TestTeamAndPRFlow(t *testing.T) {
@@ -157,3 +316,2324 @@ func TestDeactivateTeam(t *testing.T) {
 		t.Errorf("Expected 2 deactivated users, got %d", resp["deactivated_users_count"])
 	}
 }
+
+func TestListUsers(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	r, teardown := setup()
+	defer teardown()
+
+	for _, team := range []domain.Team{
+		{TeamName: "Core", Members: []domain.TeamMember{
+			{UserID: "c1", Username: "Alpha", IsActive: true},
+			{UserID: "c2", Username: "Bravo", IsActive: true},
+			{UserID: "c3", Username: "Charlie", IsActive: true},
+			{UserID: "c4", Username: "Delta", IsActive: false},
+		}},
+		{TeamName: "Edge", Members: []domain.TeamMember{{UserID: "e1", Username: "Echo", IsActive: true}}},
+	} {
+		if w := doRequest(r, "POST", "/team/add", team); w.Code != http.StatusCreated {
+			t.Fatalf("Expected team %s to be created, got %d", team.TeamName, w.Code)
+		}
+	}
+	// c2 и c3 ревьюят оба PR, один из которых смержен: у каждого 1 открытое и 2 всего
+	doRequest(r, "POST", "/pullRequest/create", map[string]string{"pull_request_id": "lu-1", "pull_request_name": "Open", "author_id": "c1"})
+	doRequest(r, "POST", "/pullRequest/create", map[string]string{"pull_request_id": "lu-2", "pull_request_name": "Merged", "author_id": "c1"})
+	doRequest(r, "POST", "/pullRequest/merge", map[string]string{"pull_request_id": "lu-2"})
+
+	list := func(query string) domain.UserPage {
+		t.Helper()
+		w := doRequest(r, "GET", "/users/list?"+query, nil)
+		var page domain.UserPage
+		if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), &page) != nil {
+			t.Fatalf("Expected users for %q, got %d: %s", query, w.Code, w.Body.String())
+		}
+		return page
+	}
+	ids := func(page domain.UserPage) []string {
+		result := []string{}
+		for _, user := range page.Users {
+			result = append(result, user.UserID)
+		}
+		return result
+	}
+
+	cases := []struct {
+		query string
+		total int
+		want  []string
+	}{
+		{"", 5, []string{"c1", "c4", "e1", "c2", "c3"}},
+		{"team_name=Core", 4, []string{"c1", "c4", "c2", "c3"}},
+		{"team_name=Core&is_active=false", 1, []string{"c4"}},
+		{"team_name=Core&min_open_reviews=1", 2, []string{"c2", "c3"}},
+		{"team_name=Core&is_active=true&max_open_reviews=0", 1, []string{"c1"}},
+		{"min_open_reviews=1&max_open_reviews=1", 2, []string{"c2", "c3"}},
+		{"team_name=Core&limit=2&offset=1", 4, []string{"c4", "c2"}},
+		{"team_name=Core&offset=10", 4, []string{}},
+	}
+	for _, c := range cases {
+		page := list(c.query)
+		if page.Total != c.total || !slices.Equal(ids(page), c.want) {
+			t.Errorf("%q: expected %d total and %v, got %d and %v", c.query, c.total, c.want, page.Total, ids(page))
+		}
+	}
+
+	if page := list("team_name=Core&min_open_reviews=1"); len(page.Users) == 2 && (page.Users[0].OpenReviews != 1 || page.Users[0].TotalReviews != 2) {
+		t.Errorf("Expected one open and two total reviews, got %+v", page.Users[0])
+	}
+	if w := doRequest(r, "GET", "/users/list?min_open_reviews=2&max_open_reviews=1", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected min above max to be rejected, got %d", w.Code)
+	}
+}
+
+func TestGetReviewPaging(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	r, teardown := setup()
+	defer teardown()
+	db := connectTestDB()
+	defer db.Close()
+
+	team := domain.Team{TeamName: "Paging", Members: []domain.TeamMember{
+		{UserID: "rv1", Username: "Author", IsActive: true},
+		{UserID: "rv2", Username: "Reviewer", IsActive: true},
+	}}
+	if w := doRequest(r, "POST", "/team/add", team); w.Code != http.StatusCreated {
+		t.Fatalf("Expected team to be created, got %d", w.Code)
+	}
+	for _, id := range []string{"rp-1", "rp-2", "rp-3", "rp-4", "rp-5"} {
+		if w := doRequest(r, "POST", "/pullRequest/create", map[string]string{"pull_request_id": id, "pull_request_name": id, "author_id": "rv1"}); w.Code != http.StatusCreated {
+			t.Fatalf("Expected %s to be created, got %d", id, w.Code)
+		}
+	}
+	doRequest(r, "POST", "/pullRequest/merge", map[string]string{"pull_request_id": "rp-5"})
+
+	// У открытых PR одинаковый created_at: порядок страниц держится только на pull_request_id
+	if _, err := db.Exec("UPDATE pull_requests SET created_at = '2025-01-01 10:00:00+00' WHERE pull_request_id IN ('rp-1', 'rp-2', 'rp-3', 'rp-4')"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("UPDATE pull_requests SET created_at = '2025-01-02 10:00:00+00' WHERE pull_request_id = 'rp-5'"); err != nil {
+		t.Fatal(err)
+	}
+
+	getPage := func(query string) domain.ReviewPage {
+		t.Helper()
+		w := doRequest(r, "GET", "/users/getReview?user_id=rv2&"+query, nil)
+		var page domain.ReviewPage
+		if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), &page) != nil {
+			t.Fatalf("Expected review page for %q, got %d: %s", query, w.Code, w.Body.String())
+		}
+		return page
+	}
+	// walk проходит все страницы и возвращает PR в порядке выдачи
+	walk := func(query string) []string {
+		t.Helper()
+		ids := []string{}
+		cursor := ""
+		for range 10 {
+			page := getPage(query + "&cursor=" + url.QueryEscape(cursor))
+			for _, pr := range page.PullRequests {
+				ids = append(ids, pr.PullRequestID)
+			}
+			if page.NextCursor == "" {
+				return ids
+			}
+			cursor = page.NextCursor
+		}
+		t.Fatalf("Paging %q did not finish: %v", query, ids)
+		return nil
+	}
+
+	cases := []struct {
+		query string
+		want  []string
+	}{
+		{"limit=1", []string{"rp-4", "rp-3", "rp-2", "rp-1"}},
+		{"limit=3", []string{"rp-4", "rp-3", "rp-2", "rp-1"}},
+		{"status=OPEN&limit=4", []string{"rp-4", "rp-3", "rp-2", "rp-1"}},
+		{"status=MERGED&limit=1", []string{"rp-5"}},
+		{"status=ALL&limit=2", []string{"rp-5", "rp-4", "rp-3", "rp-2", "rp-1"}},
+	}
+	for _, c := range cases {
+		if got := walk(c.query); !slices.Equal(got, c.want) {
+			t.Errorf("%q: expected %v, got %v", c.query, c.want, got)
+		}
+	}
+
+	if page := getPage("limit=4"); page.NextCursor != "" {
+		t.Errorf("Expected no next_cursor on the last full page, got %q", page.NextCursor)
+	}
+	if page := getPage("limit=2"); len(page.PullRequests) != 2 || page.PullRequests[0].AuthorUsername != "Author" {
+		t.Errorf("Unexpected first page: %+v", page.PullRequests)
+	}
+
+	encode := func(raw string) string { return base64.RawURLEncoding.EncodeToString([]byte(raw)) }
+	for _, cursor := range []string{
+		"not base64!",
+		encode("2025-01-01T10:00:00Z"),
+		encode("2025-01-01T10:00:00Z|"),
+		encode("yesterday|rp-2"),
+	} {
+		w := doRequest(r, "GET", "/users/getReview?user_id=rv2&cursor="+url.QueryEscape(cursor), nil)
+		var resp struct {
+			Error struct{ Code string } `json:"error"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if w.Code != http.StatusBadRequest || resp.Error.Code != apperrors.ErrBadCursor.Code {
+			t.Errorf("Expected cursor %q to be rejected with %s, got %d: %s", cursor, apperrors.ErrBadCursor.Code, w.Code, w.Body.String())
+		}
+	}
+
+	if w := doRequest(r, "GET", "/users/getReview?user_id=rv2&status=CLOSED", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected unknown status to be rejected, got %d", w.Code)
+	}
+}
+
+func TestGetPRDetails(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	r, teardown := setup()
+	defer teardown()
+
+	teamPayload := domain.Team{
+		TeamName: "Platform",
+		Members: []domain.TeamMember{
+			{UserID: "p1", Username: "Pavel", IsActive: true},
+			{UserID: "p2", Username: "Polina", IsActive: true},
+		},
+	}
+	body, _ := json.Marshal(teamPayload)
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/team/add", bytes.NewBuffer(body)))
+
+	// Время пишется в UTC независимо от часового пояса процесса
+	local := time.Local
+	time.Local = time.FixedZone("UTC+5", 5*60*60)
+	defer func() { time.Local = local }()
+
+	prPayload := map[string]string{
+		"pull_request_id":   "pr-201",
+		"pull_request_name": "Add caching",
+		"author_id":         "p1",
+	}
+	body, _ = json.Marshal(prPayload)
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/pullRequest/create", bytes.NewBuffer(body)))
+
+	req := httptest.NewRequest("GET", "/pullRequest/get?pull_request_id=pr-201", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		PR domain.PullRequestDetails `json:"pr"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+
+	if resp.PR.Author.Username != "Pavel" || resp.PR.Author.TeamName != "Platform" {
+		t.Errorf("Unexpected author: %+v", resp.PR.Author)
+	}
+	if len(resp.PR.AssignedReviewers) != 1 || resp.PR.AssignedReviewers[0].Username != "Polina" {
+		t.Errorf("Unexpected reviewers: %+v", resp.PR.AssignedReviewers)
+	}
+	if resp.PR.TimeToMergeSeconds != nil {
+		t.Error("Open PR should not have time_to_merge_seconds")
+	}
+	if resp.PR.AgeSeconds < 0 || resp.PR.AgeSeconds > 60 || resp.PR.CreatedAt == nil || time.Since(*resp.PR.CreatedAt).Abs() > time.Minute {
+		t.Errorf("Expected created_at to be now in UTC, got %v (age %ds)", resp.PR.CreatedAt, resp.PR.AgeSeconds)
+	}
+
+	req = httptest.NewRequest("GET", "/pullRequest/get?pull_request_id=missing", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+}
+
+func TestStatsReports(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	r, teardown := setup()
+	defer teardown()
+
+	teamPayload := domain.Team{
+		TeamName: "Mobile",
+		Members: []domain.TeamMember{
+			{UserID: "m1", Username: "Misha", IsActive: true},
+			{UserID: "m2", Username: "Masha", IsActive: true},
+			{UserID: "m3", Username: "Marat", IsActive: true},
+		},
+	}
+	body, _ := json.Marshal(teamPayload)
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/team/add", bytes.NewBuffer(body)))
+
+	prPayload := map[string]string{
+		"pull_request_id":   "pr-301",
+		"pull_request_name": "Dark mode",
+		"author_id":         "m1",
+	}
+	body, _ = json.Marshal(prPayload)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/pullRequest/create", bytes.NewBuffer(body)))
+
+	var created struct {
+		PR domain.PullRequest `json:"pr"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if len(created.PR.AssignedReviewers) == 0 {
+		t.Fatal("Expected reviewers to be assigned")
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/stats/aging?team_name=Mobile", nil))
+	var aging domain.AgingReport
+	json.Unmarshal(w.Body.Bytes(), &aging)
+	if aging.Buckets.LessThan1Day != 1 || len(aging.Oldest) != 1 {
+		t.Errorf("Expected one fresh open PR, got %+v", aging)
+	}
+}
+
+func TestStatsWindow(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	r, teardown := setup()
+	defer teardown()
+	db := connectTestDB()
+	defer db.Close()
+
+	for _, team := range []domain.Team{
+		{TeamName: "Window", Members: []domain.TeamMember{
+			{UserID: "w1", Username: "Author", IsActive: true},
+			{UserID: "w2", Username: "First", IsActive: true},
+			{UserID: "w3", Username: "Second", IsActive: true},
+		}},
+		{TeamName: "Outside", Members: []domain.TeamMember{
+			{UserID: "o1", Username: "OtherAuthor", IsActive: true},
+			{UserID: "o2", Username: "OtherReviewer", IsActive: true},
+		}},
+	} {
+		if w := doRequest(r, "POST", "/team/add", team); w.Code != http.StatusCreated {
+			t.Fatalf("Expected team %s to be created, got %d", team.TeamName, w.Code)
+		}
+	}
+	for id, author := range map[string]string{"win-1": "w1", "win-2": "w1", "win-3": "w1", "win-4": "w1", "out-1": "o1"} {
+		if w := doRequest(r, "POST", "/pullRequest/create", map[string]string{"pull_request_id": id, "pull_request_name": id, "author_id": author}); w.Code != http.StatusCreated {
+			t.Fatalf("Expected %s to be created, got %d", id, w.Code)
+		}
+	}
+	for _, id := range []string{"win-3", "win-4"} {
+		doRequest(r, "POST", "/pullRequest/merge", map[string]string{"pull_request_id": id})
+	}
+
+	// win-4 создан до всех окон, но смержен в марте; win-2 создан ровно на границе 10 февраля
+	for _, query := range []string{
+		"UPDATE pull_requests SET created_at = '2025-01-10 12:00:00' WHERE pull_request_id = 'win-1'",
+		"UPDATE pull_requests SET created_at = '2025-02-10 00:00:00' WHERE pull_request_id = 'win-2'",
+		"UPDATE pull_requests SET created_at = '2025-02-15 12:00:00', merged_at = '2025-02-20 12:00:00' WHERE pull_request_id = 'win-3'",
+		"UPDATE pull_requests SET created_at = '2024-12-20 12:00:00', merged_at = '2025-03-05 12:00:00' WHERE pull_request_id = 'win-4'",
+		"UPDATE pull_requests SET created_at = '2025-02-11 12:00:00' WHERE pull_request_id = 'out-1'",
+	} {
+		if _, err := db.Exec(query); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	type expected struct {
+		total, open, merged int
+		assigned            map[string]int
+	}
+	for _, c := range []struct {
+		query string
+		want  expected
+	}{
+		{"team_name=Window", expected{4, 2, 2, map[string]int{"w2": 4, "w3": 4}}},
+		{"from=2025-02-01&to=2025-03-01&team_name=Window", expected{2, 1, 1, map[string]int{"w2": 2, "w3": 2}}},
+		{"from=2025-02-01&to=2025-03-01", expected{3, 2, 1, map[string]int{"w2": 2, "w3": 2, "o2": 1}}},
+		{"from=2025-01-01&to=2025-02-10&team_name=Window", expected{1, 1, 0, map[string]int{"w2": 1, "w3": 1}}},
+		{"from=2025-03-01T00:00:00Z&team_name=Window", expected{1, 0, 1, map[string]int{"w2": 1, "w3": 1}}},
+		{"to=2024-12-01", expected{0, 0, 0, map[string]int{}}},
+	} {
+		w := doRequest(r, "GET", "/stats?"+c.query, nil)
+		var stats domain.Stats
+		if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), &stats) != nil {
+			t.Fatalf("%s: expected stats, got %d: %s", c.query, w.Code, w.Body.String())
+		}
+		if stats.TotalPRs != c.want.total || stats.OpenPRs != c.want.open || stats.MergedPRs != c.want.merged || len(stats.PRStats) != c.want.total {
+			t.Errorf("%s: expected %d PRs (%d open, %d merged), got %+v", c.query, c.want.total, c.want.open, c.want.merged, stats)
+		}
+		assigned := map[string]int{}
+		for _, reviewer := range stats.ReviewerStats {
+			assigned[reviewer.UserID] = reviewer.TotalAssigned
+		}
+		if !maps.Equal(assigned, c.want.assigned) {
+			t.Errorf("%s: expected reviewer assignments %v, got %v", c.query, c.want.assigned, assigned)
+		}
+	}
+}
+
+func TestCycleTimeStats(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	r, teardown := setup()
+	defer teardown()
+	db := connectTestDB()
+	defer db.Close()
+
+	team := domain.Team{TeamName: "Cycle", Members: []domain.TeamMember{
+		{UserID: "ct1", Username: "Author", IsActive: true},
+		{UserID: "ct2", Username: "Fast", IsActive: true},
+		{UserID: "ct3", Username: "Slow", IsActive: true},
+	}}
+	if w := doRequest(r, "POST", "/team/add", team); w.Code != http.StatusCreated {
+		t.Fatalf("Expected team to be created, got %d", w.Code)
+	}
+	for _, id := range []string{"ct-1", "ct-2"} {
+		if w := doRequest(r, "POST", "/pullRequest/create", map[string]string{"pull_request_id": id, "pull_request_name": id, "author_id": "ct1"}); w.Code != http.StatusCreated {
+			t.Fatalf("Expected %s to be created, got %d", id, w.Code)
+		}
+	}
+
+	// created_at и assigned_at берутся из одних часов, иначе время до ревью смещается на расхождение часов приложения и БД
+	var skewed int
+	if err := db.QueryRow(`
+		SELECT COUNT(*) FROM pr_reviewers prr
+		JOIN pull_requests pr ON prr.org_id = pr.org_id AND prr.pull_request_id = pr.pull_request_id
+		WHERE pr.author_id = 'ct1' AND prr.assigned_at <> pr.created_at
+	`).Scan(&skewed); err != nil || skewed != 0 {
+		t.Errorf("Expected assigned_at to equal created_at, got %d mismatches (%v)", skewed, err)
+	}
+
+	if w := doRequest(r, "POST", "/pullRequest/review", map[string]string{"pull_request_id": "ct-1", "user_id": "ct2"}); w.Code != http.StatusOK {
+		t.Fatalf("Expected review to be recorded, got %d: %s", w.Code, w.Body.String())
+	}
+	doRequest(r, "POST", "/pullRequest/merge", map[string]string{"pull_request_id": "ct-1"})
+	if w := doRequest(r, "POST", "/pullRequest/review", map[string]string{"pull_request_id": "ct-1", "user_id": "ct3"}); w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), `"REVIEW_ON_MERGED"`) {
+		t.Errorf("Expected 409 REVIEW_ON_MERGED after merge, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// ct-1: ревью через 2ч и 6ч, merge через 24ч; ct-2: одно ревью через 4ч, не смержен
+	for _, query := range []string{
+		"UPDATE pull_requests SET created_at = '2025-03-01 10:00:00' WHERE author_id = 'ct1'",
+		"UPDATE pull_requests SET merged_at = '2025-03-02 10:00:00' WHERE pull_request_id = 'ct-1'",
+		"UPDATE pr_reviewers SET assigned_at = '2025-03-01 10:00:00' WHERE pull_request_id IN ('ct-1', 'ct-2')",
+		"UPDATE pr_reviewers SET reviewed_at = '2025-03-01 12:00:00' WHERE pull_request_id = 'ct-1' AND user_id = 'ct2'",
+		"UPDATE pr_reviewers SET reviewed_at = '2025-03-01 16:00:00' WHERE pull_request_id = 'ct-1' AND user_id = 'ct3'",
+		"UPDATE pr_reviewers SET reviewed_at = '2025-03-01 14:00:00' WHERE pull_request_id = 'ct-2' AND user_id = 'ct2'",
+	} {
+		if _, err := db.Exec(query); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cycleTime := func(query string) domain.CycleTimeStats {
+		t.Helper()
+		w := doRequest(r, "GET", "/stats/cycle-time?"+query, nil)
+		var stats domain.CycleTimeStats
+		if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), &stats) != nil {
+			t.Fatalf("Expected cycle time for %q, got %d: %s", query, w.Code, w.Body.String())
+		}
+		return stats
+	}
+	median := func(summary domain.DurationSummary) float64 {
+		if summary.MedianSeconds == nil {
+			return -1
+		}
+		return *summary.MedianSeconds
+	}
+
+	stats := cycleTime("team_name=Cycle&from=2025-03-01&to=2025-03-03")
+	if stats.Overall.TimeToMerge.Count != 1 || median(stats.Overall.TimeToMerge) != 24*3600 {
+		t.Errorf("Expected one merge after 24h, got %+v", stats.Overall.TimeToMerge)
+	}
+	// Первое ревью: 2ч у ct-1 и 4ч у ct-2
+	if stats.Overall.TimeToFirstReview.Count != 2 || median(stats.Overall.TimeToFirstReview) != 3*3600 {
+		t.Errorf("Expected two first reviews with 3h median, got %+v", stats.Overall.TimeToFirstReview)
+	}
+	if len(stats.Teams) != 1 || stats.Teams[0].TeamName != "Cycle" || stats.Teams[0].TimeToMerge.Count != 1 {
+		t.Errorf("Unexpected team breakdown: %+v", stats.Teams)
+	}
+	if len(stats.Reviewers) != 2 ||
+		stats.Reviewers[0].UserID != "ct2" || stats.Reviewers[0].TimeToReview.Count != 2 || median(stats.Reviewers[0].TimeToReview) != 3*3600 ||
+		stats.Reviewers[1].UserID != "ct3" || stats.Reviewers[1].TimeToReview.Count != 1 || median(stats.Reviewers[1].TimeToReview) != 6*3600 {
+		t.Errorf("Unexpected reviewer breakdown: %+v", stats.Reviewers)
+	}
+
+	stats = cycleTime("team_name=Cycle&from=2025-04-01")
+	if stats.Overall.TimeToMerge.Count != 0 || stats.Overall.TimeToMerge.MedianSeconds != nil || len(stats.Reviewers) != 0 {
+		t.Errorf("Expected no data outside the window, got %+v", stats)
+	}
+}
+
+func TestFairnessReport(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	r, teardown := setup()
+	defer teardown()
+
+	team := domain.Team{TeamName: "Fair", Members: []domain.TeamMember{
+		{UserID: "fa1", Username: "One", IsActive: true},
+		{UserID: "fa2", Username: "Two", IsActive: true},
+		{UserID: "fa3", Username: "Three", IsActive: true},
+		{UserID: "fa4", Username: "Away", IsActive: false},
+	}}
+	if w := doRequest(r, "POST", "/team/add", team); w.Code != http.StatusCreated {
+		t.Fatalf("Expected team to be created, got %d", w.Code)
+	}
+	// В команде трое активных, поэтому ревьюверы каждого PR — двое остальных
+	for _, pr := range []struct{ id, author string }{{"fr-1", "fa1"}, {"fr-2", "fa1"}, {"fr-3", "fa2"}} {
+		if w := doRequest(r, "POST", "/pullRequest/create", map[string]string{"pull_request_id": pr.id, "pull_request_name": pr.id, "author_id": pr.author}); w.Code != http.StatusCreated {
+			t.Fatalf("Expected %s to be created, got %d", pr.id, w.Code)
+		}
+	}
+	doRequest(r, "POST", "/pullRequest/merge", map[string]string{"pull_request_id": "fr-1"})
+
+	fairness := func(query string) domain.TeamFairness {
+		t.Helper()
+		w := doRequest(r, "GET", "/stats/fairness?team_name=Fair"+query, nil)
+		var report domain.FairnessReport
+		if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), &report) != nil || len(report.Teams) != 1 {
+			t.Fatalf("Expected fairness for one team, got %d: %s", w.Code, w.Body.String())
+		}
+		return report.Teams[0]
+	}
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+	// Открытые назначения: fa1=1, fa2=1, fa3=2; всего: fa1=1, fa2=2, fa3=3. Неактивный fa4 не учитывается
+	report := fairness("")
+	if report.ActiveMembers != 3 || len(report.Members) != 3 {
+		t.Errorf("Expected 3 active members, got %d (%+v)", report.ActiveMembers, report.Members)
+	}
+	open := report.OpenAssignments
+	if open.Min != 1 || open.Max != 2 || !near(open.Gini, 1.0/6) || open.MaxMinRatio == nil || *open.MaxMinRatio != 2 {
+		t.Errorf("Unexpected open assignments: %+v", open)
+	}
+	if total := report.TotalAssignments; total.Min != 1 || total.Max != 3 || !near(total.Mean, 2) || !near(total.Gini, 2.0/9) {
+		t.Errorf("Unexpected total assignments: %+v", total)
+	}
+	if report.Imbalanced {
+		t.Error("Expected team to be balanced under the default threshold")
+	}
+	if !fairness("&threshold=0.1").Imbalanced {
+		t.Error("Expected team to be imbalanced under a 0.1 threshold")
+	}
+
+	if w := doRequest(r, "GET", "/stats/fairness?threshold=1.5", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected threshold above 1 to be rejected, got %d", w.Code)
+	}
+}
+
+func TestStatsSnapshots(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	r, teardown := setup()
+	defer teardown()
+	db := connectTestDB()
+	defer db.Close()
+	db.Exec("DELETE FROM stats_snapshots")
+
+	team := domain.Team{TeamName: "Snap", Members: []domain.TeamMember{
+		{UserID: "sn1", Username: "Author", IsActive: true},
+		{UserID: "sn2", Username: "First", IsActive: true},
+		{UserID: "sn3", Username: "Second", IsActive: true},
+		{UserID: "sn4", Username: "Third", IsActive: true},
+	}}
+	if w := doRequest(r, "POST", "/team/add", team); w.Code != http.StatusCreated {
+		t.Fatalf("Expected team to be created, got %d", w.Code)
+	}
+	w := doRequest(r, "POST", "/pullRequest/create", map[string]string{"pull_request_id": "snap-1", "pull_request_name": "Snapshots", "author_id": "sn1"})
+	var created struct {
+		PR domain.PullRequest `json:"pr"`
+	}
+	if w.Code != http.StatusCreated || json.Unmarshal(w.Body.Bytes(), &created) != nil || len(created.PR.AssignedReviewers) != 2 {
+		t.Fatalf("Expected PR with two reviewers, got %d: %s", w.Code, w.Body.String())
+	}
+	oldReviewer := created.PR.AssignedReviewers[0]
+
+	// Расчёт «завтра»: сегодняшний снимок становится окончательным, завтрашний — промежуточный
+	ctx := auth.WithOrg(context.Background(), domain.DefaultOrgID)
+	statsService := service.NewStatsService(repository.NewStatsRepository(db), config.Default().Stats.Fairness.GiniThreshold)
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	tomorrow := today.AddDate(0, 0, 1)
+	if err := statsService.SnapshotDaily(ctx, tomorrow); err != nil {
+		t.Fatal(err)
+	}
+
+	w = doRequest(r, "POST", "/pullRequest/reassign", map[string]string{"pull_request_id": "snap-1", "old_user_id": oldReviewer})
+	var reassigned struct {
+		ReplacedBy string `json:"replaced_by"`
+	}
+	if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), &reassigned) != nil || reassigned.ReplacedBy == "" {
+		t.Fatalf("Expected reviewer to be reassigned, got %d: %s", w.Code, w.Body.String())
+	}
+	if err := statsService.SnapshotDaily(ctx, tomorrow); err != nil {
+		t.Fatal(err)
+	}
+	// Backfill тоже не трогает окончательный день
+	if _, err := statsService.BackfillSnapshots(ctx, &today, &today); err != nil {
+		t.Fatal(err)
+	}
+
+	points := func(userID string) map[string]int {
+		t.Helper()
+		history, err := statsService.GetHistory(ctx, domain.SnapshotScopeReviewer, userID, &today, &tomorrow)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result := map[string]int{}
+		for _, series := range history.Series {
+			for _, point := range series.Points {
+				result[point.Date] = point.TotalPRs
+			}
+		}
+		return result
+	}
+	day, nextDay := today.Format(time.DateOnly), tomorrow.Format(time.DateOnly)
+	if got := points(oldReviewer); got[day] != 1 || got[nextDay] != 0 {
+		t.Errorf("Expected %s to keep today's assignment and lose tomorrow's, got %v", oldReviewer, got)
+	}
+	if got := points(reassigned.ReplacedBy); got[day] != 0 || got[nextDay] != 1 {
+		t.Errorf("Expected %s to appear only from tomorrow, got %v", reassigned.ReplacedBy, got)
+	}
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	// Не требует БД: доменные gauge'и регистрируются только в main вместе с пулом соединений
+	r := newTestRouter(testRouterOptions{})
+
+	scrape := func() string {
+		t.Helper()
+		w := httptest.NewRecorder()
+		router.NewInternalRouter().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		return w.Body.String()
+	}
+	// Реестр метрик общий для всех тестов пакета, поэтому сравниваем приращение, а не значение
+	const healthRequests = `pr_review_http_requests_total{method="GET",route="/health",status="200"}`
+	before := metricValue(scrape(), healthRequests)
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/health", nil))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("Expected /metrics to be absent from the public router, got %d", w.Code)
+	}
+
+	body := scrape()
+	if delta := metricValue(body, healthRequests) - before; delta != 1 {
+		t.Errorf("Expected /health counter to grow by 1, got %v", delta)
+	}
+	for _, expected := range []string{
+		`pr_review_http_request_duration_seconds_bucket{method="GET",route="/health"`,
+		`pr_review_no_candidate_errors_total `,
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("Expected metrics output to contain %q", expected)
+		}
+	}
+}
+
+// metricValue возвращает значение серии из вывода /metrics; отсутствующая серия считается нулём
+func metricValue(body, series string) float64 {
+	for _, line := range strings.Split(body, "\n") {
+		if value, ok := strings.CutPrefix(line, series+" "); ok {
+			v, _ := strconv.ParseFloat(value, 64)
+			return v
+		}
+	}
+	return 0
+}
+
+func TestTraceparentPropagation(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTracerProvider(sdktrace.NewTracerProvider())
+
+	r := newTestRouter(testRouterOptions{})
+
+	req := httptest.NewRequest("GET", "/health", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+	if spans[0].Name() != "GET /health" {
+		t.Errorf("Expected span name GET /health, got %s", spans[0].Name())
+	}
+	if got := spans[0].SpanContext().TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("Expected trace id from traceparent, got %s", got)
+	}
+}
+
+func TestRequestLogging(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.New(logging.Config{Level: "info", Format: logging.FormatJSON}, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := slog.Default()
+	slog.SetDefault(logger)
+	defer slog.SetDefault(previous)
+
+	r := newTestRouter(testRouterOptions{})
+
+	req := httptest.NewRequest("GET", "/team/get", nil)
+	req.Header.Set("X-Request-Id", "req-42")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Expected a single JSON log entry, got %q", buf.String())
+	}
+	if entry["request_id"] != "req-42" || entry["route"] != "/team/get" || entry["status"] != float64(http.StatusBadRequest) {
+		t.Errorf("Unexpected log entry: %v", entry)
+	}
+}
+
+func TestReadiness(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	r, teardown := setup()
+	defer teardown()
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/readyz", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+
+	var report domain.HealthReport
+	json.Unmarshal(w.Body.Bytes(), &report)
+	if report.Components["database"].Status != domain.HealthStatusOK || report.Components["migrations"].Status != domain.HealthStatusOK {
+		t.Errorf("Expected all components to be ok, got %+v", report.Components)
+	}
+}
+
+func TestReadinessDatabaseDown(t *testing.T) {
+	db, err := sql.Open("postgres", "host=127.0.0.1 port=1 user=pruser password=prpass dbname=pr_review_db sslmode=disable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	healthService := service.NewHealthService(repository.NewHealthRepository(db), 3, time.Second)
+	r := newTestRouter(testRouterOptions{
+		health: handler.NewHealthHandler(healthService),
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/readyz", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, got %d", w.Code)
+	}
+
+	var report domain.HealthReport
+	json.Unmarshal(w.Body.Bytes(), &report)
+	if report.Components["database"].Status != domain.HealthStatusUnavailable {
+		t.Errorf("Expected database to be unavailable, got %+v", report.Components)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/livez", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected liveness to stay 200, got %d", w.Code)
+	}
+}
+
+func TestGracefulShutdownDrainsRequests(t *testing.T) {
+	started := make(chan struct{})
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		w.Write([]byte("done"))
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	srv := server.New(server.Config{ReadHeaderTimeout: time.Second, WriteTimeout: 5 * time.Second}, slow)
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.Serve(ctx, srv, listener, 5*time.Second)
+	}()
+
+	type result struct {
+		body string
+		err  error
+	}
+	response := make(chan result, 1)
+	go func() {
+		resp, err := http.Get("http://" + listener.Addr().String())
+		if err != nil {
+			response <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		response <- result{body: string(body), err: err}
+	}()
+
+	<-started
+	cancel()
+
+	res := <-response
+	if res.err != nil || res.body != "done" {
+		t.Errorf("Expected in-flight request to complete, got body %q, err %v", res.body, res.err)
+	}
+	if err := <-serveErr; err != nil {
+		t.Errorf("Expected clean shutdown, got %v", err)
+	}
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	path := t.TempDir() + "/config.yml"
+	file := "server:\n  addr: \":9000\"\ndatabase:\n  host: file-host\n  port: \"6000\"\n  password: from-file\n  max_open_conns: 40\n"
+	if err := os.WriteFile(path, []byte(file), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	env := map[string]string{
+		"CONFIG_FILE": path,
+		"DB_HOST":     "env-host",
+		"DB_PORT":     "7000",
+	}
+	lookupEnv := func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+
+	cfg, err := config.Load([]string{"-db-port", "8000"}, lookupEnv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Server.Addr != ":9000" || cfg.Database.MaxOpenConns != 40 {
+		t.Errorf("Expected values from file, got addr %q, max_open_conns %d", cfg.Server.Addr, cfg.Database.MaxOpenConns)
+	}
+	if cfg.Database.Host != "env-host" {
+		t.Errorf("Expected env to override file, got host %q", cfg.Database.Host)
+	}
+	if cfg.Database.Port != "8000" {
+		t.Errorf("Expected flag to override env, got port %q", cfg.Database.Port)
+	}
+	if cfg.Database.ConnectRetries != 10 || cfg.Database.MigrationsPath != "./migrations" {
+		t.Errorf("Expected defaults for unset values, got %+v", cfg.Database)
+	}
+
+	var out bytes.Buffer
+	if err := cfg.Print(&out); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out.String(), "from-file") || !strings.Contains(out.String(), "password: '******'") {
+		t.Errorf("Expected password to be masked, got:\n%s", out.String())
+	}
+
+	_, err = config.Load([]string{"-db-max-open-conns", "0", "-log-format", "xml"}, lookupEnv)
+	if err == nil || !strings.Contains(err.Error(), "max_open_conns") || !strings.Contains(err.Error(), "log.format") {
+		t.Errorf("Expected validation errors for pool size and log format, got %v", err)
+	}
+
+	_, err = config.Load([]string{"-auth-jwt-jwks-url", "https://sso.example.com/jwks"}, lookupEnv)
+	if err == nil || !strings.Contains(err.Error(), "auth.jwt.issuer") {
+		t.Errorf("Expected JWKS without issuer to be rejected, got %v", err)
+	}
+	if _, err = config.Load([]string{"-auth-jwt-jwks-url", "https://sso.example.com/jwks", "-auth-jwt-issuer", "https://sso.example.com"}, lookupEnv); err != nil {
+		t.Errorf("Expected JWKS with issuer to be accepted, got %v", err)
+	}
+
+	_, err = config.Load([]string{"-stats-fairness-gini-threshold", "1.5"}, lookupEnv)
+	if err == nil || !strings.Contains(err.Error(), "stats.fairness.gini_threshold") {
+		t.Errorf("Expected Gini threshold above 1 to be rejected, got %v", err)
+	}
+	env["STATS_FAIRNESS_GINI_THRESHOLD"] = "0.1"
+	if cfg, err := config.Load(nil, lookupEnv); err != nil || cfg.Stats.Fairness.GiniThreshold != 0.1 {
+		t.Errorf("Expected Gini threshold from env, got %v (%v)", cfg, err)
+	}
+
+	_, err = config.Load([]string{"-github-webhook-secret", "s3cret"}, lookupEnv)
+	if err == nil || !strings.Contains(err.Error(), "webhooks.github.org_id") {
+		t.Errorf("Expected GitHub webhook without org to be rejected, got %v", err)
+	}
+	if _, err = config.Load([]string{"-gitlab-webhook-secret", "s3cret", "-gitlab-webhook-org-id", "acme"}, lookupEnv); err != nil {
+		t.Errorf("Expected GitLab webhook with org to be accepted, got %v", err)
+	}
+
+	env["DB_CONNECT_RETRIES"] = "many"
+	if _, err := config.Load(nil, lookupEnv); err == nil || !strings.Contains(err.Error(), "DB_CONNECT_RETRIES") {
+		t.Errorf("Expected invalid env value to be reported, got %v", err)
+	}
+}
+
+func TestRepositoryRequiresOrg(t *testing.T) {
+	// Организация проверяется до обращения к базе, поэтому соединение не нужно
+	userRepo := repository.NewUserRepository(nil)
+	if _, err := userRepo.GetUser(context.Background(), "u1"); !errors.Is(err, auth.ErrNoOrg) {
+		t.Errorf("Expected ErrNoOrg without organization in context, got %v", err)
+	}
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	r, apiKeyService, teardown := setupWithAuth(true, nil)
+	defer teardown()
+
+	admin, err := apiKeyService.CreateKey(auth.WithOrg(context.Background(), domain.DefaultOrgID), "bootstrap", []string{domain.ScopeTeamAdmin, domain.ScopeRead})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if w := doRequest(r, "GET", "/team/get?team_name=Backend", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without key, got %d", w.Code)
+	}
+	if w := doRequest(r, "GET", "/team/get?team_name=Backend", nil, apiKey("prm_unknown")); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for unknown key, got %d", w.Code)
+	}
+
+	w := doRequest(r, "POST", "/apiKeys/create", map[string]interface{}{
+		"name":   "ci",
+		"scopes": []string{domain.ScopeRead},
+	}, apiKey(admin.Key))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created struct {
+		APIKey domain.CreatedAPIKey `json:"api_key"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &created)
+
+	readKey := created.APIKey.Key
+	if w := doRequest(r, "GET", "/users/list", nil, apiKey(readKey)); w.Code != http.StatusOK {
+		t.Errorf("Expected read key to list users, got %d", w.Code)
+	}
+	w = doRequest(r, "POST", "/team/deactivate", map[string]string{"team_name": "Backend"}, apiKey(readKey))
+	if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), "FORBIDDEN") {
+		t.Errorf("Expected 403 FORBIDDEN for read key, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = doRequest(r, "GET", "/apiKeys/list", nil, apiKey(admin.Key))
+	var list struct {
+		APIKeys []domain.APIKey `json:"api_keys"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &list)
+	if len(list.APIKeys) != 2 {
+		t.Fatalf("Expected 2 keys, got %+v", list.APIKeys)
+	}
+	for _, key := range list.APIKeys {
+		if key.KeyID == created.APIKey.KeyID && key.LastUsedAt == nil {
+			t.Errorf("Expected last_used_at to be tracked for %s", key.KeyID)
+		}
+	}
+	if strings.Contains(w.Body.String(), readKey) {
+		t.Error("Expected plain key not to be listed")
+	}
+
+	if w := doRequest(r, "POST", "/apiKeys/revoke", map[string]string{"key_id": created.APIKey.KeyID}, apiKey(admin.Key)); w.Code != http.StatusOK {
+		t.Errorf("Expected revoke to succeed, got %d", w.Code)
+	}
+	if w := doRequest(r, "GET", "/users/list", nil, apiKey(readKey)); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected revoked key to be rejected, got %d", w.Code)
+	}
+}
+
+func TestAuthRequired(t *testing.T) {
+	// Запрос без ключа отклоняется до обращения к БД, пробы остаются открытыми
+	r := newTestRouter(testRouterOptions{
+		authenticator: handler.NewAuthenticator(service.NewAuthService(service.NewAPIKeyService(nil), nil, nil), true, "", ""),
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/team/deactivate", strings.NewReader(`{"team_name":"Backend"}`)))
+	if w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), `"UNAUTHORIZED"`) {
+		t.Errorf("Expected 401 UNAUTHORIZED, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/livez", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected /livez to stay open, got %d", w.Code)
+	}
+}
+
+// testIssuer выпускает токены, подписанные тестовым RSA-ключом, и отдаёт соответствующий JWKS
+type testIssuer struct {
+	t      *testing.T
+	signer jose.Signer
+	jwks   []byte
+}
+
+func newTestIssuer(t *testing.T, kid string) *testIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.RS256, Key: jose.JSONWebKey{Key: key, KeyID: kid}},
+		(&jose.SignerOptions{}).WithType("JWT"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks, _ := json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+		{Key: &key.PublicKey, KeyID: kid, Algorithm: string(jose.RS256), Use: "sig"},
+	}})
+	return &testIssuer{t: t, signer: signer, jwks: jwks}
+}
+
+func (i *testIssuer) token(claims jwt.Claims, extra ...interface{}) string {
+	builder := jwt.Signed(i.signer).Claims(claims)
+	for _, custom := range extra {
+		builder = builder.Claims(custom)
+	}
+	token, err := builder.Serialize()
+	if err != nil {
+		i.t.Fatal(err)
+	}
+	return token
+}
+
+func validClaims(subject string) jwt.Claims {
+	return jwt.Claims{
+		Issuer:   "https://sso.example.com",
+		Audience: jwt.Audience{"pr-review-manager"},
+		Subject:  subject,
+		Expiry:   jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		IssuedAt: jwt.NewNumericDate(time.Now()),
+	}
+}
+
+func TestJWTVerifier(t *testing.T) {
+	issuer := newTestIssuer(t, "key-1")
+	jwksServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(issuer.jwks)
+	}))
+	defer jwksServer.Close()
+
+	jwksFile := t.TempDir() + "/jwks.json"
+	if err := os.WriteFile(jwksFile, issuer.jwks, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, cfg := range map[string]auth.JWTConfig{
+		"file": {JWKSFile: jwksFile},
+		"url":  {JWKSURL: jwksServer.URL},
+	} {
+		cfg.Issuer = "https://sso.example.com"
+		cfg.Audience = "pr-review-manager"
+		cfg.UserClaim = "sub"
+		cfg.OrgClaim = "org"
+
+		verifier, err := auth.NewJWTVerifier(context.Background(), cfg)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		userID, orgID, err := verifier.Verify(context.Background(), issuer.token(validClaims("u1"), map[string]interface{}{"org": "acme"}))
+		if err != nil || userID != "u1" || orgID != "acme" {
+			t.Errorf("%s: expected u1 in acme, got %q/%q, err %v", name, userID, orgID, err)
+		}
+
+		expired := validClaims("u1")
+		expired.Expiry = jwt.NewNumericDate(time.Now().Add(-time.Hour))
+		wrongAudience := validClaims("u1")
+		wrongAudience.Audience = jwt.Audience{"another-service"}
+		for reason, token := range map[string]string{
+			"expired":        issuer.token(expired),
+			"wrong audience": issuer.token(wrongAudience),
+			"foreign key":    newTestIssuer(t, "key-1").token(validClaims("u1")),
+			"malformed":      "not-a-jwt",
+			"without org":    issuer.token(validClaims("u1")),
+		} {
+			if _, _, err := verifier.Verify(context.Background(), token); err == nil {
+				t.Errorf("%s: expected %s token to be rejected", name, reason)
+			}
+		}
+	}
+}
+
+func TestJWTVerifierWithoutOrgClaim(t *testing.T) {
+	issuer := newTestIssuer(t, "key-1")
+	jwksFile := t.TempDir() + "/jwks.json"
+	if err := os.WriteFile(jwksFile, issuer.jwks, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Без OrgClaim организация не читается из токена: развёртывание с одной организацией
+	verifier, err := auth.NewJWTVerifier(context.Background(), auth.JWTConfig{
+		JWKSFile:  jwksFile,
+		Issuer:    "https://sso.example.com",
+		UserClaim: "sub",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	userID, orgID, err := verifier.Verify(context.Background(), issuer.token(validClaims("u1"), map[string]interface{}{"org": "acme"}))
+	if err != nil || userID != "u1" || orgID != "" {
+		t.Errorf("Expected u1 without org, got %q/%q, err %v", userID, orgID, err)
+	}
+}
+
+func TestBearerTokenAttribution(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	issuer := newTestIssuer(t, "key-1")
+	jwksFile := t.TempDir() + "/jwks.json"
+	os.WriteFile(jwksFile, issuer.jwks, 0o600)
+	verifier, err := auth.NewJWTVerifier(context.Background(), auth.JWTConfig{
+		JWKSFile:  jwksFile,
+		Issuer:    "https://sso.example.com",
+		Audience:  "pr-review-manager",
+		UserClaim: "sub",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, apiKeyService, teardown := setupWithAuth(true, verifier)
+	defer teardown()
+
+	admin, _ := apiKeyService.CreateKey(auth.WithOrg(context.Background(), domain.DefaultOrgID), "bootstrap", []string{domain.ScopeTeamAdmin})
+
+	bearer := func(userID string) map[string]string {
+		return map[string]string{"Authorization": "Bearer " + issuer.token(validClaims(userID))}
+	}
+
+	w := doRequest(r, "POST", "/team/add", domain.Team{
+		TeamName: "Backend",
+		Members: []domain.TeamMember{
+			{UserID: "u1", Username: "Golf", IsActive: true},
+			{UserID: "u2", Username: "Lebron", IsActive: true},
+		},
+	}, map[string]string{"X-API-Key": admin.Key})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected team to be created, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := doRequest(r, "POST", "/pullRequest/create", map[string]string{}, bearer("ghost")); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected token of unknown user to be rejected, got %d", w.Code)
+	}
+
+	w = doRequest(r, "POST", "/pullRequest/create", map[string]string{
+		"pull_request_id":   "pr-jwt",
+		"pull_request_name": "Attributed",
+		"author_id":         "u1",
+	}, bearer("u1"))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected PR to be created, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = doRequest(r, "POST", "/pullRequest/review", map[string]string{"pull_request_id": "pr-jwt", "user_id": "u1"}, bearer("u2"))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected review on behalf of another user to be forbidden, got %d", w.Code)
+	}
+	w = doRequest(r, "POST", "/pullRequest/review", map[string]string{"pull_request_id": "pr-jwt"}, bearer("u2"))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected review by caller to succeed, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = doRequest(r, "POST", "/pullRequest/merge", map[string]string{"pull_request_id": "pr-jwt"}, bearer("u1"))
+	var merged struct {
+		PR domain.PullRequest `json:"pr"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &merged)
+	if merged.PR.MergedBy != "u1" {
+		t.Errorf("Expected merge to be attributed to u1, got %q", merged.PR.MergedBy)
+	}
+
+	if w := doRequest(r, "POST", "/team/deactivate", map[string]string{"team_name": "Backend"}, bearer("u1")); w.Code != http.StatusForbidden {
+		t.Errorf("Expected token holder without team:admin to be forbidden, got %d", w.Code)
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	as := func(principal *domain.Principal) context.Context {
+		return auth.WithPrincipal(context.Background(), principal)
+	}
+	admin := as(&domain.Principal{UserID: "a1", Role: domain.RoleAdmin, TeamName: "Ops"})
+	lead := as(&domain.Principal{UserID: "u1", Role: domain.RoleTeamLead, TeamName: "Backend"})
+	member := as(&domain.Principal{UserID: "u2", Role: domain.RoleMember, TeamName: "Backend"})
+	apiKey := as(&domain.Principal{APIKeyID: "key_1", Scopes: []string{domain.ScopeTeamAdmin}})
+
+	pr := &domain.PullRequest{PullRequestID: "pr-1", AuthorID: "u3", AssignedReviewers: []string{"u2"}}
+	frontendReviewer := &domain.User{UserID: "f1", TeamName: "Frontend"}
+	backendReviewer := &domain.User{UserID: "u2", TeamName: "Backend"}
+
+	cases := []struct {
+		name string
+		err  error
+		want *apperrors.AppError
+	}{
+		{"admin deactivates any team", policy.CanManageTeam(admin, "Frontend"), nil},
+		{"api key is limited by scope only", policy.CanManageTeam(apiKey, "Frontend"), nil},
+		{"no caller when auth is disabled", policy.RequireAdmin(context.Background()), nil},
+		{"lead deactivates own team", policy.CanManageTeam(lead, "Backend"), nil},
+		{"lead cannot deactivate other team", policy.CanManageTeam(lead, "Frontend"), apperrors.ErrNotTeamLead},
+		{"member cannot deactivate team", policy.CanManageTeam(member, "Backend"), apperrors.ErrNotTeamLead},
+		{"lead is not admin", policy.RequireAdmin(lead), apperrors.ErrAdminOnly},
+		{"reviewer acts on PR", policy.CanActOnPR(member, pr, "Backend"), nil},
+		{"outsider cannot act on PR", policy.CanActOnPR(as(&domain.Principal{UserID: "u4", Role: domain.RoleMember}), pr, "Backend"), apperrors.ErrNotPRMember},
+		{"lead acts on PR of own team", policy.CanActOnPR(lead, pr, "Backend"), nil},
+		{"lead reassigns within own team", policy.CanReassign(lead, pr, backendReviewer), nil},
+		{"lead cannot reassign in other team", policy.CanReassign(lead, &domain.PullRequest{AuthorID: "f2"}, frontendReviewer), apperrors.ErrNotPRMember},
+		{"member creates own PR", policy.CanCreatePR(member, &domain.User{UserID: "u2", TeamName: "Backend"}), nil},
+		{"member cannot create PR for others", policy.CanCreatePR(member, &domain.User{UserID: "u3", TeamName: "Backend"}), apperrors.ErrNotPRMember},
+	}
+	for _, c := range cases {
+		if c.want == nil && c.err != nil {
+			t.Errorf("%s: expected access, got %v", c.name, c.err)
+		}
+		if c.want != nil && c.err != c.want {
+			t.Errorf("%s: expected %v, got %v", c.name, c.want, c.err)
+		}
+	}
+}
+
+func TestRoleBasedAccess(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	r, apiKeyService, teardown := setupWithAuth(true, nil)
+	defer teardown()
+
+	admin, _ := apiKeyService.CreateKey(auth.WithOrg(context.Background(), domain.DefaultOrgID), "bootstrap", []string{domain.ScopeTeamAdmin, domain.ScopePRWrite})
+	asKey := map[string]string{"X-API-Key": admin.Key}
+	as := func(userID string) map[string]string {
+		return map[string]string{"X-Forwarded-User": userID, "X-Forwarded-Org": domain.DefaultOrgID}
+	}
+
+	for _, team := range []domain.Team{
+		{TeamName: "Backend", Members: []domain.TeamMember{
+			{UserID: "u1", Username: "Lead", IsActive: true},
+			{UserID: "u2", Username: "Dev", IsActive: true},
+			{UserID: "u3", Username: "Dev2", IsActive: true},
+		}},
+		{TeamName: "Frontend", Members: []domain.TeamMember{
+			{UserID: "f1", Username: "FrontLead", IsActive: true},
+			{UserID: "f2", Username: "FrontDev", IsActive: true},
+			{UserID: "a1", Username: "Admin", IsActive: true},
+		}},
+	} {
+		if w := doRequest(r, "POST", "/team/add", team, asKey); w.Code != http.StatusCreated {
+			t.Fatalf("Expected team %s to be created, got %d: %s", team.TeamName, w.Code, w.Body.String())
+		}
+	}
+	for userID, role := range map[string]string{"u1": domain.RoleTeamLead, "f1": domain.RoleTeamLead, "a1": domain.RoleAdmin} {
+		if w := doRequest(r, "POST", "/users/setRole", map[string]string{"user_id": userID, "role": role}, asKey); w.Code != http.StatusOK {
+			t.Fatalf("Expected role to be set, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+
+	expect := func(name string, w *httptest.ResponseRecorder, status int) {
+		t.Helper()
+		if w.Code != status {
+			t.Errorf("%s: expected %d, got %d: %s", name, status, w.Code, w.Body.String())
+		}
+	}
+
+	expect("lead of other team deactivates", doRequest(r, "POST", "/team/deactivate", map[string]string{"team_name": "Backend"}, as("f1")), http.StatusForbidden)
+	expect("member changes activity", doRequest(r, "POST", "/users/setIsActive", map[string]interface{}{"user_id": "u3", "is_active": false}, as("u2")), http.StatusForbidden)
+	expect("lead changes other team member", doRequest(r, "POST", "/users/setIsActive", map[string]interface{}{"user_id": "f2", "is_active": false}, as("u1")), http.StatusForbidden)
+	expect("lead changes own team member", doRequest(r, "POST", "/users/setIsActive", map[string]interface{}{"user_id": "u3", "is_active": true}, as("u1")), http.StatusOK)
+	expect("lead changes roles", doRequest(r, "POST", "/users/setRole", map[string]string{"user_id": "u2", "role": domain.RoleAdmin}, as("u1")), http.StatusForbidden)
+	expect("lead creates api key", doRequest(r, "POST", "/apiKeys/create", map[string]interface{}{"name": "x", "scopes": []string{"read"}}, as("u1")), http.StatusForbidden)
+
+	pr := map[string]string{"pull_request_id": "pr-rbac", "pull_request_name": "RBAC", "author_id": "u3"}
+	expect("member creates PR for someone else", doRequest(r, "POST", "/pullRequest/create", pr, as("u2")), http.StatusForbidden)
+	pr["author_id"] = "u2"
+	expect("member creates own PR", doRequest(r, "POST", "/pullRequest/create", pr, as("u2")), http.StatusCreated)
+
+	merge := map[string]string{"pull_request_id": "pr-rbac"}
+	expect("outsider merges", doRequest(r, "POST", "/pullRequest/merge", merge, as("f2")), http.StatusForbidden)
+	expect("lead of other team merges", doRequest(r, "POST", "/pullRequest/merge", merge, as("f1")), http.StatusForbidden)
+	expect("lead of author's team merges", doRequest(r, "POST", "/pullRequest/merge", merge, as("u1")), http.StatusOK)
+
+	expect("admin deactivates any team", doRequest(r, "POST", "/team/deactivate", map[string]string{"team_name": "Backend"}, as("a1")), http.StatusOK)
+}
+
+func TestOrganizationIsolation(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	r, apiKeyService, teardown := setupWithAuth(true, nil)
+	defer teardown()
+
+	db := connectTestDB()
+	defer db.Close()
+	if _, err := service.NewOrgService(repository.NewOrgRepository(db)).CreateOrg(context.Background(), "acme", "Acme"); err != nil {
+		t.Fatal(err)
+	}
+
+	scopes := []string{domain.ScopeRead, domain.ScopePRWrite, domain.ScopeTeamAdmin}
+	defaultKey, _ := apiKeyService.CreateKey(auth.WithOrg(context.Background(), domain.DefaultOrgID), "default", scopes)
+	acmeKey, _ := apiKeyService.CreateKey(auth.WithOrg(context.Background(), "acme"), "acme", scopes)
+
+	// Одинаковые идентификаторы команд, пользователей и PR допустимы в разных организациях
+	for key, username := range map[string]string{defaultKey.Key: "Default", acmeKey.Key: "Acme"} {
+		team := domain.Team{TeamName: "Backend", Members: []domain.TeamMember{
+			{UserID: "u1", Username: username + "Author", IsActive: true},
+			{UserID: "u2", Username: username + "Reviewer", IsActive: true},
+		}}
+		if w := doRequest(r, "POST", "/team/add", team, apiKey(key)); w.Code != http.StatusCreated {
+			t.Fatalf("Expected team in %s to be created, got %d: %s", username, w.Code, w.Body.String())
+		}
+		pr := map[string]string{"pull_request_id": "pr-1", "pull_request_name": username, "author_id": "u1"}
+		if w := doRequest(r, "POST", "/pullRequest/create", pr, apiKey(key)); w.Code != http.StatusCreated {
+			t.Fatalf("Expected PR in %s to be created, got %d: %s", username, w.Code, w.Body.String())
+		}
+	}
+
+	var team domain.Team
+	json.Unmarshal(doRequest(r, "GET", "/team/get?team_name=Backend", nil, apiKey(acmeKey.Key)).Body.Bytes(), &team)
+	for _, member := range team.Members {
+		if !strings.HasPrefix(member.Username, "Acme") {
+			t.Errorf("Expected only acme members, got %s", member.Username)
+		}
+	}
+
+	if w := doRequest(r, "POST", "/pullRequest/merge", map[string]string{"pull_request_id": "pr-1"}, apiKey(acmeKey.Key)); w.Code != http.StatusOK {
+		t.Fatalf("Expected merge in acme, got %d: %s", w.Code, w.Body.String())
+	}
+	var got struct {
+		PR domain.PullRequestDetails `json:"pr"`
+	}
+	json.Unmarshal(doRequest(r, "GET", "/pullRequest/get?pull_request_id=pr-1", nil, apiKey(defaultKey.Key)).Body.Bytes(), &got)
+	if got.PR.Status != domain.StatusOpen || got.PR.PullRequestName != "Default" {
+		t.Errorf("Expected default org PR to stay open, got %+v", got.PR)
+	}
+
+	var stats domain.Stats
+	json.Unmarshal(doRequest(r, "GET", "/stats", nil, apiKey(acmeKey.Key)).Body.Bytes(), &stats)
+	if stats.TotalPRs != 1 || stats.MergedPRs != 1 {
+		t.Errorf("Expected acme stats to count only its PR, got %+v", stats)
+	}
+
+	var keys struct {
+		APIKeys []domain.APIKey `json:"api_keys"`
+	}
+	json.Unmarshal(doRequest(r, "GET", "/apiKeys/list", nil, apiKey(defaultKey.Key)).Body.Bytes(), &keys)
+	if len(keys.APIKeys) != 1 || keys.APIKeys[0].KeyID != defaultKey.KeyID {
+		t.Errorf("Expected only the default org key, got %+v", keys.APIKeys)
+	}
+	if w := doRequest(r, "POST", "/apiKeys/revoke", map[string]string{"key_id": acmeKey.KeyID}, apiKey(defaultKey.Key)); w.Code != http.StatusNotFound {
+		t.Errorf("Expected foreign key revoke to be 404, got %d", w.Code)
+	}
+
+	// Пользователь прокси ищется в организации из заголовка
+	req := httptest.NewRequest("GET", "/team/get?team_name=Backend", nil)
+	req.Header.Set("X-Forwarded-User", "u1")
+	req.Header.Set("X-Forwarded-Org", "acme")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "AcmeAuthor") {
+		t.Errorf("Expected proxy user to see acme team, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Без заголовка организации u1 не подменяется пользователем u1 организации по умолчанию
+	req = httptest.NewRequest("GET", "/team/get?team_name=Backend", nil)
+	req.Header.Set("X-Forwarded-User", "u1")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected proxy user without org to be rejected, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestRateLimiting(t *testing.T) {
+	lookupEnv := func(key string) (string, bool) {
+		if key == "RATE_LIMIT_ROUTES" {
+			return "/pullRequest/create=0.01:1", true
+		}
+		return "", false
+	}
+	cfg, err := config.Load([]string{"-rate-limit-write-rps", "0.01", "-rate-limit-write-burst", "2"}, lookupEnv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Без ключа запрос отклоняется аутентификацией, но сначала расходует токен лимита
+	r := newTestRouter(testRouterOptions{
+		authenticator: handler.NewAuthenticator(service.NewAuthService(service.NewAPIKeyService(nil), nil, nil), true, "", ""),
+		rateLimiter:   handler.NewRateLimiter(cfg.RateLimit),
+	})
+	from := func(remoteAddr, method, path string, headers ...map[string]string) *httptest.ResponseRecorder {
+		req := newJSONRequest(method, path, map[string]string{}, headers...)
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	for i, remaining := range []string{"1", "0"} {
+		w := from("10.0.0.1:1234", "POST", "/team/deactivate")
+		if w.Code != http.StatusUnauthorized || w.Header().Get("X-RateLimit-Limit") != "2" || w.Header().Get("X-RateLimit-Remaining") != remaining {
+			t.Errorf("request %d: expected 401 with %s remaining, got %d %v", i, remaining, w.Code, w.Header())
+		}
+	}
+
+	w := from("10.0.0.1:5678", "POST", "/team/deactivate")
+	if w.Code != http.StatusTooManyRequests || !strings.Contains(w.Body.String(), `"RATE_LIMITED"`) {
+		t.Errorf("Expected 429 RATE_LIMITED, got %d: %s", w.Code, w.Body.String())
+	}
+	if retry, _ := strconv.Atoi(w.Header().Get("Retry-After")); retry < 90 {
+		t.Errorf("Expected Retry-After of about 100s, got %q", w.Header().Get("Retry-After"))
+	}
+
+	if w := from("10.0.0.1:1234", "GET", "/team/get?team_name=Backend"); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected reads to have a separate bucket, got %d", w.Code)
+	}
+	if w := from("10.0.0.2:1234", "POST", "/team/deactivate"); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected another client to have its own bucket, got %d", w.Code)
+	}
+
+	if w := from("10.0.0.3:1234", "POST", "/pullRequest/create"); w.Code != http.StatusUnauthorized || w.Header().Get("X-RateLimit-Limit") != "1" {
+		t.Errorf("Expected route limit of 1, got %d %v", w.Code, w.Header())
+	}
+	if w := from("10.0.0.3:1234", "POST", "/pullRequest/create"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected route limit to be exhausted, got %d", w.Code)
+	}
+
+	// Непроверенные ключи не получают своих корзин: перебор случайных ключей упирается в лимит IP
+	for i := 0; i < 3; i++ {
+		w := from("10.0.0.4:1234", "POST", "/team/deactivate", apiKey("bogus-key-"+strconv.Itoa(i)))
+		if expected := []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests}[i]; w.Code != expected {
+			t.Errorf("bogus key %d: expected %d, got %d", i, expected, w.Code)
+		}
+	}
+
+	if w := from("10.0.0.1:1234", "GET", "/livez"); w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Limit") != "" {
+		t.Errorf("Expected probes to bypass rate limiting, got %d %v", w.Code, w.Header())
+	}
+}
+
+func TestRateLimitTrustedProxies(t *testing.T) {
+	lookupEnv := func(key string) (string, bool) {
+		if key == "RATE_LIMIT_TRUSTED_PROXIES" {
+			return "10.1.0.0/16, 10.2.0.1", true
+		}
+		return "", false
+	}
+	cfg, err := config.Load([]string{"-rate-limit-write-rps", "0.01", "-rate-limit-write-burst", "1"}, lookupEnv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := newTestRouter(testRouterOptions{
+		authenticator: handler.NewAuthenticator(service.NewAuthService(service.NewAPIKeyService(nil), nil, nil), true, "", ""),
+		rateLimiter:   handler.NewRateLimiter(cfg.RateLimit),
+	})
+	from := func(remoteAddr string, headers map[string]string) int {
+		req := newJSONRequest("POST", "/team/deactivate", map[string]string{}, headers)
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	forwardedFor := func(value string) map[string]string {
+		return map[string]string{"X-Forwarded-For": value}
+	}
+
+	cases := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		expected   int
+	}{
+		{"client behind a trusted proxy", "10.1.0.5:1234", forwardedFor("203.0.113.1"), http.StatusUnauthorized},
+		{"same client through another trusted proxy", "10.2.0.1:1234", forwardedFor("203.0.113.1"), http.StatusTooManyRequests},
+		{"another client behind the proxy", "10.1.0.5:1234", forwardedFor("203.0.113.2"), http.StatusUnauthorized},
+		{"spoofed leftmost hop is ignored", "10.1.0.5:1234", forwardedFor("198.51.100.1, 203.0.113.2"), http.StatusTooManyRequests},
+		{"trusted hops are skipped", "10.1.0.5:1234", forwardedFor("203.0.113.3, 10.1.0.9"), http.StatusUnauthorized},
+		{"X-Real-IP from a trusted proxy", "10.1.0.5:1234", map[string]string{"X-Real-IP": "203.0.113.3"}, http.StatusTooManyRequests},
+		{"untrusted peer", "192.0.2.1:1234", forwardedFor("203.0.113.4"), http.StatusUnauthorized},
+		{"headers from an untrusted peer are ignored", "192.0.2.1:1234", forwardedFor("203.0.113.5"), http.StatusTooManyRequests},
+	}
+	for _, tc := range cases {
+		if code := from(tc.remoteAddr, tc.headers); code != tc.expected {
+			t.Errorf("%s: expected %d, got %d", tc.name, tc.expected, code)
+		}
+	}
+
+	if _, err := config.Load([]string{"-rate-limit-trusted-proxies", "10.0.0.0/33"}, func(string) (string, bool) { return "", false }); err == nil || !strings.Contains(err.Error(), "rate_limit.trusted_proxies") {
+		t.Errorf("Expected invalid proxy CIDR to be rejected, got %v", err)
+	}
+}
+
+func TestIdempotencyBodyLimit(t *testing.T) {
+	// Не требует БД: тело читается до обращения к хранилищу ключей
+	r := newTestRouter(testRouterOptions{})
+
+	req := httptest.NewRequest("POST", "/team/add", bytes.NewReader(bytes.Repeat([]byte("a"), 5<<20+1)))
+	req.Header.Set("Idempotency-Key", "too-large")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusRequestEntityTooLarge || !strings.Contains(w.Body.String(), apperrors.ErrBodyTooLarge.Code) {
+		t.Errorf("Expected oversized body to be rejected with 413, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestIdempotency(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	r, teardown := setup()
+	defer teardown()
+
+	team := domain.Team{TeamName: "Platform", Members: []domain.TeamMember{
+		{UserID: "p1", Username: "Author", IsActive: true},
+		{UserID: "p2", Username: "Reviewer", IsActive: true},
+		{UserID: "p3", Username: "Other", IsActive: true},
+	}}
+	if w := doRequest(r, "POST", "/team/add", team); w.Code != http.StatusCreated {
+		t.Fatalf("Expected team to be created, got %d: %s", w.Code, w.Body.String())
+	}
+
+	pr := map[string]string{"pull_request_id": "pr-idem", "pull_request_name": "Retry me", "author_id": "p1"}
+	first := doRequest(r, "POST", "/pullRequest/create", pr, idempotencyKey("create-1"))
+	if first.Code != http.StatusCreated {
+		t.Fatalf("Expected PR to be created, got %d: %s", first.Code, first.Body.String())
+	}
+
+	retry := doRequest(r, "POST", "/pullRequest/create", pr, idempotencyKey("create-1"))
+	if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() {
+		t.Errorf("Expected the stored response to be replayed, got %d: %s", retry.Code, retry.Body.String())
+	}
+	if retry.Header().Get("Idempotent-Replayed") != "true" {
+		t.Error("Expected replayed response to be marked with Idempotent-Replayed")
+	}
+
+	if w := doRequest(r, "POST", "/pullRequest/create", pr); w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), `"PR_EXISTS"`) {
+		t.Errorf("Expected retry without a key to hit PR_EXISTS, got %d: %s", w.Code, w.Body.String())
+	}
+
+	pr["pull_request_name"] = "Other body"
+	if w := doRequest(r, "POST", "/pullRequest/create", pr, idempotencyKey("create-1")); w.Code != http.StatusUnprocessableEntity || !strings.Contains(w.Body.String(), `"IDEMPOTENCY_KEY_REUSED"`) {
+		t.Errorf("Expected key reuse with another body to be rejected, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := doRequest(r, "POST", "/pullRequest/merge", map[string]string{"pull_request_id": "pr-idem"}, idempotencyKey("create-1")); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected key reuse on another endpoint to be rejected, got %d", w.Code)
+	}
+
+	// Ошибка бизнес-логики тоже сохраняется и повторяется как есть
+	missing := map[string]string{"pull_request_id": "pr-missing", "pull_request_name": "No author", "author_id": "nobody"}
+	for i := 0; i < 2; i++ {
+		if w := doRequest(r, "POST", "/pullRequest/create", missing, idempotencyKey("create-missing")); w.Code != http.StatusNotFound {
+			t.Errorf("attempt %d: expected stored 404, got %d", i, w.Code)
+		}
+	}
+
+	deactivate := map[string]string{"team_name": "Platform"}
+	for i := 0; i < 2; i++ {
+		w := doRequest(r, "POST", "/team/deactivate", deactivate, idempotencyKey("deactivate-1"))
+		var resp map[string]int
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if w.Code != http.StatusOK || resp["deactivated_users_count"] != 3 {
+			t.Errorf("attempt %d: expected the original deactivation result, got %d: %s", i, w.Code, w.Body.String())
+		}
+	}
+
+	if w := doRequest(r, "POST", "/team/deactivate", deactivate, idempotencyKey(strings.Repeat("k", 256))); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected overlong key to be rejected, got %d", w.Code)
+	}
+
+	// Повтор создания ключа возвращает тот же key_id, но открытое значение не сохраняется
+	var created, replayed struct {
+		APIKey map[string]any `json:"api_key"`
+	}
+	createKey := map[string]any{"name": "ci", "scopes": []string{domain.ScopeRead}}
+	w := doRequest(r, "POST", "/apiKeys/create", createKey, idempotencyKey("key-1"))
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if w.Code != http.StatusCreated || created.APIKey["key"] == nil {
+		t.Fatalf("Expected key to be created with its secret, got %d: %s", w.Code, w.Body.String())
+	}
+	w = doRequest(r, "POST", "/apiKeys/create", createKey, idempotencyKey("key-1"))
+	json.Unmarshal(w.Body.Bytes(), &replayed)
+	if w.Code != http.StatusCreated || w.Header().Get("Idempotent-Replayed") != "true" || replayed.APIKey["key_id"] != created.APIKey["key_id"] {
+		t.Errorf("Expected replay of the created key, got %d: %s", w.Code, w.Body.String())
+	}
+	if _, ok := replayed.APIKey["key"]; ok || strings.Contains(w.Body.String(), created.APIKey["key"].(string)) {
+		t.Errorf("Expected replay without the secret, got %s", w.Body.String())
+	}
+}
+
+// deliverGitHub отправляет записанный payload из testdata/github так же, как его подписывает GitHub
+func deliverGitHub(t *testing.T, r http.Handler, event, fixture, secret string) *httptest.ResponseRecorder {
+	t.Helper()
+	payload, err := os.ReadFile(filepath.Join("testdata", "github", fixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+
+	req := httptest.NewRequest("POST", "/webhooks/github", bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-GitHub-Event", event)
+	req.Header.Set("X-GitHub-Delivery", "72d3162e-cc78-11e3-81ab-4c9367dc0958")
+	req.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestGitHubWebhookSignature(t *testing.T) {
+	newRouter := func(secret string) http.Handler {
+		return newTestRouter(testRouterOptions{
+			authenticator: handler.NewAuthenticator(nil, true, "", ""),
+			github:        handler.NewGitHubWebhookHandler(nil, secret, domain.DefaultOrgID),
+		})
+	}
+	r := newRouter(githubWebhookSecret)
+
+	// ping не требует API-ключа и не доходит до сервиса
+	w := deliverGitHub(t, r, "ping", "ping.json", githubWebhookSecret)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"ignored"`) {
+		t.Errorf("Expected ping to be acknowledged, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := deliverGitHub(t, r, "pull_request", "pull_request.opened.json", "wrong-secret"); w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), `"INVALID_SIGNATURE"`) {
+		t.Errorf("Expected wrong signature to be rejected, got %d: %s", w.Code, w.Body.String())
+	}
+
+	req := httptest.NewRequest("POST", "/webhooks/github", strings.NewReader(`{}`))
+	req.Header.Set("X-GitHub-Event", "pull_request")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected unsigned delivery to be rejected, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest("POST", "/webhooks/github", bytes.NewReader(bytes.Repeat([]byte(" "), 5<<20+1)))
+	req.Header.Set("X-GitHub-Event", "pull_request")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected oversized delivery to be rejected with 413, got %d", w.Code)
+	}
+
+	if w := deliverGitHub(t, newRouter(""), "ping", "ping.json", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected webhook without a secret to be disabled, got %d", w.Code)
+	}
+}
+
+func TestGitHubWebhook(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	r, teardown := setup()
+	defer teardown()
+
+	team := domain.Team{TeamName: "Payments", Members: []domain.TeamMember{
+		{UserID: "gh-author", Username: "Author", IsActive: true},
+		{UserID: "gh-reviewer", Username: "Reviewer", IsActive: true},
+		{UserID: "gh-lead", Username: "Lead", IsActive: true},
+	}}
+	if w := doRequest(r, "POST", "/team/add", team); w.Code != http.StatusCreated {
+		t.Fatalf("Expected team to be created, got %d: %s", w.Code, w.Body.String())
+	}
+
+	for login, userID := range map[string]string{"Octo-Author": "gh-author", "Octo-Reviewer": "gh-reviewer", "Octo-Lead": "gh-lead"} {
+		w := doRequest(r, "POST", "/users/linkAccount", map[string]string{"user_id": userID, "provider": "github", "login": login})
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), strings.ToLower(login)) {
+			t.Fatalf("Expected %s to be linked, got %d: %s", login, w.Code, w.Body.String())
+		}
+	}
+	if w := doRequest(r, "POST", "/users/linkAccount", map[string]string{"user_id": "gh-lead", "provider": "bitbucket", "login": "lead"}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected unknown provider to be rejected, got %d", w.Code)
+	}
+
+	outcome := func(w *httptest.ResponseRecorder) domain.WebhookResult {
+		t.Helper()
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected delivery to succeed, got %d: %s", w.Code, w.Body.String())
+		}
+		var result domain.WebhookResult
+		json.Unmarshal(w.Body.Bytes(), &result)
+		return result
+	}
+	getPR := func(prID string) domain.PullRequestDetails {
+		var resp struct {
+			PR domain.PullRequestDetails `json:"pr"`
+		}
+		json.Unmarshal(doRequest(r, "GET", "/pullRequest/get?pull_request_id="+url.QueryEscape(prID), nil).Body.Bytes(), &resp)
+		return resp.PR
+	}
+
+	if result := outcome(deliverGitHub(t, r, "pull_request", "pull_request.opened.json", githubWebhookSecret)); result.Outcome != domain.WebhookApplied || result.PullRequestID != "octo-org/api#42" {
+		t.Fatalf("Expected opened PR to be created, got %+v", result)
+	}
+	pr := getPR("octo-org/api#42")
+	if pr.Author.UserID != "gh-author" || pr.PullRequestName != "Add retry to payment client" || len(pr.AssignedReviewers) != 2 {
+		t.Errorf("Expected PR by gh-author with two reviewers, got %+v", pr)
+	}
+
+	// Повторная доставка ничего не меняет
+	if result := outcome(deliverGitHub(t, r, "pull_request", "pull_request.opened.json", githubWebhookSecret)); result.Outcome != domain.WebhookIgnored {
+		t.Errorf("Expected redelivery to be ignored, got %+v", result)
+	}
+
+	if result := outcome(deliverGitHub(t, r, "pull_request", "pull_request.opened_draft.json", githubWebhookSecret)); result.Outcome != domain.WebhookIgnored {
+		t.Errorf("Expected draft PR to be ignored, got %+v", result)
+	}
+	if w := doRequest(r, "GET", "/pullRequest/get?pull_request_id="+url.QueryEscape("octo-org/api#43"), nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected draft PR not to be created, got %d", w.Code)
+	}
+
+	if result := outcome(deliverGitHub(t, r, "pull_request_review", "pull_request_review.submitted.json", githubWebhookSecret)); result.Outcome != domain.WebhookApplied {
+		t.Errorf("Expected review to be recorded, got %+v", result)
+	}
+
+	if result := outcome(deliverGitHub(t, r, "pull_request", "pull_request.closed.json", githubWebhookSecret)); result.Outcome != domain.WebhookApplied {
+		t.Errorf("Expected merge to be applied, got %+v", result)
+	}
+	pr = getPR("octo-org/api#42")
+	if pr.Status != domain.StatusMerged || pr.MergedBy != "gh-lead" {
+		t.Errorf("Expected PR merged by gh-lead, got status %s merged by %q", pr.Status, pr.MergedBy)
+	}
+	if result := outcome(deliverGitHub(t, r, "pull_request", "pull_request.closed.json", githubWebhookSecret)); result.Outcome != domain.WebhookIgnored {
+		t.Errorf("Expected repeated merge to be ignored, got %+v", result)
+	}
+}
+
+// deliverGitLab отправляет записанный payload из testdata/gitlab с секретным токеном
+func deliverGitLab(t *testing.T, r http.Handler, event, fixture, token string) *httptest.ResponseRecorder {
+	t.Helper()
+	payload, err := os.ReadFile(filepath.Join("testdata", "gitlab", fixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest("POST", "/webhooks/gitlab", bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gitlab-Event", event)
+	req.Header.Set("X-Gitlab-Event-UUID", "13792a34-cac6-4fda-95a8-c58e00a3954e")
+	req.Header.Set("X-Gitlab-Token", token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestGitLabWebhookToken(t *testing.T) {
+	r := newTestRouter(testRouterOptions{
+		authenticator: handler.NewAuthenticator(nil, true, "", ""),
+		gitlab:        handler.NewGitLabWebhookHandler(nil, gitlabWebhookToken, domain.DefaultOrgID),
+	})
+
+	if w := deliverGitLab(t, r, "Push Hook", "merge_request.open.json", gitlabWebhookToken); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"ignored"`) {
+		t.Errorf("Expected other events to be acknowledged, got %d: %s", w.Code, w.Body.String())
+	}
+	for _, token := range []string{"", "wrong-token"} {
+		if w := deliverGitLab(t, r, "Merge Request Hook", "merge_request.open.json", token); w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected token %q to be rejected, got %d", token, w.Code)
+		}
+	}
+	if w := deliverGitHub(t, r, "ping", "ping.json", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected GitHub webhook without a secret to stay disabled, got %d", w.Code)
+	}
+
+	req := httptest.NewRequest("POST", "/webhooks/gitlab", bytes.NewReader(bytes.Repeat([]byte(" "), 5<<20+1)))
+	req.Header.Set("X-Gitlab-Event", "Merge Request Hook")
+	req.Header.Set("X-Gitlab-Token", gitlabWebhookToken)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected oversized delivery to be rejected with 413, got %d", w.Code)
+	}
+}
+
+func TestGitLabWebhook(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	r, teardown := setup()
+	defer teardown()
+
+	team := domain.Team{TeamName: "Billing", Members: []domain.TeamMember{
+		{UserID: "gl-author", Username: "Author", IsActive: true},
+		{UserID: "gl-reviewer", Username: "Reviewer", IsActive: true},
+		{UserID: "gl-lead", Username: "Lead", IsActive: true},
+	}}
+	if w := doRequest(r, "POST", "/team/add", team); w.Code != http.StatusCreated {
+		t.Fatalf("Expected team to be created, got %d: %s", w.Code, w.Body.String())
+	}
+	for login, userID := range map[string]string{"dev.author": "gl-author", "dev.reviewer": "gl-reviewer", "dev.lead": "gl-lead"} {
+		if w := doRequest(r, "POST", "/users/linkAccount", map[string]string{"user_id": userID, "provider": "gitlab", "login": login}); w.Code != http.StatusOK {
+			t.Fatalf("Expected %s to be linked, got %d: %s", login, w.Code, w.Body.String())
+		}
+	}
+
+	deliver := func(fixture string) domain.WebhookResult {
+		t.Helper()
+		w := deliverGitLab(t, r, "Merge Request Hook", fixture, gitlabWebhookToken)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected %s to be accepted, got %d: %s", fixture, w.Code, w.Body.String())
+		}
+		var result domain.WebhookResult
+		json.Unmarshal(w.Body.Bytes(), &result)
+		return result
+	}
+	getPR := func(prID string) domain.PullRequestDetails {
+		var resp struct {
+			PR domain.PullRequestDetails `json:"pr"`
+		}
+		json.Unmarshal(doRequest(r, "GET", "/pullRequest/get?pull_request_id="+url.QueryEscape(prID), nil).Body.Bytes(), &resp)
+		return resp.PR
+	}
+
+	// Имя пользователя GitLab сравнивается без учёта регистра: в payload Dev.Author
+	if result := deliver("merge_request.open.json"); result.Outcome != domain.WebhookApplied || result.PullRequestID != "platform/billing!7" {
+		t.Fatalf("Expected MR to be created, got %+v", result)
+	}
+	pr := getPR("platform/billing!7")
+	if pr.Author.UserID != "gl-author" || pr.PullRequestName != "Render invoices as PDF" || len(pr.AssignedReviewers) != 2 {
+		t.Errorf("Expected MR by gl-author with two reviewers, got %+v", pr)
+	}
+	if result := deliver("merge_request.open.json"); result.Outcome != domain.WebhookIgnored {
+		t.Errorf("Expected redelivery to be ignored, got %+v", result)
+	}
+
+	if result := deliver("merge_request.open_draft.json"); result.Outcome != domain.WebhookIgnored {
+		t.Errorf("Expected draft MR to be ignored, got %+v", result)
+	}
+	if result := deliver("merge_request.update_ready.json"); result.Outcome != domain.WebhookApplied || getPR("platform/billing!8").Status != domain.StatusOpen {
+		t.Errorf("Expected MR marked as ready to be created, got %+v", result)
+	}
+	// user в payload — тот, кто снял отметку draft; автором он не считается
+	if result := deliver("merge_request.update_ready_by_other.json"); result.Outcome != domain.WebhookIgnored || getPR("platform/billing!9").PullRequestID != "" {
+		t.Errorf("Expected MR marked as ready by another user to be ignored, got %+v", result)
+	}
+
+	if result := deliver("merge_request.approved.json"); result.Outcome != domain.WebhookApplied {
+		t.Errorf("Expected approval to be recorded as review, got %+v", result)
+	}
+
+	if result := deliver("merge_request.merge.json"); result.Outcome != domain.WebhookApplied {
+		t.Errorf("Expected merge to be applied, got %+v", result)
+	}
+	pr = getPR("platform/billing!7")
+	if pr.Status != domain.StatusMerged || pr.MergedBy != "gl-lead" {
+		t.Errorf("Expected MR merged by gl-lead, got status %s merged by %q", pr.Status, pr.MergedBy)
+	}
+	if result := deliver("merge_request.merge.json"); result.Outcome != domain.WebhookIgnored {
+		t.Errorf("Expected repeated merge to be ignored, got %+v", result)
+	}
+}
+
+type githubRequest struct {
+	Method    string
+	Path      string
+	Token     string
+	Reviewers []string
+}
+
+// fakeGitHub — локальная замена REST API GitHub: записывает запросы и отвечает статусами из очереди,
+// а когда она пуста — 201
+type fakeGitHub struct {
+	*httptest.Server
+	requests chan githubRequest
+	statuses chan int
+}
+
+func newFakeGitHub(t *testing.T, statuses ...int) *fakeGitHub {
+	f := &fakeGitHub{
+		requests: make(chan githubRequest, 16),
+		statuses: make(chan int, len(statuses)),
+	}
+	for _, status := range statuses {
+		f.statuses <- status
+	}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Reviewers []string `json:"reviewers"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		f.requests <- githubRequest{
+			Method:    r.Method,
+			Path:      r.URL.Path,
+			Token:     strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "),
+			Reviewers: body.Reviewers,
+		}
+
+		status := http.StatusCreated
+		select {
+		case status = <-f.statuses:
+		default:
+		}
+		if status == http.StatusForbidden {
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("Retry-After", "0")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]string{"message": http.StatusText(status)})
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeGitHub) next(t *testing.T) githubRequest {
+	t.Helper()
+	select {
+	case req := <-f.requests:
+		return req
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected a request to the GitHub API")
+		return githubRequest{}
+	}
+}
+
+func TestGitHubClient(t *testing.T) {
+	fake := newFakeGitHub(t, http.StatusBadGateway, http.StatusForbidden, http.StatusCreated, http.StatusUnprocessableEntity)
+
+	tokens, err := github.ParseTokens("octo-org/api=repo-token, octo-org=owner-token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := github.NewClient(github.Config{
+		BaseURL:      fake.URL + "/api/v3/",
+		Token:        "default-token",
+		Tokens:       tokens,
+		Timeout:      time.Second,
+		Retries:      2,
+		RetryBackoff: time.Millisecond,
+	})
+	ctx := context.Background()
+
+	// 502 и исчерпанный лимит повторяются, третья попытка успешна
+	if err := client.RequestReviewers(ctx, "octo-org/api", 42, []string{"octocat"}); err != nil {
+		t.Fatalf("Expected retries to succeed, got %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		req := fake.next(t)
+		if req.Method != "POST" || req.Path != "/api/v3/repos/octo-org/api/pulls/42/requested_reviewers" || req.Token != "repo-token" || !slices.Equal(req.Reviewers, []string{"octocat"}) {
+			t.Errorf("attempt %d: unexpected request %+v", i, req)
+		}
+	}
+
+	// 422 (например, логин не коллаборатор) не повторяется
+	err = client.RequestReviewers(ctx, "octo-org/web", 7, []string{"outsider"})
+	if apiErr, ok := err.(*github.APIError); !ok || apiErr.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 API error, got %v", err)
+	}
+	if req := fake.next(t); req.Token != "owner-token" {
+		t.Errorf("Expected owner token for octo-org/web, got %q", req.Token)
+	}
+	select {
+	case req := <-fake.requests:
+		t.Errorf("Expected no retry after 422, got %+v", req)
+	default:
+	}
+
+	if err := client.RemoveReviewers(ctx, "someone/else", 3, []string{"octocat"}); err != nil {
+		t.Fatal(err)
+	}
+	if req := fake.next(t); req.Method != "DELETE" || req.Token != "default-token" {
+		t.Errorf("Expected DELETE with the default token, got %+v", req)
+	}
+
+	for id, ok := range map[string]bool{"octo-org/api#42": true, "pr-101": false, "octo-org/api#x": false, "a/b/c#1": false} {
+		if _, _, got := github.ParsePullRequestID(id); got != ok {
+			t.Errorf("ParsePullRequestID(%q) = %v, want %v", id, got, ok)
+		}
+	}
+}
+
+func TestGitHubReviewerSync(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	r, teardown := setup()
+	defer teardown()
+	db := connectTestDB()
+	defer db.Close()
+
+	fake := newFakeGitHub(t)
+	userRepo := repository.NewUserRepository(db)
+	userService := service.NewUserService(userRepo, repository.NewPRRepository(db), nil)
+	syncJob := jobs.NewReviewerSyncJob(github.NewClient(github.Config{BaseURL: fake.URL, Token: "token", Timeout: time.Second}), userService)
+	prService := service.NewPRService(repository.NewPRRepository(db), userRepo, syncJob, nil)
+	teamService := service.NewTeamService(repository.NewTeamRepository(db), userRepo, repository.NewPRRepository(db), syncJob, nil)
+
+	ctx, cancel := context.WithCancel(auth.WithOrg(context.Background(), domain.DefaultOrgID))
+	defer cancel()
+	go syncJob.Run(ctx)
+
+	team := domain.Team{TeamName: "Sync", Members: []domain.TeamMember{
+		{UserID: "s1", Username: "Author", IsActive: true},
+		{UserID: "s2", Username: "First", IsActive: true},
+		{UserID: "s3", Username: "Second", IsActive: true},
+		{UserID: "s4", Username: "Spare", IsActive: true},
+	}}
+	if w := doRequest(r, "POST", "/team/add", team); w.Code != http.StatusCreated {
+		t.Fatalf("Expected team to be created, got %d", w.Code)
+	}
+	// У s4 нет логина GitHub
+	for userID, login := range map[string]string{"s1": "octo-author", "s2": "octo-first", "s3": "octo-second"} {
+		if w := doRequest(r, "POST", "/users/linkAccount", map[string]string{"user_id": userID, "provider": "github", "login": login}); w.Code != http.StatusOK {
+			t.Fatalf("Expected %s to be linked, got %d: %s", login, w.Code, w.Body.String())
+		}
+	}
+
+	pr, err := prService.CreatePR(ctx, "octo-org/api#5", "Sync reviewers", "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	logins := map[string]string{"s2": "octo-first", "s3": "octo-second", "s4": ""}
+	expected := []string{}
+	for _, reviewerID := range pr.AssignedReviewers {
+		if logins[reviewerID] != "" {
+			expected = append(expected, logins[reviewerID])
+		}
+	}
+	req := fake.next(t)
+	if req.Method != "POST" || req.Path != "/repos/octo-org/api/pulls/5/requested_reviewers" || !slices.Equal(req.Reviewers, expected) {
+		t.Errorf("Expected reviewers %v to be requested, got %+v", expected, req)
+	}
+
+	// PR не из GitHub не синхронизируется
+	if _, err := prService.CreatePR(ctx, "internal-1", "Local", "s1"); err != nil {
+		t.Fatal(err)
+	}
+
+	var linked string
+	for _, reviewerID := range pr.AssignedReviewers {
+		if logins[reviewerID] != "" {
+			linked = reviewerID
+		}
+	}
+	_, newReviewerID, err := prService.ReassignReviewer(ctx, "octo-org/api#5", linked)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if login := logins[newReviewerID]; login != "" {
+		if req := fake.next(t); req.Method != "POST" || !slices.Equal(req.Reviewers, []string{login}) {
+			t.Errorf("Expected %s to be requested, got %+v", login, req)
+		}
+	}
+	if req := fake.next(t); req.Method != "DELETE" || !slices.Equal(req.Reviewers, []string{logins[linked]}) {
+		t.Errorf("Expected %s to be removed, got %+v", logins[linked], req)
+	}
+
+	// Деактивация команды снимает её ревьюверов и в GitHub; замена — единственные активные s5 и s6, логин есть только у s5
+	backup := domain.Team{TeamName: "Backup", Members: []domain.TeamMember{
+		{UserID: "s5", Username: "Backup", IsActive: true},
+		{UserID: "s6", Username: "Unlinked", IsActive: true},
+	}}
+	if w := doRequest(r, "POST", "/team/add", backup); w.Code != http.StatusCreated {
+		t.Fatalf("Expected team to be created, got %d", w.Code)
+	}
+	if w := doRequest(r, "POST", "/users/linkAccount", map[string]string{"user_id": "s5", "provider": "github", "login": "octo-backup"}); w.Code != http.StatusOK {
+		t.Fatalf("Expected octo-backup to be linked, got %d: %s", w.Code, w.Body.String())
+	}
+	current, err := prService.GetPR(ctx, "octo-org/api#5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	removed := []string{}
+	for _, reviewer := range current.AssignedReviewers {
+		if logins[reviewer.UserID] != "" {
+			removed = append(removed, logins[reviewer.UserID])
+		}
+	}
+	slices.Sort(removed)
+
+	if _, _, err := teamService.DeactivateTeam(ctx, "Sync"); err != nil {
+		t.Fatal(err)
+	}
+	if req := fake.next(t); req.Method != "POST" || !slices.Equal(req.Reviewers, []string{"octo-backup"}) {
+		t.Errorf("Expected octo-backup to be requested, got %+v", req)
+	}
+	if len(removed) > 0 {
+		req := fake.next(t)
+		slices.Sort(req.Reviewers)
+		if req.Method != "DELETE" || !slices.Equal(req.Reviewers, removed) {
+			t.Errorf("Expected %v to be removed, got %+v", removed, req)
+		}
+	}
+}
+
+type subscriberRequest struct {
+	Event     string
+	Delivery  string
+	Signature string
+	Body      []byte
+}
+
+// newSubscriber — получатель исходящих вебхуков: записывает запросы и отвечает статусами из очереди, затем 200
+func newSubscriber(t *testing.T) (*httptest.Server, chan subscriberRequest, chan int) {
+	requests := make(chan subscriberRequest, 32)
+	statuses := make(chan int, 8)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- subscriberRequest{
+			Event:     r.Header.Get("X-PR-Review-Event"),
+			Delivery:  r.Header.Get("X-PR-Review-Delivery"),
+			Signature: r.Header.Get("X-PR-Review-Signature-256"),
+			Body:      body,
+		}
+		status := http.StatusOK
+		select {
+		case status = <-statuses:
+		default:
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, requests, statuses
+}
+
+func TestSubscriptionURLValidation(t *testing.T) {
+	// Не требует БД: адрес проверяется до сохранения подписки
+	r := newTestRouter(testRouterOptions{
+		subscriptions: handler.NewSubscriptionHandler(service.NewSubscriptionService(nil, service.DeliveryOptions{Timeout: time.Second})),
+	})
+
+	for _, target := range []string{
+		"http://localhost:8080/hook",
+		"http://api.localhost/hook",
+		"http://127.0.0.1/hook",
+		"http://[::1]/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://10.0.0.5/hook",
+		"https://192.168.1.10/hook",
+		"http://100.100.100.200/hook",
+		"http://[fd00::1]/hook",
+		"http://[::ffff:127.0.0.1]/hook",
+		"http://0.0.0.0/hook",
+	} {
+		body, _ := json.Marshal(map[string]interface{}{"url": target, "secret": "s", "event_types": []string{domain.EventPRCreated}})
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("POST", "/subscriptions/create", bytes.NewReader(body)))
+		if w.Code != apperrors.ErrInvalidWebhookURL.HTTPStatus || !strings.Contains(w.Body.String(), apperrors.ErrInvalidWebhookURL.Code) {
+			t.Errorf("Expected %s to be rejected with %s, got %d: %s", target, apperrors.ErrInvalidWebhookURL.Code, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestOutgoingWebhooks(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	r, teardown := setup()
+	defer teardown()
+	db := connectTestDB()
+	defer db.Close()
+
+	subscriber, received, statuses := newSubscriber(t)
+	const secret = "subscriber-secret"
+
+	// Повторы без задержки, после второй неудачи доставка окончательно failed. Подписчик слушает loopback
+	deliveries := service.NewSubscriptionService(repository.NewSubscriptionRepository(db), service.DeliveryOptions{
+		Timeout:              time.Second,
+		MaxAttempts:          2,
+		RetryBackoff:         time.Millisecond,
+		MaxRetryBackoff:      time.Millisecond,
+		AllowPrivateNetworks: true,
+	})
+	ctx := auth.WithOrg(context.Background(), domain.DefaultOrgID)
+	deliverDue := func() int {
+		t.Helper()
+		time.Sleep(5 * time.Millisecond)
+		sent, err := deliveries.DeliverDue(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sent
+	}
+	next := func(eventType string) domain.Event {
+		t.Helper()
+		var req subscriberRequest
+		select {
+		case req = <-received:
+		default:
+			t.Fatalf("Expected %s to be delivered", eventType)
+		}
+		if req.Event != eventType || req.Delivery == "" {
+			t.Errorf("Expected %s headers, got event %q delivery %q", eventType, req.Event, req.Delivery)
+		}
+		if req.Signature != service.SignPayload(secret, req.Body) {
+			t.Errorf("Signature %q does not match the body", req.Signature)
+		}
+		var event domain.Event
+		if err := json.Unmarshal(req.Body, &event); err != nil || event.Type != eventType || event.EventID == "" {
+			t.Errorf("Expected %s event, got %s", eventType, req.Body)
+		}
+		return event
+	}
+
+	listDeliveries := func(query string) []domain.Delivery {
+		t.Helper()
+		w := doRequest(r, "GET", "/subscriptions/deliveries?"+query, nil)
+		var resp struct {
+			Deliveries []domain.Delivery `json:"deliveries"`
+		}
+		if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), &resp) != nil {
+			t.Fatalf("Expected delivery log, got %d: %s", w.Code, w.Body.String())
+		}
+		return resp.Deliveries
+	}
+
+	invalid := []map[string]interface{}{
+		{"url": "ftp://example.com/hook", "secret": secret, "event_types": []string{domain.EventPRCreated}},
+		{"url": subscriber.URL, "secret": secret, "event_types": []string{"pr.closed"}},
+		{"url": subscriber.URL, "secret": secret, "event_types": []string{}},
+		{"url": subscriber.URL, "event_types": []string{domain.EventPRCreated}},
+	}
+	for _, body := range invalid {
+		if w := doRequest(r, "POST", "/subscriptions/create", body); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected %v to be rejected, got %d: %s", body, w.Code, w.Body.String())
+		}
+	}
+
+	w := doRequest(r, "POST", "/subscriptions/create", map[string]interface{}{
+		"url":         subscriber.URL,
+		"secret":      secret,
+		"event_types": []string{domain.EventPRCreated, domain.EventUserActivityChanged, domain.EventTeamDeactivated},
+	})
+	if w.Code != http.StatusCreated || strings.Contains(w.Body.String(), secret) {
+		t.Fatalf("Expected subscription without secret, got %d: %s", w.Code, w.Body.String())
+	}
+	var created struct {
+		Subscription domain.Subscription `json:"subscription"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &created)
+
+	team := domain.Team{TeamName: "Hooks", Members: []domain.TeamMember{
+		{UserID: "wh1", Username: "Author", IsActive: true},
+		{UserID: "wh2", Username: "Reviewer", IsActive: true},
+	}}
+	if w := doRequest(r, "POST", "/team/add", team); w.Code != http.StatusCreated {
+		t.Fatalf("Expected team to be created, got %d", w.Code)
+	}
+
+	// pr.created приходит со второй попытки; на merge и назначение ревьювера подписки нет
+	doRequest(r, "POST", "/pullRequest/create", map[string]string{"pull_request_id": "wh-pr-1", "pull_request_name": "Hooks", "author_id": "wh1"})
+	doRequest(r, "POST", "/pullRequest/merge", map[string]string{"pull_request_id": "wh-pr-1"})
+	statuses <- http.StatusInternalServerError
+	if sent := deliverDue(); sent != 1 {
+		t.Fatalf("Expected one delivery, sent %d", sent)
+	}
+	first := next(domain.EventPRCreated)
+	pending := listDeliveries("status=pending")
+	if len(pending) != 1 || pending[0].Attempts != 1 || pending[0].LastStatusCode == nil || *pending[0].LastStatusCode != 500 {
+		t.Fatalf("Expected the failed delivery to wait for a retry, got %+v", pending)
+	}
+	deliverDue()
+	if retried := next(domain.EventPRCreated); retried.EventID != first.EventID {
+		t.Errorf("Expected the same event on retry, got %s and %s", first.EventID, retried.EventID)
+	}
+	if data, _ := json.Marshal(first.Data); !strings.Contains(string(data), `"pull_request_id":"wh-pr-1"`) {
+		t.Errorf("Expected PR in event data, got %s", data)
+	}
+
+	// Повторная установка того же значения не создаёт события
+	doRequest(r, "POST", "/users/setIsActive", map[string]interface{}{"user_id": "wh2", "is_active": false})
+	doRequest(r, "POST", "/users/setIsActive", map[string]interface{}{"user_id": "wh2", "is_active": false})
+	deliverDue()
+	next(domain.EventUserActivityChanged)
+	select {
+	case req := <-received:
+		t.Errorf("Expected a single event, got %s", req.Event)
+	default:
+	}
+
+	// После MaxAttempts неудач доставка остаётся в журнале как failed
+	statuses <- http.StatusBadGateway
+	statuses <- http.StatusBadGateway
+	doRequest(r, "POST", "/team/deactivate", map[string]string{"team_name": "Hooks"})
+	deliverDue()
+	next(domain.EventTeamDeactivated)
+	deliverDue()
+	next(domain.EventTeamDeactivated)
+	failed := listDeliveries("status=failed&subscription_id=" + created.Subscription.SubscriptionID)
+	if len(failed) != 1 || failed[0].Attempts != 2 || failed[0].EventType != domain.EventTeamDeactivated {
+		t.Fatalf("Expected a failed team.deactivated delivery, got %+v", failed)
+	}
+
+	w = doRequest(r, "POST", "/subscriptions/redeliver", map[string]int64{"delivery_id": failed[0].DeliveryID})
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected redelivery to be queued, got %d: %s", w.Code, w.Body.String())
+	}
+	deliverDue()
+	redelivered := next(domain.EventTeamDeactivated)
+	if redelivered.EventID != failed[0].EventID {
+		t.Errorf("Expected redelivery of %s, got %s", failed[0].EventID, redelivered.EventID)
+	}
+	if log := listDeliveries(""); len(log) != 4 || log[0].Status != domain.DeliveryDelivered || log[1].Status != domain.DeliveryFailed {
+		t.Errorf("Expected 4 deliveries, newest delivered, got %+v", log)
+	}
+	if w := doRequest(r, "POST", "/subscriptions/redeliver", map[string]int64{"delivery_id": failed[0].DeliveryID + 100}); w.Code != http.StatusNotFound {
+		t.Errorf("Expected unknown delivery to be 404, got %d", w.Code)
+	}
+
+	// Без AllowPrivateNetworks соединение с loopback-адресом подписчика не устанавливается
+	guarded := service.NewSubscriptionService(repository.NewSubscriptionRepository(db), service.DeliveryOptions{
+		Timeout:         time.Second,
+		MaxAttempts:     2,
+		RetryBackoff:    time.Hour,
+		MaxRetryBackoff: time.Hour,
+	})
+	doRequest(r, "POST", "/subscriptions/redeliver", map[string]int64{"delivery_id": failed[0].DeliveryID})
+	time.Sleep(5 * time.Millisecond)
+	if sent, err := guarded.DeliverDue(ctx); err != nil || sent != 1 {
+		t.Fatalf("Expected one delivery attempt, sent %d: %v", sent, err)
+	}
+	select {
+	case req := <-received:
+		t.Errorf("Expected loopback subscriber to be unreachable, got %s", req.Event)
+	default:
+	}
+	if blocked := listDeliveries("status=pending"); len(blocked) != 1 || !strings.Contains(blocked[0].LastError, "not a public address") {
+		t.Errorf("Expected the delivery to be refused at dial time, got %+v", blocked)
+	}
+
+	// Удалённая подписка больше не получает событий
+	if w := doRequest(r, "POST", "/subscriptions/delete", map[string]string{"subscription_id": created.Subscription.SubscriptionID}); w.Code != http.StatusOK {
+		t.Fatalf("Expected subscription to be deleted, got %d", w.Code)
+	}
+	doRequest(r, "POST", "/users/setIsActive", map[string]interface{}{"user_id": "wh1", "is_active": true})
+	if sent := deliverDue(); sent != 0 {
+		t.Errorf("Expected nothing to deliver after deletion, sent %d", sent)
+	}
+}
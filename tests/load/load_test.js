@@ -5,6 +5,9 @@ import { SharedArray } from 'k6/data';
 
 const errorRate = new Rate('errors');
 const BASE_URL = 'http://localhost:8080';
+// Ключ со scope read, pr:write и team:admin: go run ./cmd/server apikey create k6 read,pr:write,team:admin
+const AUTH_HEADERS = { 'X-API-Key': __ENV.API_KEY || '' };
+const JSON_HEADERS = { ...AUTH_HEADERS, 'Content-Type': 'application/json' };
 
 export const options = {
   stages: [
@@ -52,7 +55,7 @@ export function setup() {
     const response = http.post(
       `${BASE_URL}/team/add`,
       JSON.stringify({ team_name: teamName, members }),
-      { headers: { 'Content-Type': 'application/json' } }
+      { headers: JSON_HEADERS }
     );
     
     const created = check(response, { 'team created': (r) => r.status === 201 });
@@ -72,7 +75,7 @@ export function setup() {
         pull_request_name: `Setup PR ${i}`,
         author_id: authorId,
       }),
-      { headers: { 'Content-Type': 'application/json' } }
+      { headers: JSON_HEADERS }
     );
     
     if (response.status === 201) {
@@ -119,7 +122,7 @@ function testCreatePR(data) {
       pull_request_name: `Test PR ${prId}`,
       author_id: authorId,
     }),
-    { headers: { 'Content-Type': 'application/json' } }
+    { headers: JSON_HEADERS }
   );
   
   const success = check(response, {
@@ -133,7 +136,7 @@ function testCreatePR(data) {
 function testGetTeam(data) {
   const teamName = data.teamNames[Math.floor(Math.random() * data.teamNames.length)];
   
-  const response = http.get(`${BASE_URL}/team/get?team_name=${teamName}`);
+  const response = http.get(`${BASE_URL}/team/get?team_name=${teamName}`, { headers: AUTH_HEADERS });
   
   const success = check(response, {
     'team retrieved': (r) => r.status === 200,
@@ -152,7 +155,7 @@ function testSetIsActive(data) {
       user_id: userId,
       is_active: Math.random() > 0.5,
     }),
-    { headers: { 'Content-Type': 'application/json' } }
+    { headers: JSON_HEADERS }
   );
   
   const success = check(response, {
@@ -166,7 +169,7 @@ function testSetIsActive(data) {
 function testGetReview(data) {
   const userId = data.userIds[Math.floor(Math.random() * data.userIds.length)];
   
-  const response = http.get(`${BASE_URL}/users/getReview?user_id=${userId}`);
+  const response = http.get(`${BASE_URL}/users/getReview?user_id=${userId}`, { headers: AUTH_HEADERS });
   
   const success = check(response, {
     'reviews retrieved': (r) => r.status === 200,
@@ -184,7 +187,7 @@ function testMergePR(data) {
   const response = http.post(
     `${BASE_URL}/pullRequest/merge`,
     JSON.stringify({ pull_request_id: prId }),
-    { headers: { 'Content-Type': 'application/json' } }
+    { headers: JSON_HEADERS }
   );
   
   // 200 = success, 404 = already merged or doesn't exist (acceptable in concurrent test)
@@ -199,7 +202,7 @@ function testMergePR(data) {
 
 
 function testGetStats() {
-  const response = http.get(`${BASE_URL}/stats`);
+  const response = http.get(`${BASE_URL}/stats`, { headers: AUTH_HEADERS });
   
   const success = check(response, {
     'stats retrieved': (r) => r.status === 200,